@@ -5,9 +5,11 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"factorbacktest/internal"
 	"factorbacktest/internal/app"
 	"factorbacktest/internal/auth"
+	"factorbacktest/internal/calculator"
 	"factorbacktest/internal/data"
 	"factorbacktest/internal/db/models/postgres/public/model"
 	"factorbacktest/internal/logger"
@@ -46,6 +48,7 @@ type ApiHandler struct {
 	InvestmentRepository         repository.InvestmentRepository
 	TradingService               service.TradeService
 	StrategyService              service.StrategyService
+	FactorAnalysisService        service.FactorAnalysisService
 	StrategySummaryApp           app.StrategySummaryApp
 
 	// AuthService is the custom Go auth package that owns /auth/* and the
@@ -144,6 +147,11 @@ func (m ApiHandler) InitializeRouterEngine(ctx context.Context) *gin.Engine {
 	engine.POST("/benchmark", m.benchmark)
 	engine.POST("/contact", m.contact)
 	engine.POST("/constructFactorEquation", m.constructFactorEquation)
+	engine.POST("/factorQuantiles", m.factorQuantiles)
+	engine.POST("/factorIC", m.factorIC)
+	engine.POST("/backtestSweep", m.backtestSweep)
+	engine.GET("/stressScenarios", m.getStressScenarios)
+	engine.POST("/strategies/:strategyID/stressTest", m.stressTest)
 	engine.GET("/usageStats", func(ctx *gin.Context) {
 		result, err := repository.GetUsageStats(m.Db)
 		if err != nil {
@@ -157,6 +165,7 @@ func (m ApiHandler) InitializeRouterEngine(ctx context.Context) *gin.Engine {
 	engine.POST("/backtestBondPortfolio", m.backtestBondPortfolio)
 	engine.POST("/updatePrices", m.updatePrices)
 	engine.POST("/addAssetsToUniverse", m.addAssetsToUniverse)
+	engine.POST("/universes/:universeName/membershipHistory", m.loadUniverseMembership)
 	engine.POST("/bookmarkStrategy", m.bookmarkStrategy)
 	engine.POST("/isStrategyBookmarked", m.isStrategyBookmarked)
 	engine.GET("/savedStrategies", m.getSavedStrategies)
@@ -213,9 +222,18 @@ func returnErrorJson(err error, c *gin.Context) {
 func returnErrorJsonCode(err error, c *gin.Context, code int) {
 	lg := logger.FromContext(c)
 	lg.Errorf("[%d] %s", code, err.Error())
-	c.AbortWithStatusJSON(code, gin.H{
+	body := gin.H{
 		"error": err.Error(),
-	})
+	}
+	// lets the UI underline the part of the expression that's wrong
+	var expressionErr *calculator.ExpressionError
+	if errors.As(err, &expressionErr) {
+		body["position"] = gin.H{
+			"start": expressionErr.Start,
+			"end":   expressionErr.End,
+		}
+	}
+	c.AbortWithStatusJSON(code, body)
 }
 
 func blockBots(c *gin.Context) {
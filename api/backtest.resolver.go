@@ -5,6 +5,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"factorbacktest/internal"
 	"factorbacktest/internal/calculator"
 	"factorbacktest/internal/db/models/postgres/public/model"
 	"factorbacktest/internal/domain"
@@ -15,7 +16,6 @@ import (
 	"factorbacktest/internal/util"
 	"fmt"
 	"regexp"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -37,6 +37,138 @@ type BacktestRequest struct {
 
 	NumSymbols int     `json:"numSymbols"`
 	UserID     *string `json:"userID"`
+
+	TransactionCosts *TransactionCostOptions `json:"transactionCosts"`
+	// trading days between scoring and filling trades
+	ExecutionDelayDays int `json:"executionDelayDays"`
+	// "adjusted" (default), "cash" or "reinvest"
+	DividendMode string `json:"dividendMode"`
+	// optional - goes long the top numSymbols and short
+	// the bottom numShortSymbols
+	LongShort *LongShortOptions `json:"longShort"`
+	// symbol to compare against. defaults to SPY
+	Benchmark string `json:"benchmark"`
+	// optional - reports in-sample and out-of-sample windows separately
+	WalkForward *WalkForwardOptions `json:"walkForward"`
+	// optional - resamples returns to put error bars on the results
+	Bootstrap *BootstrapOptions `json:"bootstrap"`
+	// optional - narrows assetUniverse on each rebalance date
+	UniverseFilter *UniverseFilterOptions `json:"universeFilter"`
+	// optional - limits on position sizes, saved with the strategy
+	// so investments rebalance the same way
+	Constraints *PositionConstraintsOptions `json:"constraints"`
+	// optional - defaults to the z-score tilt. saved with the strategy
+	Weighting *WeightingOptions `json:"weighting"`
+}
+
+type WeightingOptions struct {
+	// zScoreTilt, equal, scoreProportional, rank,
+	// inverseVolatility or marketCap
+	Scheme string `json:"scheme"`
+	// zScoreTilt only, between 0 and 1
+	Intensity float64 `json:"intensity"`
+}
+
+func (o WeightingOptions) toInternal() internal.WeightingOptions {
+	return internal.WeightingOptions{
+		Scheme:    internal.WeightingScheme(o.Scheme),
+		Intensity: o.Intensity,
+	}
+}
+
+type PositionConstraintsOptions struct {
+	// fractions of portfolio value
+	MaxWeight         float64 `json:"maxWeight"`
+	MinWeight         float64 `json:"minWeight"`
+	MaxNamesPerSector int     `json:"maxNamesPerSector"`
+	// smaller positions are dropped, lowest score first
+	MinPositionDollars float64 `json:"minPositionDollars"`
+	// fraction of portfolio value held as cash
+	CashBuffer float64 `json:"cashBuffer"`
+}
+
+func (o PositionConstraintsOptions) toInternal() internal.PositionConstraints {
+	return internal.PositionConstraints{
+		MaxWeight:          o.MaxWeight,
+		MinWeight:          o.MinWeight,
+		MaxNamesPerSector:  o.MaxNamesPerSector,
+		MinPositionDollars: o.MinPositionDollars,
+		CashBuffer:         o.CashBuffer,
+	}
+}
+
+type UniverseFilterOptions struct {
+	// e.g. price(currentDate) > 5 && stdev(nYearsAgo(1), currentDate) < 0.6
+	Condition string `json:"condition"`
+	// keeps the top names by this expression, e.g. marketCap(currentDate)
+	RankBy string `json:"rankBy"`
+	Top    int    `json:"top"`
+}
+
+func (o UniverseFilterOptions) toService() service.UniverseFilter {
+	return service.UniverseFilter{
+		Condition: o.Condition,
+		RankBy:    o.RankBy,
+		Top:       o.Top,
+	}
+}
+
+// caps memory, since every simulated path is kept for the fan chart
+const maxBootstrapSimulations = 2000
+
+type BootstrapOptions struct {
+	NumSimulations int   `json:"numSimulations"`
+	BlockSize      int   `json:"blockSize"`
+	Seed           int64 `json:"seed"`
+	// resample returns between rebalances instead of daily returns
+	RebalancePeriods bool `json:"rebalancePeriods"`
+}
+
+func (o BootstrapOptions) Validate() error {
+	if o.NumSimulations > maxBootstrapSimulations {
+		return fmt.Errorf("number of simulations cannot be more than %d", maxBootstrapSimulations)
+	}
+	return calculator.BootstrapOptions{
+		NumSimulations: o.NumSimulations,
+		BlockSize:      o.BlockSize,
+	}.Validate()
+}
+
+type WalkForwardOptions struct {
+	InSampleMonths    int  `json:"inSampleMonths"`
+	OutOfSampleMonths int  `json:"outOfSampleMonths"`
+	Anchored          bool `json:"anchored"`
+}
+
+func (o WalkForwardOptions) toService() service.WalkForwardOptions {
+	return service.WalkForwardOptions{
+		InSampleMonths:    o.InSampleMonths,
+		OutOfSampleMonths: o.OutOfSampleMonths,
+		Anchored:          o.Anchored,
+	}
+}
+
+type LongShortOptions struct {
+	NumShortSymbols int     `json:"numShortSymbols"`
+	GrossExposure   float64 `json:"grossExposure"`
+	NetExposure     float64 `json:"netExposure"`
+	// annualized, in basis points of short market value
+	BorrowCostBps float64 `json:"borrowCostBps"`
+}
+
+func (o LongShortOptions) toInternal() internal.LongShortOptions {
+	return internal.LongShortOptions{
+		NumShortTickers: o.NumShortSymbols,
+		GrossExposure:   o.GrossExposure,
+		NetExposure:     o.NetExposure,
+	}
+}
+
+type TransactionCostOptions struct {
+	CommissionPerShare           float64 `json:"commissionPerShare"`
+	NotionalBps                  float64 `json:"notionalBps"`
+	FixedFeePerOrder             float64 `json:"fixedFeePerOrder"`
+	SlippageVolatilityMultiplier float64 `json:"slippageVolatilityMultiplier"`
 }
 
 type BacktestResponse struct {
@@ -47,6 +179,329 @@ type BacktestResponse struct {
 	SharpeRatio      *float64                            `json:"sharpeRatio"`
 	AnnualizedReturn *float64                            `json:"annualizedReturn"`
 	AnnualizedStdev  *float64                            `json:"annualizedStandardDeviation"`
+	Metrics          *BacktestMetrics                    `json:"metrics"`
+	BenchmarkStats   *BenchmarkStats                     `json:"benchmarkStats"`
+	Overfitting      *StrategyOverfitting                `json:"overfitting"`
+
+	TotalTransactionCosts float64 `json:"totalTransactionCosts"`
+	TotalIncome           float64 `json:"totalIncome"`
+	TotalBorrowCosts      float64 `json:"totalBorrowCosts"`
+
+	// daily, unlike snapshots which only exist on rebalance days
+	EquityCurve []EquityCurvePoint `json:"equityCurve"`
+	Turnover    TurnoverReport     `json:"turnover"`
+	WalkForward *WalkForwardReport `json:"walkForward"`
+	Bootstrap   *BootstrapReport   `json:"bootstrap"`
+	// date -> symbols that passed the universe filter,
+	// only set when the request had one
+	UniverseMembership map[string][]string `json:"universeMembership"`
+}
+
+// StrategyOverfitting adjusts the sharpe for the other variants the user
+// backtested on the same universe and window
+type StrategyOverfitting struct {
+	// including this backtest
+	NumTrials         int     `json:"numTrials"`
+	ExpectedMaxSharpe float64 `json:"expectedMaxSharpe"`
+	// probability the true sharpe is above the best we'd
+	// expect from NumTrials tries by luck alone
+	DeflatedSharpeRatio float64 `json:"deflatedSharpeRatio"`
+}
+
+type BootstrapReport struct {
+	NumSimulations   int                `json:"numSimulations"`
+	RebalancePeriods bool               `json:"rebalancePeriods"`
+	AnnualizedReturn ConfidenceInterval `json:"annualizedReturn"`
+	SharpeRatio      ConfidenceInterval `json:"sharpeRatio"`
+	MaxDrawdown      ConfidenceInterval `json:"maxDrawdown"`
+	FanChart         []FanChartPoint    `json:"fanChart"`
+}
+
+// 5th, 50th and 95th percentiles
+type ConfidenceInterval struct {
+	Lower  float64 `json:"lower"`
+	Median float64 `json:"median"`
+	Upper  float64 `json:"upper"`
+}
+
+type FanChartPoint struct {
+	Date string  `json:"date"`
+	P5   float64 `json:"p5"`
+	P25  float64 `json:"p25"`
+	P50  float64 `json:"p50"`
+	P75  float64 `json:"p75"`
+	P95  float64 `json:"p95"`
+}
+
+func toConfidenceInterval(in calculator.ConfidenceInterval) ConfidenceInterval {
+	return ConfidenceInterval{
+		Lower:  in.Lower,
+		Median: in.Median,
+		Upper:  in.Upper,
+	}
+}
+
+func (h ApiHandler) bootstrap(ctx context.Context, result service.BacktestResponse, options BootstrapOptions) (*BootstrapReport, error) {
+	curve := result.EquityCurve
+	calculatorOptions := calculator.BootstrapOptions{
+		NumSimulations: options.NumSimulations,
+		BlockSize:      options.BlockSize,
+		Seed:           options.Seed,
+	}
+	if options.RebalancePeriods {
+		// value on each rebalance, plus the end of the backtest
+		curve = []service.EquityPoint{}
+		for i, p := range result.EquityCurve {
+			_, isRebalance := result.Snapshots[p.Date.Format(time.DateOnly)]
+			if isRebalance || i == len(result.EquityCurve)-1 {
+				curve = append(curve, p)
+			}
+		}
+		if len(curve) >= 2 {
+			numYears := curve[len(curve)-1].Date.Sub(curve[0].Date).Hours() / (365 * 24)
+			calculatorOptions.PeriodsPerYear = float64(len(curve)-1) / numYears
+		}
+	}
+
+	bootstrap, err := h.StrategyService.Bootstrap(ctx, curve, calculatorOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &BootstrapReport{
+		NumSimulations:   bootstrap.NumSimulations,
+		RebalancePeriods: options.RebalancePeriods,
+		AnnualizedReturn: toConfidenceInterval(bootstrap.AnnualizedReturn),
+		SharpeRatio:      toConfidenceInterval(bootstrap.SharpeRatio),
+		MaxDrawdown:      toConfidenceInterval(bootstrap.MaxDrawdown),
+		FanChart:         []FanChartPoint{},
+	}
+	for _, p := range bootstrap.FanChart {
+		out.FanChart = append(out.FanChart, FanChartPoint{
+			Date: p.Date.Format(time.DateOnly),
+			P5:   p.P5,
+			P25:  p.P25,
+			P50:  p.P50,
+			P75:  p.P75,
+			P95:  p.P95,
+		})
+	}
+	return out, nil
+}
+
+type WalkForwardReport struct {
+	Folds []WalkForwardFold `json:"folds"`
+	// averaged across folds
+	MeanInSampleSharpe    float64 `json:"meanInSampleSharpe"`
+	MeanOutOfSampleSharpe float64 `json:"meanOutOfSampleSharpe"`
+	// out-of-sample sharpe over in-sample sharpe. 1 means no
+	// degradation, and less than 0 means the sign flipped
+	SharpeRetention          *float64           `json:"sharpeRetention"`
+	StitchedOutOfSample      []EquityCurvePoint `json:"stitchedOutOfSample"`
+	StitchedOutOfSampleStats WindowMetrics      `json:"stitchedOutOfSampleStats"`
+}
+
+type WalkForwardFold struct {
+	InSampleStart    string        `json:"inSampleStart"`
+	OutOfSampleStart string        `json:"outOfSampleStart"`
+	OutOfSampleEnd   string        `json:"outOfSampleEnd"`
+	InSample         WindowMetrics `json:"inSample"`
+	OutOfSample      WindowMetrics `json:"outOfSample"`
+}
+
+type WindowMetrics struct {
+	AnnualizedReturn float64 `json:"annualizedReturn"`
+	AnnualizedStdev  float64 `json:"annualizedStandardDeviation"`
+	SharpeRatio      float64 `json:"sharpeRatio"`
+	MaxDrawdown      float64 `json:"maxDrawdown"`
+}
+
+func (h ApiHandler) windowMetrics(ctx context.Context, curve []service.EquityPoint) (*WindowMetrics, error) {
+	m, err := h.StrategyService.CalculateMetrics(ctx, curve)
+	if err != nil {
+		return nil, err
+	}
+	return &WindowMetrics{
+		AnnualizedReturn: m.AnnualizedReturn,
+		AnnualizedStdev:  m.AnnualizedStdev,
+		SharpeRatio:      m.SharpeRatio,
+		MaxDrawdown:      m.MaxDrawdown.Depth,
+	}, nil
+}
+
+func (h ApiHandler) toWalkForwardReport(ctx context.Context, in service.WalkForwardResult) (*WalkForwardReport, error) {
+	out := &WalkForwardReport{
+		Folds:               []WalkForwardFold{},
+		StitchedOutOfSample: toEquityCurvePoints(in.StitchedOutOfSample),
+	}
+	inSampleTotal, outOfSampleTotal := 0.0, 0.0
+	for _, fold := range in.Folds {
+		inSample, err := h.windowMetrics(ctx, fold.InSample)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate in-sample metrics from %s: %w", fold.InSampleStart.Format(time.DateOnly), err)
+		}
+		outOfSample, err := h.windowMetrics(ctx, fold.OutOfSample)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate out-of-sample metrics from %s: %w", fold.OutOfSampleStart.Format(time.DateOnly), err)
+		}
+		inSampleTotal += inSample.SharpeRatio
+		outOfSampleTotal += outOfSample.SharpeRatio
+		out.Folds = append(out.Folds, WalkForwardFold{
+			InSampleStart:    fold.InSampleStart.Format(time.DateOnly),
+			OutOfSampleStart: fold.OutOfSampleStart.Format(time.DateOnly),
+			OutOfSampleEnd:   fold.OutOfSampleEnd.Format(time.DateOnly),
+			InSample:         *inSample,
+			OutOfSample:      *outOfSample,
+		})
+	}
+	out.MeanInSampleSharpe = inSampleTotal / float64(len(in.Folds))
+	out.MeanOutOfSampleSharpe = outOfSampleTotal / float64(len(in.Folds))
+	if out.MeanInSampleSharpe != 0 {
+		retention := out.MeanOutOfSampleSharpe / out.MeanInSampleSharpe
+		out.SharpeRetention = &retention
+	}
+
+	stitched, err := h.windowMetrics(ctx, in.StitchedOutOfSample)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate stitched out-of-sample metrics: %w", err)
+	}
+	out.StitchedOutOfSampleStats = *stitched
+
+	return out, nil
+}
+
+type TurnoverReport struct {
+	AnnualizedTurnover       float64             `json:"annualizedTurnover"`
+	AverageHoldingPeriodDays *float64            `json:"averageHoldingPeriodDays"`
+	Rebalances               []RebalanceTurnover `json:"rebalances"`
+	Trades                   []TradeRecord       `json:"trades"`
+}
+
+type RebalanceTurnover struct {
+	Date       string  `json:"date"`
+	Turnover   float64 `json:"turnover"`
+	NumEntered int     `json:"numEntered"`
+	NumExited  int     `json:"numExited"`
+	NumTrades  int     `json:"numTrades"`
+}
+
+type TradeRecord struct {
+	Date     string  `json:"date"`
+	Symbol   string  `json:"symbol"`
+	Quantity float64 `json:"quantity"`
+	Price    float64 `json:"price"`
+	Amount   float64 `json:"amount"`
+}
+
+func toTurnoverReport(in service.TurnoverReport) TurnoverReport {
+	out := TurnoverReport{
+		AnnualizedTurnover:       in.AnnualizedTurnover,
+		AverageHoldingPeriodDays: in.AverageHoldingPeriodDays,
+		Rebalances:               []RebalanceTurnover{},
+		Trades:                   []TradeRecord{},
+	}
+	for _, r := range in.Rebalances {
+		out.Rebalances = append(out.Rebalances, RebalanceTurnover{
+			Date:       r.Date.Format(time.DateOnly),
+			Turnover:   r.Turnover,
+			NumEntered: r.NumEntered,
+			NumExited:  r.NumExited,
+			NumTrades:  r.NumTrades,
+		})
+	}
+	for _, t := range in.Trades {
+		out.Trades = append(out.Trades, TradeRecord{
+			Date:     t.Date.Format(time.DateOnly),
+			Symbol:   t.Symbol,
+			Quantity: t.Quantity,
+			Price:    t.Price,
+			Amount:   t.Amount,
+		})
+	}
+	return out
+}
+
+// BacktestMetrics holds risk stats beyond sharpe, return and stdev.
+// returns are fractions, not percents
+type BacktestMetrics struct {
+	SortinoRatio             *float64 `json:"sortinoRatio"`
+	DownsideDeviation        float64  `json:"downsideDeviation"`
+	MaxDrawdown              float64  `json:"maxDrawdown"`
+	MaxDrawdownPeak          string   `json:"maxDrawdownPeak"`
+	MaxDrawdownTrough        string   `json:"maxDrawdownTrough"`
+	MaxDrawdownRecovery      *string  `json:"maxDrawdownRecovery"`
+	CalmarRatio              *float64 `json:"calmarRatio"`
+	Skewness                 float64  `json:"skewness"`
+	Kurtosis                 float64  `json:"kurtosis"`
+	BestPeriodReturn         float64  `json:"bestPeriodReturn"`
+	BestPeriodDate           string   `json:"bestPeriodDate"`
+	WorstPeriodReturn        float64  `json:"worstPeriodReturn"`
+	WorstPeriodDate          string   `json:"worstPeriodDate"`
+	PercentPositivePeriods   float64  `json:"percentPositivePeriods"`
+	ValueAtRisk95            float64  `json:"valueAtRisk95"`
+	ConditionalValueAtRisk95 float64  `json:"conditionalValueAtRisk95"`
+}
+
+func toBacktestMetrics(m *calculator.CalculateMetricsResult) *BacktestMetrics {
+	// metrics failed to calculate
+	if m == nil || m.BestPeriod.Date.IsZero() {
+		return nil
+	}
+	var recovery *string
+	if m.MaxDrawdown.Recovery != nil {
+		recovery = strPtr(m.MaxDrawdown.Recovery.Format(time.DateOnly))
+	}
+	return &BacktestMetrics{
+		SortinoRatio:             m.SortinoRatio,
+		DownsideDeviation:        m.DownsideDeviation,
+		MaxDrawdown:              m.MaxDrawdown.Depth,
+		MaxDrawdownPeak:          m.MaxDrawdown.Peak.Format(time.DateOnly),
+		MaxDrawdownTrough:        m.MaxDrawdown.Trough.Format(time.DateOnly),
+		MaxDrawdownRecovery:      recovery,
+		CalmarRatio:              m.CalmarRatio,
+		Skewness:                 m.Skewness,
+		Kurtosis:                 m.Kurtosis,
+		BestPeriodReturn:         m.BestPeriod.Return,
+		BestPeriodDate:           m.BestPeriod.Date.Format(time.DateOnly),
+		WorstPeriodReturn:        m.WorstPeriod.Return,
+		WorstPeriodDate:          m.WorstPeriod.Date.Format(time.DateOnly),
+		PercentPositivePeriods:   m.PercentPositivePeriods,
+		ValueAtRisk95:            m.ValueAtRisk95,
+		ConditionalValueAtRisk95: m.ConditionalValueAtRisk95,
+	}
+}
+
+type BenchmarkStats struct {
+	Symbol           string   `json:"symbol"`
+	Beta             float64  `json:"beta"`
+	Alpha            float64  `json:"alpha"`
+	TrackingError    float64  `json:"trackingError"`
+	InformationRatio *float64 `json:"informationRatio"`
+	UpCapture        *float64 `json:"upCapture"`
+	DownCapture      *float64 `json:"downCapture"`
+	Correlation      float64  `json:"correlation"`
+}
+
+type EquityCurvePoint struct {
+	Date               string  `json:"date"`
+	Value              float64 `json:"value"`
+	ValuePercentChange float64 `json:"valuePercentChange"`
+}
+
+func toEquityCurvePoints(curve []service.EquityPoint) []EquityCurvePoint {
+	out := []EquityCurvePoint{}
+	for _, p := range curve {
+		pc := 0.0
+		if curve[0].Value != 0 {
+			pc = 100 * (p.Value - curve[0].Value) / curve[0].Value
+		}
+		out = append(out, EquityCurvePoint{
+			Date:               p.Date.Format(time.DateOnly),
+			Value:              p.Value,
+			ValuePercentChange: pc,
+		})
+	}
+	return out
 }
 
 type LatestHoldings struct {
@@ -163,10 +618,70 @@ func parseBacktestRequest(c *gin.Context) (*BacktestRequest, error) {
 	if backtestEndDate.Before(backtestStartDate) {
 		return nil, fmt.Errorf("end date cannot be before start date")
 	}
+	if err := calculator.ValidateFactorExpression(requestBody.FactorOptions.Expression); err != nil {
+		return nil, fmt.Errorf("invalid factor expression: %w", err)
+	}
+	if requestBody.ExecutionDelayDays < 0 {
+		return nil, fmt.Errorf("execution delay cannot be negative")
+	}
+	if _, err := domain.ParseRebalanceSchedule(requestBody.SamplingIntervalUnit); err != nil {
+		return nil, err
+	}
+	if _, err := parseDividendMode(requestBody.DividendMode); err != nil {
+		return nil, err
+	}
+	if ls := requestBody.LongShort; ls != nil {
+		if err := ls.toInternal().Validate(); err != nil {
+			return nil, err
+		}
+		if ls.BorrowCostBps < 0 {
+			return nil, fmt.Errorf("borrow cost cannot be negative")
+		}
+	}
+	if wf := requestBody.WalkForward; wf != nil {
+		if err := wf.toService().Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if b := requestBody.Bootstrap; b != nil {
+		if err := b.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if uf := requestBody.UniverseFilter; uf != nil {
+		if err := uf.toService().Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if pc := requestBody.Constraints; pc != nil {
+		if err := pc.toInternal().Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if w := requestBody.Weighting; w != nil {
+		if err := w.toInternal().Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if tc := requestBody.TransactionCosts; tc != nil {
+		if tc.CommissionPerShare < 0 || tc.NotionalBps < 0 || tc.FixedFeePerOrder < 0 || tc.SlippageVolatilityMultiplier < 0 {
+			return nil, fmt.Errorf("transaction costs cannot be negative")
+		}
+	}
 
 	return &requestBody, nil
 }
 
+func parseDividendMode(s string) (service.DividendMode, error) {
+	switch s {
+	case "", "adjusted":
+		return service.DividendModeAdjusted, nil
+	case string(service.DividendModeCash), string(service.DividendModeReinvest):
+		return service.DividendMode(s), nil
+	}
+	return "", fmt.Errorf("invalid dividend mode %s", s)
+}
+
 // runBacktest is the shared core of the synchronous and streaming
 // endpoints. It owns: persisting the strategy, invoking the backtest
 // service, computing metrics, and assembling the BacktestResponse. The
@@ -197,13 +712,9 @@ func (h ApiHandler) runBacktest(c *gin.Context, requestBody BacktestRequest) (*B
 		assetUniverse = requestBody.AssetUniverse
 	}
 
-	samplingInterval := time.Hour * 24
-	if strings.EqualFold(requestBody.SamplingIntervalUnit, "weekly") {
-		samplingInterval *= 7
-	} else if strings.EqualFold(requestBody.SamplingIntervalUnit, "monthly") {
-		samplingInterval *= 30
-	} else if strings.EqualFold(requestBody.SamplingIntervalUnit, "yearly") {
-		samplingInterval *= 365
+	rebalanceSchedule, err := domain.ParseRebalanceSchedule(requestBody.SamplingIntervalUnit)
+	if err != nil {
+		return nil, &runBacktestErr{Err: err, Code: 400}
 	}
 
 	var requestId *uuid.UUID
@@ -228,14 +739,7 @@ func (h ApiHandler) runBacktest(c *gin.Context, requestBody BacktestRequest) (*B
 	}
 
 	// new version - save the strategy
-	insertedStrategy, err := h.addNewStrategy(
-		c,
-		requestBody.FactorOptions.Name,
-		requestBody.FactorOptions.Expression,
-		requestBody.SamplingIntervalUnit,
-		assetUniverse,
-		requestBody.NumSymbols,
-	)
+	insertedStrategy, err := h.addNewStrategy(c, requestBody, assetUniverse)
 	if err != nil {
 		return nil, &runBacktestErr{Err: err, Code: 500}
 	}
@@ -244,10 +748,43 @@ func (h ApiHandler) runBacktest(c *gin.Context, requestBody BacktestRequest) (*B
 		FactorExpression:  requestBody.FactorOptions.Expression,
 		BacktestStart:     backtestStartDate,
 		BacktestEnd:       backtestEndDate,
-		RebalanceInterval: samplingInterval,
+		RebalanceSchedule: *rebalanceSchedule,
 		StartingCash:      requestBody.StartCash,
 		NumTickers:        requestBody.NumSymbols,
 		AssetUniverse:     assetUniverse,
+		ExecutionDelay:    requestBody.ExecutionDelayDays,
+	}
+	backtestInput.DividendMode, err = parseDividendMode(requestBody.DividendMode)
+	if err != nil {
+		return nil, &runBacktestErr{Err: err, Code: 400}
+	}
+	if ls := requestBody.LongShort; ls != nil {
+		longShort := ls.toInternal()
+		backtestInput.LongShort = &longShort
+		backtestInput.ShortBorrowBps = ls.BorrowCostBps
+	}
+	if wf := requestBody.WalkForward; wf != nil {
+		walkForward := wf.toService()
+		backtestInput.WalkForward = &walkForward
+	}
+	if uf := requestBody.UniverseFilter; uf != nil {
+		universeFilter := uf.toService()
+		backtestInput.UniverseFilter = &universeFilter
+	}
+	if pc := requestBody.Constraints; pc != nil {
+		constraints := pc.toInternal()
+		backtestInput.Constraints = &constraints
+	}
+	if w := requestBody.Weighting; w != nil {
+		backtestInput.Weighting = w.toInternal()
+	}
+	if tc := requestBody.TransactionCosts; tc != nil {
+		backtestInput.TransactionCosts = &calculator.TransactionCostModel{
+			CommissionPerShare:           tc.CommissionPerShare,
+			NotionalBps:                  tc.NotionalBps,
+			FixedFeePerOrder:             tc.FixedFeePerOrder,
+			SlippageVolatilityMultiplier: tc.SlippageVolatilityMultiplier,
+		}
 	}
 
 	backtestSpan, endSpan := profile.StartNewSpan("running backtest")
@@ -260,7 +797,7 @@ func (h ApiHandler) runBacktest(c *gin.Context, requestBody BacktestRequest) (*B
 	// calculate stats — this is fast enough that we group it with the
 	// "save run" call under a single user-visible "metrics" step.
 	endMetricsStep := progress.Step(ctx, "metrics", "Computing performance metrics")
-	metrics, err := h.StrategyService.CalculateMetrics(ctx, insertedStrategy.StrategyID, result.Results)
+	metrics, err := h.StrategyService.CalculateMetrics(ctx, result.EquityCurve)
 	if err != nil {
 		log.Errorf("failed to calculate metrics: %w", err)
 		metrics = &calculator.CalculateMetricsResult{}
@@ -280,8 +817,65 @@ func (h ApiHandler) runBacktest(c *gin.Context, requestBody BacktestRequest) (*B
 	if _, err := h.StrategyRepository.AddRun(newRunModel); err != nil {
 		log.Errorf("failed to add strategy run: %w", err)
 	}
+
+	var strategyOverfitting *StrategyOverfitting
+	overfitting, err := h.StrategyService.CalculateStrategyOverfitting(ctx, insertedStrategy.StrategyID, backtestStartDate, backtestEndDate, result.EquityCurve)
+	if err != nil {
+		log.Errorf("failed to calculate overfitting: %w", err)
+	} else {
+		strategyOverfitting = &StrategyOverfitting{
+			NumTrials:           overfitting.NumTrials,
+			ExpectedMaxSharpe:   overfitting.ExpectedMaxSharpe,
+			DeflatedSharpeRatio: overfitting.DeflatedSharpeRatios[0],
+		}
+	}
+
+	benchmarkSymbol := "SPY"
+	if requestBody.Benchmark != "" {
+		benchmarkSymbol = requestBody.Benchmark
+	}
+	var benchmarkStats *BenchmarkStats
+	stats, err := h.StrategyService.CalculateBenchmarkStats(ctx, result.EquityCurve, benchmarkSymbol)
+	if err != nil {
+		log.Errorf("failed to calculate benchmark stats: %w", err)
+	} else {
+		benchmarkStats = &BenchmarkStats{
+			Symbol:           benchmarkSymbol,
+			Beta:             stats.Beta,
+			Alpha:            stats.Alpha,
+			TrackingError:    stats.TrackingError,
+			InformationRatio: stats.InformationRatio,
+			UpCapture:        stats.UpCapture,
+			DownCapture:      stats.DownCapture,
+			Correlation:      stats.Correlation,
+		}
+	}
+
+	var walkForward *WalkForwardReport
+	if result.WalkForward != nil {
+		walkForward, err = h.toWalkForwardReport(ctx, *result.WalkForward)
+		if err != nil {
+			return nil, &runBacktestErr{Err: err, Code: 500}
+		}
+	}
+
+	var bootstrap *BootstrapReport
+	if requestBody.Bootstrap != nil {
+		bootstrap, err = h.bootstrap(ctx, *result, *requestBody.Bootstrap)
+		if err != nil {
+			log.Errorf("failed to bootstrap: %w", err)
+		}
+	}
 	endMetricsStep()
 
+	var universeMembership map[string][]string
+	if result.UniverseMembership != nil {
+		universeMembership = map[string][]string{}
+		for day, symbols := range result.UniverseMembership {
+			universeMembership[day.Format(time.DateOnly)] = symbols
+		}
+	}
+
 	responseJson := &BacktestResponse{
 		StrategyID: insertedStrategy.StrategyID,
 		FactorName: requestBody.FactorOptions.Name,
@@ -293,6 +887,18 @@ func (h ApiHandler) runBacktest(c *gin.Context, requestBody BacktestRequest) (*B
 		AnnualizedReturn: &metrics.AnnualizedReturn,
 		SharpeRatio:      &metrics.SharpeRatio,
 		AnnualizedStdev:  &metrics.AnnualizedStdev,
+		Metrics:          toBacktestMetrics(metrics),
+		BenchmarkStats:   benchmarkStats,
+		Overfitting:      strategyOverfitting,
+
+		TotalTransactionCosts: result.TotalTransactionCosts,
+		TotalIncome:           result.TotalIncome,
+		TotalBorrowCosts:      result.TotalBorrowCosts,
+		EquityCurve:           toEquityCurvePoints(result.EquityCurve),
+		Turnover:              toTurnoverReport(result.Turnover),
+		WalkForward:           walkForward,
+		Bootstrap:             bootstrap,
+		UniverseMembership:    universeMembership,
 	}
 
 	endProfile()
@@ -384,13 +990,12 @@ func saveUserStrategy(
 	return err
 }
 
+// addNewStrategy saves everything the backtest ran with that changes the
+// portfolio, so stress tests and investments hold the same thing
 func (m ApiHandler) addNewStrategy(
 	c *gin.Context,
-	name string,
-	expression string,
-	rebalanceInterval string,
+	requestBody BacktestRequest,
 	assetUniverse string,
-	numAssets int,
 ) (*model.Strategy, error) {
 	var userAccountID *uuid.UUID
 	ginUserAccountID, ok := c.Get("userAccountID")
@@ -411,12 +1016,52 @@ func (m ApiHandler) addNewStrategy(
 	// i think this should try to find one if it exists
 
 	newModel := model.Strategy{
-		StrategyName:      name,
-		FactorExpression:  expression,
-		RebalanceInterval: rebalanceInterval,
-		NumAssets:         int32(numAssets),
-		AssetUniverse:     assetUniverse,
-		UserAccountID:     userAccountID,
+		StrategyName:       requestBody.FactorOptions.Name,
+		FactorExpression:   requestBody.FactorOptions.Expression,
+		RebalanceInterval:  requestBody.SamplingIntervalUnit,
+		NumAssets:          int32(requestBody.NumSymbols),
+		AssetUniverse:      assetUniverse,
+		UserAccountID:      userAccountID,
+		ExecutionDelayDays: int32(requestBody.ExecutionDelayDays),
+	}
+	dividendMode, err := parseDividendMode(requestBody.DividendMode)
+	if err != nil {
+		return nil, err
+	}
+	if dividendMode != service.DividendModeAdjusted {
+		newModel.DividendMode = util.StringPointer(string(dividendMode))
+	}
+	if ls := requestBody.LongShort; ls != nil {
+		bytes, err := json.Marshal(ls.toInternal())
+		if err != nil {
+			return nil, err
+		}
+		newModel.LongShort = util.StringPointer(string(bytes))
+		if ls.BorrowCostBps != 0 {
+			newModel.ShortBorrowBps = &ls.BorrowCostBps
+		}
+	}
+	if uf := requestBody.UniverseFilter; uf != nil {
+		bytes, err := json.Marshal(uf.toService())
+		if err != nil {
+			return nil, err
+		}
+		newModel.UniverseFilter = util.StringPointer(string(bytes))
+	}
+	if constraints := requestBody.Constraints; constraints != nil {
+		bytes, err := json.Marshal(constraints.toInternal())
+		if err != nil {
+			return nil, err
+		}
+		newModel.PositionConstraints = util.StringPointer(string(bytes))
+	}
+	if weighting := requestBody.Weighting; weighting != nil {
+		if weighting.Scheme != "" {
+			newModel.WeightingScheme = util.StringPointer(weighting.Scheme)
+		}
+		if weighting.Intensity != 0 {
+			newModel.WeightingIntensity = &weighting.Intensity
+		}
 	}
 	insertedStrategy, err := m.StrategyRepository.Add(newModel)
 	if err != nil {
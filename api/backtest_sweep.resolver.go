@@ -0,0 +1,286 @@
+package api
+
+import (
+	"context"
+	"factorbacktest/internal/calculator"
+	"factorbacktest/internal/domain"
+	"factorbacktest/internal/logger"
+	"factorbacktest/internal/service"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type backtestSweepRequest struct {
+	// may contain $placeholders, e.g.
+	// pricePercentChange(nMonthsAgo($lookback), currentDate)
+	Expression    string  `json:"expression"`
+	BacktestStart string  `json:"backtestStart"`
+	BacktestEnd   string  `json:"backtestEnd"`
+	StartCash     float64 `json:"startCash"`
+	AssetUniverse string  `json:"assetUniverse"`
+
+	Parameters []sweepParameter `json:"parameters"`
+	NumSymbols []int            `json:"numSymbols"`
+	// same values as samplingIntervalUnit on /backtest
+	SamplingIntervalUnits []string `json:"samplingIntervalUnits"`
+
+	TransactionCosts   *TransactionCostOptions `json:"transactionCosts"`
+	ExecutionDelayDays int                     `json:"executionDelayDays"`
+	DividendMode       string                  `json:"dividendMode"`
+	UniverseFilter     *UniverseFilterOptions  `json:"universeFilter"`
+	Weighting          *WeightingOptions       `json:"weighting"`
+}
+
+type sweepParameter struct {
+	Name   string    `json:"name"`
+	Values []float64 `json:"values"`
+}
+
+type backtestSweepResponse struct {
+	// one axis per parameter, then samplingIntervalUnit, then numSymbols
+	Axes []sweepAxis `json:"axes"`
+	// one row per combination. the last axis varies fastest
+	Rows []sweepRow `json:"rows"`
+	// metric name -> value per row, in the same order as rows, so it
+	// can be reshaped into an n-dimensional grid using the axis lengths.
+	// null where the combination failed
+	Heatmap map[string][]*float64 `json:"heatmap"`
+	// nil when no combination succeeded or it failed to calculate
+	Overfitting *sweepOverfitting `json:"overfitting"`
+}
+
+// sweepOverfitting adjusts the sweep's best result for the number of
+// combinations tried
+type sweepOverfitting struct {
+	NumTrials int `json:"numTrials"`
+	// index into rows
+	BestRow           int     `json:"bestRow"`
+	ExpectedMaxSharpe float64 `json:"expectedMaxSharpe"`
+	// probability the best row's true sharpe is above 0
+	BestDeflatedSharpeRatio          float64  `json:"bestDeflatedSharpeRatio"`
+	ProbabilityOfBacktestOverfitting *float64 `json:"probabilityOfBacktestOverfitting"`
+	NumSplits                        int      `json:"numSplits"`
+}
+
+type sweepAxis struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+type sweepRow struct {
+	Parameters           map[string]float64 `json:"parameters"`
+	NumSymbols           int                `json:"numSymbols"`
+	SamplingIntervalUnit string             `json:"samplingIntervalUnit"`
+	Expression           string             `json:"expression"`
+	Metrics              *WindowMetrics     `json:"metrics"`
+	// probability the true sharpe is above 0, given how many
+	// combinations were tried
+	DeflatedSharpeRatio *float64 `json:"deflatedSharpeRatio"`
+	Error               *string  `json:"error"`
+}
+
+func (h ApiHandler) backtestSweep(c *gin.Context) {
+	var requestBody backtestSweepRequest
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		returnErrorJson(fmt.Errorf("failed to read request body: %w", err), c)
+		return
+	}
+
+	in, err := requestBody.toService()
+	if err != nil {
+		returnErrorJson(err, c)
+		return
+	}
+	if err := in.Validate(); err != nil {
+		returnErrorJson(err, c)
+		return
+	}
+
+	profile, endProfile := domain.NewProfile()
+	defer endProfile()
+	ctx := context.WithValue(context.Background(), domain.ContextProfileKey, profile)
+
+	result, err := h.BacktestHandler.Sweep(ctx, *in)
+	if err != nil {
+		returnErrorJson(fmt.Errorf("failed to run sweep: %w", err), c)
+		return
+	}
+
+	out := backtestSweepResponse{
+		Axes: []sweepAxis{},
+		Rows: []sweepRow{},
+		Heatmap: map[string][]*float64{
+			"annualizedReturn":            {},
+			"annualizedStandardDeviation": {},
+			"sharpeRatio":                 {},
+			"maxDrawdown":                 {},
+		},
+	}
+	for _, p := range in.Parameters {
+		values := []string{}
+		for _, v := range p.Values {
+			values = append(values, strconv.FormatFloat(v, 'f', -1, 64))
+		}
+		out.Axes = append(out.Axes, sweepAxis{Name: p.Name, Values: values})
+	}
+	numSymbolValues := []string{}
+	for _, n := range requestBody.NumSymbols {
+		numSymbolValues = append(numSymbolValues, strconv.Itoa(n))
+	}
+	out.Axes = append(out.Axes,
+		sweepAxis{Name: "samplingIntervalUnit", Values: requestBody.SamplingIntervalUnits},
+		sweepAxis{Name: "numSymbols", Values: numSymbolValues},
+	)
+
+	// row index of each curve passed to the overfitting calculation
+	trialRows := []int{}
+	trialCurves := [][]service.EquityPoint{}
+	for i, combination := range result.Combinations {
+		row := sweepRow{
+			Parameters: map[string]float64{},
+			NumSymbols: combination.NumTickers,
+			// combinations are ordered schedule-major within each
+			// set of parameter values
+			SamplingIntervalUnit: requestBody.SamplingIntervalUnits[(i/len(requestBody.NumSymbols))%len(requestBody.SamplingIntervalUnits)],
+			Expression:           combination.FactorExpression,
+		}
+		for j, p := range in.Parameters {
+			row.Parameters[p.Name] = combination.ParameterValues[j]
+		}
+
+		err := combination.Err
+		if err == nil {
+			row.Metrics, err = h.windowMetrics(ctx, combination.EquityCurve)
+		}
+		if err != nil {
+			row.Error = strPtr(err.Error())
+			for metric := range out.Heatmap {
+				out.Heatmap[metric] = append(out.Heatmap[metric], nil)
+			}
+		} else {
+			out.Heatmap["annualizedReturn"] = append(out.Heatmap["annualizedReturn"], &row.Metrics.AnnualizedReturn)
+			out.Heatmap["annualizedStandardDeviation"] = append(out.Heatmap["annualizedStandardDeviation"], &row.Metrics.AnnualizedStdev)
+			out.Heatmap["sharpeRatio"] = append(out.Heatmap["sharpeRatio"], &row.Metrics.SharpeRatio)
+			out.Heatmap["maxDrawdown"] = append(out.Heatmap["maxDrawdown"], &row.Metrics.MaxDrawdown)
+			trialRows = append(trialRows, i)
+			trialCurves = append(trialCurves, combination.EquityCurve)
+		}
+		out.Rows = append(out.Rows, row)
+	}
+
+	if len(trialCurves) > 0 {
+		overfitting, err := h.StrategyService.CalculateOverfitting(ctx, trialCurves)
+		if err != nil {
+			logger.FromContext(c).Errorf("failed to calculate sweep overfitting: %s", err.Error())
+		} else {
+			for trial, row := range trialRows {
+				out.Rows[row].DeflatedSharpeRatio = &overfitting.DeflatedSharpeRatios[trial]
+			}
+			out.Overfitting = &sweepOverfitting{
+				NumTrials:                        overfitting.NumTrials,
+				BestRow:                          trialRows[overfitting.BestTrial],
+				ExpectedMaxSharpe:                overfitting.ExpectedMaxSharpe,
+				BestDeflatedSharpeRatio:          overfitting.DeflatedSharpeRatios[overfitting.BestTrial],
+				ProbabilityOfBacktestOverfitting: overfitting.ProbabilityOfBacktestOverfitting,
+				NumSplits:                        overfitting.NumSplits,
+			}
+		}
+	}
+	deflatedSharpeRatios := []*float64{}
+	for _, row := range out.Rows {
+		deflatedSharpeRatios = append(deflatedSharpeRatios, row.DeflatedSharpeRatio)
+	}
+	out.Heatmap["deflatedSharpeRatio"] = deflatedSharpeRatios
+
+	c.JSON(200, out)
+}
+
+func (r backtestSweepRequest) toService() (*service.SweepInput, error) {
+	start, err := time.Parse(time.DateOnly, r.BacktestStart)
+	if err != nil {
+		return nil, err
+	}
+	end, err := time.Parse(time.DateOnly, r.BacktestEnd)
+	if err != nil {
+		return nil, err
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("end date cannot be before start date")
+	}
+	if r.ExecutionDelayDays < 0 {
+		return nil, fmt.Errorf("execution delay cannot be negative")
+	}
+	if len(r.NumSymbols) == 0 {
+		return nil, fmt.Errorf("numSymbols needs at least one value")
+	}
+	if len(r.SamplingIntervalUnits) == 0 {
+		return nil, fmt.Errorf("samplingIntervalUnits needs at least one value")
+	}
+	dividendMode, err := parseDividendMode(r.DividendMode)
+	if err != nil {
+		return nil, err
+	}
+
+	assetUniverse := "SPY_TOP_80"
+	if r.AssetUniverse != "" {
+		assetUniverse = r.AssetUniverse
+	}
+
+	out := &service.SweepInput{
+		Base: service.BacktestInput{
+			FactorExpression: r.Expression,
+			BacktestStart:    start,
+			BacktestEnd:      end,
+			StartingCash:     r.StartCash,
+			AssetUniverse:    assetUniverse,
+			ExecutionDelay:   r.ExecutionDelayDays,
+			DividendMode:     dividendMode,
+		},
+		Parameters:         []service.SweepParameter{},
+		NumTickers:         r.NumSymbols,
+		RebalanceSchedules: []domain.RebalanceSchedule{},
+	}
+	for _, p := range r.Parameters {
+		out.Parameters = append(out.Parameters, service.SweepParameter{
+			Name:   p.Name,
+			Values: p.Values,
+		})
+	}
+	for _, s := range r.SamplingIntervalUnits {
+		schedule, err := domain.ParseRebalanceSchedule(s)
+		if err != nil {
+			return nil, err
+		}
+		out.RebalanceSchedules = append(out.RebalanceSchedules, *schedule)
+	}
+	if tc := r.TransactionCosts; tc != nil {
+		if tc.CommissionPerShare < 0 || tc.NotionalBps < 0 || tc.FixedFeePerOrder < 0 || tc.SlippageVolatilityMultiplier < 0 {
+			return nil, fmt.Errorf("transaction costs cannot be negative")
+		}
+		out.Base.TransactionCosts = &calculator.TransactionCostModel{
+			CommissionPerShare:           tc.CommissionPerShare,
+			NotionalBps:                  tc.NotionalBps,
+			FixedFeePerOrder:             tc.FixedFeePerOrder,
+			SlippageVolatilityMultiplier: tc.SlippageVolatilityMultiplier,
+		}
+	}
+
+	if uf := r.UniverseFilter; uf != nil {
+		universeFilter := uf.toService()
+		if err := universeFilter.Validate(); err != nil {
+			return nil, err
+		}
+		out.Base.UniverseFilter = &universeFilter
+	}
+	if w := r.Weighting; w != nil {
+		out.Base.Weighting = w.toInternal()
+		if err := out.Base.Weighting.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"factorbacktest/internal/calculator"
+	"factorbacktest/internal/domain"
+	"factorbacktest/internal/service"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type factorICRequest struct {
+	Expression    string `json:"expression"`
+	AssetUniverse string `json:"assetUniverse"`
+	Start         string `json:"start"`
+	End           string `json:"end"`
+	// same values as samplingIntervalUnit on /backtest
+	RebalanceSchedule string `json:"rebalanceSchedule"`
+	// trading days of forward returns. defaults to 1, 5, 21 and 63
+	Horizons []int `json:"horizons"`
+}
+
+type factorICResponse struct {
+	Horizons []factorICHorizon `json:"horizons"`
+	// mean IC at each horizon, in order
+	Decay []factorICDecayPoint `json:"decay"`
+}
+
+type factorICHorizon struct {
+	Horizon        int             `json:"horizon"`
+	Series         []factorICPoint `json:"series"`
+	MeanRankIC     *float64        `json:"meanRankIC"`
+	MeanPearsonIC  *float64        `json:"meanPearsonIC"`
+	RankICTStat    *float64        `json:"rankICTStat"`
+	PearsonICTStat *float64        `json:"pearsonICTStat"`
+}
+
+type factorICPoint struct {
+	Date      string  `json:"date"`
+	RankIC    float64 `json:"rankIC"`
+	PearsonIC float64 `json:"pearsonIC"`
+	NumAssets int     `json:"numAssets"`
+}
+
+type factorICDecayPoint struct {
+	Horizon       int      `json:"horizon"`
+	MeanRankIC    *float64 `json:"meanRankIC"`
+	MeanPearsonIC *float64 `json:"meanPearsonIC"`
+}
+
+func (h ApiHandler) factorIC(c *gin.Context) {
+	var requestBody factorICRequest
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		returnErrorJson(fmt.Errorf("failed to read request body: %w", err), c)
+		return
+	}
+
+	if err := calculator.ValidateFactorExpression(requestBody.Expression); err != nil {
+		returnErrorJson(fmt.Errorf("invalid factor expression: %w", err), c)
+		return
+	}
+
+	start, err := time.Parse(time.DateOnly, requestBody.Start)
+	if err != nil {
+		returnErrorJson(err, c)
+		return
+	}
+	end, err := time.Parse(time.DateOnly, requestBody.End)
+	if err != nil {
+		returnErrorJson(err, c)
+		return
+	}
+	schedule, err := domain.ParseRebalanceSchedule(requestBody.RebalanceSchedule)
+	if err != nil {
+		returnErrorJson(err, c)
+		return
+	}
+	if schedule.Type == domain.RebalanceScheduleType_Drift {
+		returnErrorJson(fmt.Errorf("drift schedules are not supported for IC analysis"), c)
+		return
+	}
+	horizons := requestBody.Horizons
+	if len(horizons) == 0 {
+		horizons = calculator.DefaultICHorizons
+	}
+	for _, horizon := range horizons {
+		if horizon <= 0 || horizon > 252 {
+			returnErrorJson(fmt.Errorf("horizons must be between 1 and 252 trading days"), c)
+			return
+		}
+	}
+
+	result, err := h.FactorAnalysisService.InformationCoefficients(c, service.ICAnalysisInput{
+		FactorExpression:  requestBody.Expression,
+		AssetUniverse:     requestBody.AssetUniverse,
+		Start:             start,
+		End:               end,
+		RebalanceSchedule: *schedule,
+		Horizons:          horizons,
+	})
+	if err != nil {
+		returnErrorJson(err, c)
+		return
+	}
+
+	out := factorICResponse{
+		Horizons: []factorICHorizon{},
+		Decay:    []factorICDecayPoint{},
+	}
+	for _, hz := range result.Horizons {
+		series := []factorICPoint{}
+		for _, p := range hz.Series {
+			series = append(series, factorICPoint{
+				Date:      p.Date.Format(time.DateOnly),
+				RankIC:    p.RankIC,
+				PearsonIC: p.PearsonIC,
+				NumAssets: p.NumAssets,
+			})
+		}
+		out.Horizons = append(out.Horizons, factorICHorizon{
+			Horizon:        hz.Horizon,
+			Series:         series,
+			MeanRankIC:     hz.MeanRankIC,
+			MeanPearsonIC:  hz.MeanPearsonIC,
+			RankICTStat:    hz.RankICTStat,
+			PearsonICTStat: hz.PearsonICTStat,
+		})
+		out.Decay = append(out.Decay, factorICDecayPoint{
+			Horizon:       hz.Horizon,
+			MeanRankIC:    hz.MeanRankIC,
+			MeanPearsonIC: hz.MeanPearsonIC,
+		})
+	}
+
+	c.JSON(200, out)
+}
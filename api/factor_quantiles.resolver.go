@@ -0,0 +1,116 @@
+package api
+
+import (
+	"factorbacktest/internal/calculator"
+	"factorbacktest/internal/domain"
+	"factorbacktest/internal/service"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type factorQuantilesRequest struct {
+	Expression    string `json:"expression"`
+	AssetUniverse string `json:"assetUniverse"`
+	Start         string `json:"start"`
+	End           string `json:"end"`
+	// same values as samplingIntervalUnit on /backtest
+	RebalanceSchedule string `json:"rebalanceSchedule"`
+	// e.g. 3 for terciles, 5 for quintiles, 10 for deciles
+	NumQuantiles int `json:"numQuantiles"`
+}
+
+type factorQuantilesResponse struct {
+	NumQuantiles int                     `json:"numQuantiles"`
+	Periods      []factorQuantilesPeriod `json:"periods"`
+	// percent, lowest scores first
+	CumulativeReturns []float64 `json:"cumulativeReturns"`
+	MeanReturns       []float64 `json:"meanReturns"`
+	CumulativeSpread  float64   `json:"cumulativeSpread"`
+}
+
+type factorQuantilesPeriod struct {
+	Start       string    `json:"start"`
+	End         string    `json:"end"`
+	Returns     []float64 `json:"returns"`
+	BucketSizes []int     `json:"bucketSizes"`
+	Spread      float64   `json:"spread"`
+}
+
+func toPercents(f []float64) []float64 {
+	out := make([]float64, len(f))
+	for i, v := range f {
+		out[i] = 100 * v
+	}
+	return out
+}
+
+func (h ApiHandler) factorQuantiles(c *gin.Context) {
+	var requestBody factorQuantilesRequest
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		returnErrorJson(fmt.Errorf("failed to read request body: %w", err), c)
+		return
+	}
+
+	if err := calculator.ValidateFactorExpression(requestBody.Expression); err != nil {
+		returnErrorJson(fmt.Errorf("invalid factor expression: %w", err), c)
+		return
+	}
+
+	start, err := time.Parse(time.DateOnly, requestBody.Start)
+	if err != nil {
+		returnErrorJson(err, c)
+		return
+	}
+	end, err := time.Parse(time.DateOnly, requestBody.End)
+	if err != nil {
+		returnErrorJson(err, c)
+		return
+	}
+	schedule, err := domain.ParseRebalanceSchedule(requestBody.RebalanceSchedule)
+	if err != nil {
+		returnErrorJson(err, c)
+		return
+	}
+	if schedule.Type == domain.RebalanceScheduleType_Drift {
+		returnErrorJson(fmt.Errorf("drift schedules are not supported for quantile analysis"), c)
+		return
+	}
+	if requestBody.NumQuantiles < 2 || requestBody.NumQuantiles > 20 {
+		returnErrorJson(fmt.Errorf("numQuantiles must be between 2 and 20"), c)
+		return
+	}
+
+	result, err := h.FactorAnalysisService.QuantileReturns(c, service.QuantileAnalysisInput{
+		FactorExpression:  requestBody.Expression,
+		AssetUniverse:     requestBody.AssetUniverse,
+		Start:             start,
+		End:               end,
+		RebalanceSchedule: *schedule,
+		NumQuantiles:      requestBody.NumQuantiles,
+	})
+	if err != nil {
+		returnErrorJson(err, c)
+		return
+	}
+
+	out := factorQuantilesResponse{
+		NumQuantiles:      requestBody.NumQuantiles,
+		Periods:           []factorQuantilesPeriod{},
+		CumulativeReturns: toPercents(result.CumulativeBucketReturns),
+		MeanReturns:       toPercents(result.MeanBucketReturns),
+		CumulativeSpread:  100 * result.CumulativeSpread,
+	}
+	for _, p := range result.Periods {
+		out.Periods = append(out.Periods, factorQuantilesPeriod{
+			Start:       p.Start.Format(time.DateOnly),
+			End:         p.End.Format(time.DateOnly),
+			Returns:     toPercents(p.BucketReturns),
+			BucketSizes: p.BucketSizes,
+			Spread:      100 * p.Spread,
+		})
+	}
+
+	c.JSON(200, out)
+}
@@ -0,0 +1,193 @@
+package api
+
+import (
+	"context"
+	"factorbacktest/internal/domain"
+	"factorbacktest/internal/service"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// most scenarios, named and custom, one request can run
+const maxStressScenarios = 10
+
+type stressTestRequest struct {
+	// names from /stressScenarios. empty runs all of them
+	Scenarios []string `json:"scenarios"`
+	// run alongside the named ones
+	CustomScenarios []stressScenario `json:"customScenarios"`
+}
+
+type stressScenario struct {
+	Name  string `json:"name"`
+	Start string `json:"start"`
+	End   string `json:"end"`
+	// optional - keeps running after end to see when the drawdown
+	// recovers. the equity curve runs through it too
+	RecoveryEnd string `json:"recoveryEnd,omitempty"`
+	// defaults to SPY
+	Benchmark string `json:"benchmark"`
+}
+
+type stressTestResult struct {
+	Scenario    stressScenario     `json:"scenario"`
+	EquityCurve []EquityCurvePoint `json:"equityCurve"`
+	// fractions, not percents
+	TotalReturn          float64  `json:"totalReturn"`
+	MaxDrawdown          float64  `json:"maxDrawdown"`
+	MaxDrawdownPeak      *string  `json:"maxDrawdownPeak"`
+	MaxDrawdownTrough    *string  `json:"maxDrawdownTrough"`
+	RecoveryDays         *int     `json:"recoveryDays"`
+	BenchmarkTotalReturn *float64 `json:"benchmarkTotalReturn"`
+	BenchmarkMaxDrawdown *float64 `json:"benchmarkMaxDrawdown"`
+	ExcessReturn         *float64 `json:"excessReturn"`
+	// set when only the benchmark failed
+	BenchmarkError *string `json:"benchmarkError"`
+	Error          *string `json:"error"`
+}
+
+func toStressScenario(in service.StressScenario) stressScenario {
+	out := stressScenario{
+		Name:      in.Name,
+		Start:     in.Start.Format(time.DateOnly),
+		End:       in.End.Format(time.DateOnly),
+		Benchmark: in.Benchmark,
+	}
+	if !in.RecoveryEnd.IsZero() {
+		out.RecoveryEnd = in.RecoveryEnd.Format(time.DateOnly)
+	}
+	return out
+}
+
+func (s stressScenario) toService() (*service.StressScenario, error) {
+	if s.Name == "" {
+		return nil, fmt.Errorf("custom scenarios need a name")
+	}
+	start, err := time.Parse(time.DateOnly, s.Start)
+	if err != nil {
+		return nil, err
+	}
+	end, err := time.Parse(time.DateOnly, s.End)
+	if err != nil {
+		return nil, err
+	}
+	if !end.After(start) {
+		return nil, fmt.Errorf("scenario %s must end after it starts", s.Name)
+	}
+	var recoveryEnd time.Time
+	if s.RecoveryEnd != "" {
+		recoveryEnd, err = time.Parse(time.DateOnly, s.RecoveryEnd)
+		if err != nil {
+			return nil, err
+		}
+		if !recoveryEnd.After(end) {
+			return nil, fmt.Errorf("scenario %s must end its recovery after it ends", s.Name)
+		}
+	}
+	benchmark := "SPY"
+	if s.Benchmark != "" {
+		benchmark = s.Benchmark
+	}
+	return &service.StressScenario{
+		Name:        s.Name,
+		Start:       start,
+		End:         end,
+		RecoveryEnd: recoveryEnd,
+		Benchmark:   benchmark,
+	}, nil
+}
+
+func (m ApiHandler) getStressScenarios(c *gin.Context) {
+	out := []stressScenario{}
+	for _, s := range service.DefaultStressScenarios {
+		out = append(out, toStressScenario(s))
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+func (m ApiHandler) stressTest(c *gin.Context) {
+	strategyID, err := uuid.Parse(c.Param("strategyID"))
+	if err != nil {
+		returnErrorJsonCode(fmt.Errorf("invalid strategy id"), c, http.StatusBadRequest)
+		return
+	}
+
+	var requestBody stressTestRequest
+	// the body is optional
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&requestBody); err != nil {
+			returnErrorJson(fmt.Errorf("failed to read request body: %w", err), c)
+			return
+		}
+	}
+
+	scenarios := []service.StressScenario{}
+	if len(requestBody.Scenarios) == 0 && len(requestBody.CustomScenarios) == 0 {
+		scenarios = append(scenarios, service.DefaultStressScenarios...)
+	}
+	for _, name := range requestBody.Scenarios {
+		found := false
+		for _, s := range service.DefaultStressScenarios {
+			if s.Name == name {
+				scenarios = append(scenarios, s)
+				found = true
+			}
+		}
+		if !found {
+			returnErrorJsonCode(fmt.Errorf("unknown scenario %s", name), c, http.StatusBadRequest)
+			return
+		}
+	}
+	for _, s := range requestBody.CustomScenarios {
+		scenario, err := s.toService()
+		if err != nil {
+			returnErrorJsonCode(err, c, http.StatusBadRequest)
+			return
+		}
+		scenarios = append(scenarios, *scenario)
+	}
+	if len(scenarios) > maxStressScenarios {
+		returnErrorJsonCode(fmt.Errorf("can run at most %d scenarios at once, got %d", maxStressScenarios, len(scenarios)), c, http.StatusBadRequest)
+		return
+	}
+
+	profile, endProfile := domain.NewProfile()
+	defer endProfile()
+	ctx := context.WithValue(context.Background(), domain.ContextProfileKey, profile)
+
+	results, err := m.StrategyService.RunStressScenarios(ctx, strategyID, scenarios)
+	if err != nil {
+		returnErrorJson(err, c)
+		return
+	}
+
+	out := []stressTestResult{}
+	for _, r := range results {
+		result := stressTestResult{
+			Scenario:             toStressScenario(r.Scenario),
+			EquityCurve:          toEquityCurvePoints(r.EquityCurve),
+			TotalReturn:          r.TotalReturn,
+			MaxDrawdown:          r.MaxDrawdown.Depth,
+			RecoveryDays:         r.RecoveryDays,
+			BenchmarkTotalReturn: r.BenchmarkTotalReturn,
+			BenchmarkMaxDrawdown: r.BenchmarkMaxDrawdown,
+			ExcessReturn:         r.ExcessReturn,
+		}
+		if r.BenchmarkErr != nil {
+			result.BenchmarkError = strPtr(r.BenchmarkErr.Error())
+		}
+		if r.Err != nil {
+			result.Error = strPtr(r.Err.Error())
+		} else if r.MaxDrawdown.Depth > 0 {
+			result.MaxDrawdownPeak = strPtr(r.MaxDrawdown.Peak.Format(time.DateOnly))
+			result.MaxDrawdownTrough = strPtr(r.MaxDrawdown.Trough.Format(time.DateOnly))
+		}
+		out = append(out, result)
+	}
+
+	c.JSON(http.StatusOK, out)
+}
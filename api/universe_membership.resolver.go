@@ -0,0 +1,153 @@
+package api
+
+import (
+	"encoding/csv"
+	"errors"
+	"factorbacktest/internal/db/models/postgres/public/model"
+	"factorbacktest/internal/repository"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type membershipChangeRow struct {
+	Date   time.Time
+	Symbol string
+	Name   string
+	Sector string
+	Added  bool
+}
+
+// parseMembershipChangesCSV reads a history of index changes. the header
+// must have date, symbol and action columns, in any order, and may have
+// name and sector columns. action is "add" or "remove"
+func parseMembershipChangesCSV(r io.Reader) ([]membershipChangeRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, h := range header {
+		columns[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	for _, required := range []string{"date", "symbol", "action"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("csv is missing a %s column", required)
+		}
+	}
+	nameColumn, hasName := columns["name"]
+	sectorColumn, hasSector := columns["sector"]
+
+	out := []membershipChangeRow{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read csv line %d: %w", line, err)
+		}
+
+		date, err := time.Parse(time.DateOnly, record[columns["date"]])
+		if err != nil {
+			return nil, fmt.Errorf("invalid date on line %d: %w", line, err)
+		}
+		symbol := strings.ToUpper(strings.TrimSpace(record[columns["symbol"]]))
+		if symbol == "" {
+			return nil, fmt.Errorf("missing symbol on line %d", line)
+		}
+		row := membershipChangeRow{
+			Date:   date,
+			Symbol: symbol,
+		}
+		switch strings.ToLower(strings.TrimSpace(record[columns["action"]])) {
+		case "add":
+			row.Added = true
+		case "remove":
+			row.Added = false
+		default:
+			return nil, fmt.Errorf("invalid action %s on line %d, expected add or remove", record[columns["action"]], line)
+		}
+		if hasName {
+			row.Name = strings.TrimSpace(record[nameColumn])
+		}
+		if hasSector {
+			row.Sector = strings.TrimSpace(record[sectorColumn])
+		}
+		out = append(out, row)
+	}
+
+	if len(out) == 0 {
+		return nil, fmt.Errorf("csv has no changes")
+	}
+	return out, nil
+}
+
+// loadUniverseMembership replays a csv of index changes onto a universe,
+// creating it and any unknown tickers. unlike addAssetsToUniverse it
+// doesn't ingest prices, since delisted tickers usually have none to fetch
+func (m ApiHandler) loadUniverseMembership(c *gin.Context) {
+	universeName := c.Param("universeName")
+
+	rows, err := parseMembershipChangesCSV(c.Request.Body)
+	if err != nil {
+		returnErrorJson(err, c)
+		return
+	}
+
+	tx, err := m.Db.Begin()
+	if err != nil {
+		returnErrorJson(err, c)
+		return
+	}
+	defer tx.Rollback()
+
+	universe, err := m.AssetUniverseRepository.GetOrCreate(tx, universeName)
+	if err != nil {
+		returnErrorJson(err, c)
+		return
+	}
+
+	changes := []repository.UniverseMembershipChange{}
+	for _, row := range rows {
+		t := model.Ticker{
+			Symbol: row.Symbol,
+			Name:   row.Name,
+		}
+		if row.Sector != "" {
+			t.Sector = &row.Sector
+		}
+		ticker, err := m.TickerRepository.GetOrCreate(tx, t)
+		if err != nil {
+			returnErrorJson(err, c)
+			return
+		}
+		changes = append(changes, repository.UniverseMembershipChange{
+			Date:   row.Date,
+			Ticker: *ticker,
+			Added:  row.Added,
+		})
+	}
+
+	err = m.AssetUniverseRepository.ApplyMembershipChanges(tx, *universe, changes)
+	if err != nil {
+		returnErrorJson(err, c)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		returnErrorJson(err, c)
+		return
+	}
+
+	c.JSON(200, map[string]any{
+		"message":    "ok",
+		"numChanges": len(changes),
+	})
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"factorbacktest/internal/util"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseMembershipChangesCSV(t *testing.T) {
+	t.Run("parses changes", func(t *testing.T) {
+		rows, err := parseMembershipChangesCSV(strings.NewReader(
+			"symbol,date,action,name\n" +
+				"enrn,2001-12-03,remove,Enron\n" +
+				"META, 2013-12-23, Add, Meta Platforms\n",
+		))
+		require.NoError(t, err)
+		require.Equal(t, []membershipChangeRow{
+			{Date: util.NewDate(2001, 12, 3), Symbol: "ENRN", Name: "Enron", Added: false},
+			{Date: util.NewDate(2013, 12, 23), Symbol: "META", Name: "Meta Platforms", Added: true},
+		}, rows)
+	})
+
+	t.Run("name is optional", func(t *testing.T) {
+		rows, err := parseMembershipChangesCSV(strings.NewReader("date,symbol,action\n2020-01-02,AAPL,add\n"))
+		require.NoError(t, err)
+		require.Equal(t, "", rows[0].Name)
+	})
+
+	t.Run("parses sector", func(t *testing.T) {
+		rows, err := parseMembershipChangesCSV(strings.NewReader("date,symbol,action,sector\n2020-01-02,XOM,add,Energy\n"))
+		require.NoError(t, err)
+		require.Equal(t, "Energy", rows[0].Sector)
+	})
+
+	t.Run("missing column", func(t *testing.T) {
+		_, err := parseMembershipChangesCSV(strings.NewReader("date,symbol\n2020-01-02,AAPL\n"))
+		require.ErrorContains(t, err, "action")
+	})
+
+	t.Run("invalid action", func(t *testing.T) {
+		_, err := parseMembershipChangesCSV(strings.NewReader("date,symbol,action\n2020-01-02,AAPL,join\n"))
+		require.ErrorContains(t, err, "line 2")
+	})
+}
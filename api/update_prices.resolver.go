@@ -2,6 +2,8 @@ package api
 
 import (
 	"factorbacktest/internal/repository"
+	"time"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -11,7 +13,7 @@ type UpdatePricesResponse struct {
 }
 
 func (m ApiHandler) updatePrices(c *gin.Context) {
-	assets, err := m.AssetUniverseRepository.GetAssets("ALL")
+	assets, err := m.AssetUniverseRepository.GetAssets("ALL", time.Now().UTC())
 	if err != nil {
 		returnErrorJson(err, c)
 		return
@@ -102,20 +102,23 @@ func InitializeDependencies(secrets util.Secrets, overrides *api.ApiHandler) (*a
 	investmentRebalanceRepository := repository.NewInvestmentRebalanceRepository(dbConn)
 	excessVolumeRepository := repository.NewExcessTradeVolumeRepository(dbConn)
 	rebalancePriceRepository := repository.NewRebalancePriceRepository(dbConn)
+	corporateActionRepository := repository.NewCorporateActionRepository(dbConn)
+	interestRateRepository := repository.NewInterestRateRepository(dbConn)
 
 	quoteProvider := data.NewHybridQuoteProvider(alpacaRepository)
 	if priceService == nil {
-		priceService = data.NewPriceService(dbConn, priceRepository, nil, quoteProvider)
+		priceService = data.NewPriceService(dbConn, priceRepository, nil, quoteProvider, corporateActionRepository)
 	}
 
 	assetUniverseRepository := repository.NewAssetUniverseRepository(dbConn)
 	factorExpressionService := calculator.NewFactorExpressionService(dbConn, factorMetricsHandler, priceService, factorScoreRepository, priceRepository)
 	backtestHandler := service.BacktestHandler{
-		PriceRepository:         priceRepository,
-		AssetUniverseRepository: assetUniverseRepository,
-		Db:                      dbConn,
-		PriceService:            priceService,
-		FactorExpressionService: factorExpressionService,
+		PriceRepository:           priceRepository,
+		AssetUniverseRepository:   assetUniverseRepository,
+		Db:                        dbConn,
+		PriceService:              priceService,
+		FactorExpressionService:   factorExpressionService,
+		CorporateActionRepository: corporateActionRepository,
 	}
 	tradingService := service.NewTradeService(
 		dbConn,
@@ -153,6 +156,12 @@ func InitializeDependencies(secrets util.Secrets, overrides *api.ApiHandler) (*a
 		assetUniverseRepository,
 		priceRepository,
 		backtestHandler,
+		interestRateRepository,
+	)
+	factorAnalysisService := service.NewFactorAnalysisService(
+		priceRepository,
+		assetUniverseRepository,
+		factorExpressionService,
 	)
 
 	// Email transport is optional. When secrets.Resend.APIKey is empty
@@ -220,6 +229,7 @@ func InitializeDependencies(secrets util.Secrets, overrides *api.ApiHandler) (*a
 		InvestmentService:            investmentService,
 		TradingService:               tradingService,
 		StrategyService:              strategyService,
+		FactorAnalysisService:        factorAnalysisService,
 		StrategySummaryApp:           strategySummaryApp,
 		AuthService:                  authService,
 	}
@@ -78,7 +78,6 @@ require (
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/maja42/goval v1.3.1
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/stretchr/testify v1.8.3
 	gopkg.in/yaml.v3 v3.0.1 // indirect
@@ -133,6 +133,9 @@ func Test_backtestFlow(t *testing.T) {
 				ValuePercentChange: 33.6989043,
 				Value:              13369.88700,
 				Date:               "2020-12-29",
+				ScoringDate:        "2020-12-29",
+				// adjusted prices, so it's all price return
+				PriceReturnPercentChange: 33.6989043,
 				AssetMetrics: map[string]service.SnapshotAssetMetrics{
 					"AAPL": {
 						AssetWeight:                  0.1253766234821042,
@@ -58,7 +58,7 @@ func NewTestServerWithDependencies(
 	}
 
 	priceRepository := repository.NewAdjustedPriceRepository(testDb.db)
-	priceService := data.NewPriceService(testDb.db, priceRepository, nil, nil)
+	priceService := data.NewPriceService(testDb.db, priceRepository, nil, nil, nil)
 	handler, err := cmd.InitializeDependencies(secrets, &api.ApiHandler{
 		AlpacaRepository: alpacaRepository,
 		PriceService: NewMockPriceServiceForTests(
@@ -2,6 +2,7 @@ package app
 
 import (
 	"context"
+	"factorbacktest/internal"
 	"factorbacktest/internal/calculator"
 	"factorbacktest/internal/data"
 	"factorbacktest/internal/db/models/postgres/public/model"
@@ -125,7 +126,7 @@ func (h *strategySummaryAppHandler) computeStrategySummary(
 	referencePortfolioValue decimal.Decimal,
 ) (*domain.StrategySummaryResult, error) {
 	// 1. Get assets in the strategy's universe
-	universe, err := h.AssetUniverseRepository.GetAssets(strategy.AssetUniverse)
+	universe, err := h.AssetUniverseRepository.GetAssets(strategy.AssetUniverse, time.Now().UTC())
 	if err != nil {
 		return nil, fmt.Errorf("failed to get assets in universe %s: %w", strategy.AssetUniverse, err)
 	}
@@ -133,6 +134,17 @@ func (h *strategySummaryAppHandler) computeStrategySummary(
 	if len(universe) == 0 {
 		return nil, fmt.Errorf("universe %s has no assets", strategy.AssetUniverse)
 	}
+	universeFilter, err := service.ParseUniverseFilter(strategy.UniverseFilter)
+	if err != nil {
+		return nil, err
+	}
+	if universeFilter != nil {
+		members, err := service.ApplyUniverseFilter(ctx, h.FactorExpressionService, []time.Time{date}, map[time.Time][]model.Ticker{date: universe}, *universeFilter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to filter universe %s: %w", strategy.AssetUniverse, err)
+		}
+		universe = members[date]
+	}
 
 	// Extract symbols and build ticker ID map
 	universeSymbols := []string{}
@@ -165,6 +177,25 @@ func (h *strategySummaryAppHandler) computeStrategySummary(
 	factorScoresMap := scoresOnDay.SymbolScores
 
 	// 4. Compute target portfolio using calculator.ComputeTargetPortfolio
+	constraints, err := internal.ParsePositionConstraints(strategy.PositionConstraints)
+	if err != nil {
+		return nil, err
+	}
+	longShort, err := internal.ParseLongShortOptions(strategy.LongShort)
+	if err != nil {
+		return nil, err
+	}
+	weighting := internal.StrategyWeighting(strategy)
+	var weightingData map[string]*float64
+	if expression := weighting.Scheme.DataExpression(); expression != "" {
+		dataByDay, err := h.FactorExpressionService.CalculateFactorScores(ctx, []time.Time{date}, universe, expression)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate %s weighting data: %w", weighting.Scheme, err)
+		}
+		if d, ok := dataByDay[date]; ok {
+			weightingData = d.SymbolScores
+		}
+	}
 	computeTargetPortfolioResponse, err := calculator.ComputeTargetPortfolio(calculator.ComputeTargetPortfolioInput{
 		Date:             date,
 		TargetNumTickers: int(strategy.NumAssets),
@@ -172,6 +203,11 @@ func (h *strategySummaryAppHandler) computeStrategySummary(
 		PortfolioValue:   referencePortfolioValue,
 		PriceMap:         priceMap,
 		TickerIDMap:      tickerIDMap,
+		LongShort:        longShort,
+		Constraints:      constraints,
+		SectorBySymbol:   internal.SectorBySymbol(universe),
+		Weighting:        weighting,
+		WeightingData:    weightingData,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to compute target portfolio: %w", err)
@@ -0,0 +1,128 @@
+package calculator
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/montanaflynn/stats"
+)
+
+type BenchmarkStats struct {
+	Beta float64
+	// jensen's alpha, annualized
+	Alpha float64
+	// annualized stdev of the strategy's return minus the benchmark's
+	TrackingError    float64
+	InformationRatio *float64
+	// nil when the benchmark never had an up (or down) day
+	UpCapture   *float64
+	DownCapture *float64
+	Correlation float64
+	// days where both the strategy and benchmark had returns
+	NumPeriods int
+}
+
+// CalculateBenchmarkStats compares the strategy's daily values against
+// benchmark prices, keyed by date. only days where the benchmark has a
+// price on both ends are compared. riskFreeRates follows CalculateMetrics
+func CalculateBenchmarkStats(dates []time.Time, values []float64, benchmarkPrices map[time.Time]float64, riskFreeRates []float64) (*BenchmarkStats, error) {
+	if len(dates) != len(values) {
+		return nil, fmt.Errorf("got %d dates and %d values", len(dates), len(values))
+	}
+	if riskFreeRates != nil && len(riskFreeRates) != len(values) {
+		return nil, fmt.Errorf("expected %d risk free rates, got %d", len(values), len(riskFreeRates))
+	}
+
+	strategyReturns, benchmarkReturns, riskFree := []float64{}, []float64{}, []float64{}
+	for i := 1; i < len(values); i++ {
+		start, okStart := benchmarkPrices[dates[i-1]]
+		end, okEnd := benchmarkPrices[dates[i]]
+		if !okStart || !okEnd || start == 0 || values[i-1] == 0 {
+			continue
+		}
+		strategyReturns = append(strategyReturns, (values[i]-values[i-1])/values[i-1])
+		benchmarkReturns = append(benchmarkReturns, (end-start)/start)
+		rf := 0.0
+		if riskFreeRates != nil {
+			rf = riskFreeRates[i-1] / tradingDaysPerYear
+		}
+		riskFree = append(riskFree, rf)
+	}
+	if len(strategyReturns) < 2 {
+		return nil, fmt.Errorf("need at least 2 overlapping returns with the benchmark, got %d", len(strategyReturns))
+	}
+
+	covariance, err := stats.CovariancePopulation(strategyReturns, benchmarkReturns)
+	if err != nil {
+		return nil, err
+	}
+	benchmarkVariance, err := stats.PopulationVariance(benchmarkReturns)
+	if err != nil {
+		return nil, err
+	}
+	if benchmarkVariance == 0 {
+		return nil, fmt.Errorf("benchmark returns have no variance")
+	}
+	beta := covariance / benchmarkVariance
+
+	correlation := 0.0
+	strategyVariance, err := stats.PopulationVariance(strategyReturns)
+	if err != nil {
+		return nil, err
+	}
+	if strategyVariance != 0 {
+		correlation = covariance / math.Sqrt(strategyVariance*benchmarkVariance)
+	}
+
+	activeReturns := []float64{}
+	alphaTotal := 0.0
+	for i := range strategyReturns {
+		activeReturns = append(activeReturns, strategyReturns[i]-benchmarkReturns[i])
+		alphaTotal += (strategyReturns[i] - riskFree[i]) - beta*(benchmarkReturns[i]-riskFree[i])
+	}
+	alpha := alphaTotal / float64(len(strategyReturns)) * tradingDaysPerYear
+
+	activeStdev, err := stats.StandardDeviationSample(activeReturns)
+	if err != nil {
+		return nil, err
+	}
+	trackingError := activeStdev * math.Sqrt(tradingDaysPerYear)
+	var informationRatio *float64
+	if trackingError != 0 {
+		meanActive, err := stats.Mean(activeReturns)
+		if err != nil {
+			return nil, err
+		}
+		ir := meanActive * tradingDaysPerYear / trackingError
+		informationRatio = &ir
+	}
+
+	return &BenchmarkStats{
+		Beta:             beta,
+		Alpha:            alpha,
+		TrackingError:    trackingError,
+		InformationRatio: informationRatio,
+		UpCapture:        captureRatio(strategyReturns, benchmarkReturns, func(r float64) bool { return r > 0 }),
+		DownCapture:      captureRatio(strategyReturns, benchmarkReturns, func(r float64) bool { return r < 0 }),
+		Correlation:      correlation,
+		NumPeriods:       len(strategyReturns),
+	}, nil
+}
+
+// captureRatio is the strategy's mean return over the benchmark's, on
+// days where the benchmark return matches include
+func captureRatio(strategyReturns, benchmarkReturns []float64, include func(float64) bool) *float64 {
+	strategyTotal, benchmarkTotal := 0.0, 0.0
+	for i, r := range benchmarkReturns {
+		if include(r) {
+			strategyTotal += strategyReturns[i]
+			benchmarkTotal += r
+		}
+	}
+	if benchmarkTotal == 0 {
+		return nil
+	}
+	ratio := strategyTotal / benchmarkTotal
+	return &ratio
+}
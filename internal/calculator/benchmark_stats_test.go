@@ -0,0 +1,54 @@
+package calculator
+
+import (
+	"factorbacktest/internal/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalculateBenchmarkStats(t *testing.T) {
+	dates := []time.Time{
+		util.NewDate(2020, 1, 2),
+		util.NewDate(2020, 1, 3),
+		util.NewDate(2020, 1, 6),
+		util.NewDate(2020, 1, 7),
+		util.NewDate(2020, 1, 8),
+	}
+	benchmark := map[time.Time]float64{
+		dates[0]: 100,
+		dates[1]: 101,
+		dates[2]: 99.99,
+		dates[3]: 101.9898,
+		dates[4]: 100.969902,
+	}
+
+	t.Run("twice the benchmark", func(t *testing.T) {
+		// +2%, -2%, +4%, -2%
+		values := []float64{100, 102, 99.96, 103.9584, 101.879232}
+		result, err := CalculateBenchmarkStats(dates, values, benchmark, nil)
+		require.NoError(t, err)
+
+		require.Equal(t, 4, result.NumPeriods)
+		require.InDelta(t, 2, result.Beta, 1e-6)
+		require.InDelta(t, 1, result.Correlation, 1e-6)
+		require.InDelta(t, 0, result.Alpha, 1e-6)
+		require.InDelta(t, 2, *result.UpCapture, 1e-6)
+		require.InDelta(t, 2, *result.DownCapture, 1e-6)
+		require.Greater(t, result.TrackingError, 0.0)
+	})
+
+	t.Run("skips days missing a benchmark price", func(t *testing.T) {
+		values := []float64{100, 102, 99.96, 103.9584, 101.879232}
+		partial := map[time.Time]float64{
+			dates[0]: 100,
+			dates[1]: 101,
+			dates[2]: 99.99,
+			dates[4]: 100.969902,
+		}
+		result, err := CalculateBenchmarkStats(dates, values, partial, nil)
+		require.NoError(t, err)
+		require.Equal(t, 2, result.NumPeriods)
+	})
+}
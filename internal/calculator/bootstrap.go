@@ -0,0 +1,177 @@
+package calculator
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/montanaflynn/stats"
+)
+
+type BootstrapOptions struct {
+	NumSimulations int
+	// number of consecutive returns drawn together, which keeps some
+	// of the autocorrelation and volatility clustering of the original
+	BlockSize int
+	// same seed, same paths
+	Seed int64
+	// used to annualize sharpe. 0 means the values are daily
+	PeriodsPerYear float64
+}
+
+func (o BootstrapOptions) Validate() error {
+	if o.NumSimulations <= 0 {
+		return fmt.Errorf("number of simulations must be positive")
+	}
+	if o.BlockSize <= 0 {
+		return fmt.Errorf("block size must be positive")
+	}
+	if o.PeriodsPerYear < 0 {
+		return fmt.Errorf("periods per year cannot be negative")
+	}
+	return nil
+}
+
+// ConfidenceInterval is the 5th, 50th and 95th percentile of a stat
+// across every simulated path
+type ConfidenceInterval struct {
+	Lower  float64
+	Median float64
+	Upper  float64
+}
+
+type FanChartPoint struct {
+	Date time.Time
+	P5   float64
+	P25  float64
+	P50  float64
+	P75  float64
+	P95  float64
+}
+
+type BootstrapResult struct {
+	NumSimulations   int
+	AnnualizedReturn ConfidenceInterval
+	SharpeRatio      ConfidenceInterval
+	// as positive fractions, like CalculateMetricsResult
+	MaxDrawdown ConfidenceInterval
+	// percentiles of simulated value on each of the original dates
+	FanChart []FanChartPoint
+}
+
+// Bootstrap resamples the curve's returns with a circular block bootstrap
+// and reports how much the headline stats vary across the simulated paths.
+// every path starts at the curve's first value and runs over its dates.
+//
+// riskFreeRates are annualized rates on each date, e.g. 0.05 for 5%.
+// nil treats the risk free rate as 0
+func Bootstrap(dates []time.Time, values []float64, riskFreeRates []float64, options BootstrapOptions) (*BootstrapResult, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+	if len(values) < 3 || len(dates) != len(values) {
+		return nil, fmt.Errorf("cannot bootstrap < 3 equity curve points")
+	}
+	if riskFreeRates != nil && len(riskFreeRates) != len(values) {
+		return nil, fmt.Errorf("expected %d risk free rates, got %d", len(values), len(riskFreeRates))
+	}
+	periodsPerYear := options.PeriodsPerYear
+	if periodsPerYear == 0 {
+		periodsPerYear = tradingDaysPerYear
+	}
+
+	// rates are resampled with their returns, so each draw keeps the
+	// excess return it actually had
+	returns := []float64{}
+	periodRiskFreeRates := []float64{}
+	for i := 1; i < len(values); i++ {
+		if values[i-1] == 0 {
+			return nil, fmt.Errorf("portfolio value is 0 on %s", dates[i-1].Format(time.DateOnly))
+		}
+		returns = append(returns, (values[i]-values[i-1])/values[i-1])
+		rf := 0.0
+		if riskFreeRates != nil {
+			rf = riskFreeRates[i-1] / periodsPerYear
+		}
+		periodRiskFreeRates = append(periodRiskFreeRates, rf)
+	}
+	numReturns := len(returns)
+	numYears := dates[len(dates)-1].Sub(dates[0]).Hours() / (365 * 24)
+
+	r := rand.New(rand.NewSource(options.Seed))
+	annualizedReturns := make([]float64, options.NumSimulations)
+	sharpes := make([]float64, options.NumSimulations)
+	drawdowns := make([]float64, options.NumSimulations)
+	// paths[simulation][date]
+	paths := make([][]float64, options.NumSimulations)
+	for sim := range paths {
+		path := make([]float64, len(values))
+		path[0] = values[0]
+		excessReturns := make([]float64, 0, numReturns)
+		for i := 0; i < numReturns; {
+			start := r.Intn(numReturns)
+			for j := 0; j < options.BlockSize && i < numReturns; j++ {
+				k := (start + j) % numReturns
+				path[i+1] = path[i] * (1 + returns[k])
+				excessReturns = append(excessReturns, returns[k]-periodRiskFreeRates[k])
+				i++
+			}
+		}
+		paths[sim] = path
+
+		annualizedReturns[sim] = math.Pow(path[len(path)-1]/path[0], 1/numYears) - 1
+		mean, _ := stats.Mean(excessReturns)
+		stdev, _ := stats.StandardDeviationSample(excessReturns)
+		if stdev != 0 {
+			sharpes[sim] = mean / stdev * math.Sqrt(periodsPerYear)
+		}
+		drawdowns[sim] = maxDrawdown(dates, path).Depth
+	}
+
+	out := &BootstrapResult{
+		NumSimulations:   options.NumSimulations,
+		AnnualizedReturn: confidenceInterval(annualizedReturns),
+		SharpeRatio:      confidenceInterval(sharpes),
+		MaxDrawdown:      confidenceInterval(drawdowns),
+		FanChart:         []FanChartPoint{},
+	}
+	valuesOnDate := make([]float64, options.NumSimulations)
+	for i, date := range dates {
+		for sim, path := range paths {
+			valuesOnDate[sim] = path[i]
+		}
+		sort.Float64s(valuesOnDate)
+		out.FanChart = append(out.FanChart, FanChartPoint{
+			Date: date,
+			P5:   percentileOfSorted(valuesOnDate, 0.05),
+			P25:  percentileOfSorted(valuesOnDate, 0.25),
+			P50:  percentileOfSorted(valuesOnDate, 0.5),
+			P75:  percentileOfSorted(valuesOnDate, 0.75),
+			P95:  percentileOfSorted(valuesOnDate, 0.95),
+		})
+	}
+
+	return out, nil
+}
+
+func confidenceInterval(samples []float64) ConfidenceInterval {
+	sorted := append([]float64{}, samples...)
+	sort.Float64s(sorted)
+	return ConfidenceInterval{
+		Lower:  percentileOfSorted(sorted, 0.05),
+		Median: percentileOfSorted(sorted, 0.5),
+		Upper:  percentileOfSorted(sorted, 0.95),
+	}
+}
+
+// percentileOfSorted linearly interpolates between the two closest
+// samples, so small simulation counts still move smoothly
+func percentileOfSorted(sorted []float64, p float64) float64 {
+	position := p * float64(len(sorted)-1)
+	lower := int(math.Floor(position))
+	upper := int(math.Ceil(position))
+	weight := position - float64(lower)
+	return sorted[lower]*(1-weight) + sorted[upper]*weight
+}
@@ -0,0 +1,64 @@
+package calculator
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBootstrap(t *testing.T) {
+	dates := []time.Time{}
+	values := []float64{100}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 504; i++ {
+		dates = append(dates, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, i))
+		if i > 0 {
+			values = append(values, values[i-1]*(1+0.0005+0.01*r.NormFloat64()))
+		}
+	}
+	options := BootstrapOptions{
+		NumSimulations: 200,
+		BlockSize:      10,
+		Seed:           42,
+	}
+
+	result, err := Bootstrap(dates, values, nil, options)
+	require.NoError(t, err)
+	require.Equal(t, 200, result.NumSimulations)
+
+	for _, ci := range []ConfidenceInterval{result.AnnualizedReturn, result.SharpeRatio, result.MaxDrawdown} {
+		require.Less(t, ci.Lower, ci.Median)
+		require.Less(t, ci.Median, ci.Upper)
+	}
+	require.Greater(t, result.MaxDrawdown.Lower, 0.0)
+
+	require.Len(t, result.FanChart, len(dates))
+	// every path starts from the same value, then fans out
+	first := result.FanChart[0]
+	require.Equal(t, 100.0, first.P5)
+	require.Equal(t, 100.0, first.P95)
+	last := result.FanChart[len(result.FanChart)-1]
+	require.Less(t, last.P5, last.P25)
+	require.Less(t, last.P25, last.P50)
+	require.Less(t, last.P50, last.P75)
+	require.Less(t, last.P75, last.P95)
+
+	again, err := Bootstrap(dates, values, nil, options)
+	require.NoError(t, err)
+	require.Equal(t, result, again)
+
+	options.Seed = 43
+	different, err := Bootstrap(dates, values, nil, options)
+	require.NoError(t, err)
+	require.NotEqual(t, result.SharpeRatio, different.SharpeRatio)
+}
+
+func Test_percentileOfSorted(t *testing.T) {
+	sorted := []float64{1, 2, 3, 4, 5}
+	require.Equal(t, 1.0, percentileOfSorted(sorted, 0))
+	require.Equal(t, 3.0, percentileOfSorted(sorted, 0.5))
+	require.Equal(t, 5.0, percentileOfSorted(sorted, 1))
+	require.InDelta(t, 1.2, percentileOfSorted(sorted, 0.05), 1e-9)
+}
@@ -17,6 +17,16 @@ type ComputeTargetPortfolioInput struct {
 	FactorScores     map[string]*float64
 	TargetNumTickers int
 	TickerIDMap      map[string]uuid.UUID
+	// optional - nil is long-only
+	LongShort *internal.LongShortOptions
+	// optional - nil is unconstrained
+	Constraints *internal.PositionConstraints
+	// only needed for Constraints.MaxNamesPerSector
+	SectorBySymbol map[string]string
+	// zero value is the z-score tilt
+	Weighting internal.WeightingOptions
+	// values from Weighting.Scheme.DataExpression(), if it has one
+	WeightingData map[string]*float64
 }
 
 type ComputeTargetPortfolioResponse struct {
@@ -32,14 +42,31 @@ func ComputeTargetPortfolio(in ComputeTargetPortfolioInput) (*ComputeTargetPortf
 	if in.PortfolioValue.LessThan(decimal.NewFromFloat(0.001)) {
 		return nil, fmt.Errorf("cannot compute target portfolio with value %s", in.PortfolioValue.String())
 	}
-	if in.TargetNumTickers < 3 {
-		return nil, fmt.Errorf("insufficient tickers: at least 3 target tickers required, got %d", in.TargetNumTickers)
+	if in.TargetNumTickers < 1 {
+		return nil, fmt.Errorf("insufficient tickers: at least 1 target ticker required, got %d", in.TargetNumTickers)
 	}
 
 	computeTargetInput := internal.CalculateTargetAssetWeightsInput{
-		Date:                 in.Date,
-		FactorScoresBySymbol: in.FactorScores,
-		NumTickers:           in.TargetNumTickers,
+		Date:                  in.Date,
+		FactorScoresBySymbol:  in.FactorScores,
+		NumTickers:            in.TargetNumTickers,
+		LongShort:             in.LongShort,
+		SectorBySymbol:        in.SectorBySymbol,
+		Weighting:             in.Weighting,
+		WeightingDataBySymbol: in.WeightingData,
+	}
+	if in.Constraints != nil {
+		// weighting doesn't know the portfolio value, so the smallest
+		// position is expressed as a weight
+		constraints := *in.Constraints
+		if constraints.MinPositionDollars > 0 {
+			portfolioValue := in.PortfolioValue.InexactFloat64()
+			if constraints.MinPositionDollars > portfolioValue {
+				return nil, fmt.Errorf("portfolio value %f is below the minimum position size %f", portfolioValue, constraints.MinPositionDollars)
+			}
+			constraints.MinWeight = max(constraints.MinWeight, constraints.MinPositionDollars/portfolioValue)
+		}
+		computeTargetInput.Constraints = &constraints
 	}
 	newWeights, err := internal.CalculateTargetAssetWeights(computeTargetInput)
 	if err != nil {
@@ -47,8 +74,17 @@ func ComputeTargetPortfolio(in ComputeTargetPortfolioInput) (*ComputeTargetPortf
 	}
 
 	// this is where the assumption that target portfolio will not hold
-	// cash comes from - the field is just not populated
+	// cash comes from - the field is just not populated. long/short books
+	// are the exception, since they hold short proceeds and anything not
+	// covered by net exposure, as are constrained books with a cash buffer
 	targetPortfolio := domain.NewPortfolio()
+	if in.LongShort != nil || (in.Constraints != nil && in.Constraints.CashBuffer > 0) {
+		invested := decimal.Zero
+		for _, weight := range newWeights {
+			invested = invested.Add(in.PortfolioValue.Mul(decimal.NewFromFloat(weight)).Round(3))
+		}
+		targetPortfolio.SetCash(in.PortfolioValue.Sub(invested))
+	}
 
 	// convert weights into quantities
 	for symbol, weight := range newWeights {
@@ -0,0 +1,167 @@
+package calculator
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// cross-sectional operators compare a name to the rest of the universe
+// on the same day, so they can't be evaluated one ticker at a time like
+// everything else. their argument is scored for every member first, the
+// operator is applied across the day, and then the rest of the
+// expression is evaluated with each call standing in for its result
+var crossSectionalOperators = map[string]bool{
+	"rank":       true,
+	"zscore":     true,
+	"percentile": true,
+	"winsorize":  true,
+	"demean":     true,
+}
+
+// winsorize clips this fraction off each tail unless told otherwise
+const defaultWinsorizeLimit = 0.05
+
+func validateWinsorizeLimit(limit float64) error {
+	if limit <= 0 || limit >= 0.5 {
+		return fmt.Errorf("winsorize limit must be between 0 and 0.5, got %g", limit)
+	}
+	return nil
+}
+
+type crossSectionalTerm struct {
+	Operator string
+	// scored per ticker before the operator is applied
+	Argument *parsedExpression
+	// only used by winsorize
+	Limit float64
+	// every call the term's result is read from. identical calls
+	// share a term
+	calls []span
+}
+
+// findCrossSectional returns the outermost cross-sectional calls in the
+// expression, e.g. zscore(pricePercentChange(nYearsAgo(1), currentDate)).
+// calls nested in another call's argument are left for when that
+// argument gets scored
+func findCrossSectional(expression *parsedExpression) []crossSectionalTerm {
+	terms := []crossSectionalTerm{}
+	termByCall := map[string]int{}
+
+	var walk func(n exprNode)
+	walk = func(n exprNode) {
+		call, ok := n.(callNode)
+		if !ok || !crossSectionalOperators[call.name] {
+			for _, child := range childNodes(n) {
+				walk(child)
+			}
+			return
+		}
+
+		argument := expression.subExpression(call.args[0])
+		limit := 0.0
+		if call.name == "winsorize" {
+			limit = defaultWinsorizeLimit
+			if len(call.args) == 2 {
+				// parsing checked it's a number written out
+				limit = toFloat(call.args[1].(numberNode).value)
+			}
+		}
+
+		key := fmt.Sprintf("%s(%s, %g)", call.name, argument.text(), limit)
+		i, ok := termByCall[key]
+		if !ok {
+			i = len(terms)
+			termByCall[key] = i
+			terms = append(terms, crossSectionalTerm{
+				Operator: call.name,
+				Argument: argument,
+				Limit:    limit,
+			})
+		}
+		terms[i].calls = append(terms[i].calls, call.span)
+	}
+	walk(expression.root)
+
+	return terms
+}
+
+// applyCrossSectional runs the term's operator over every scored name on
+// a day. names without a score are left out
+func applyCrossSectional(term crossSectionalTerm, scores map[string]*float64) map[string]float64 {
+	symbols := []string{}
+	for symbol, score := range scores {
+		if score != nil {
+			symbols = append(symbols, symbol)
+		}
+	}
+	// lowest first, ties broken by symbol so reruns match
+	sort.Slice(symbols, func(i, j int) bool {
+		a, b := *scores[symbols[i]], *scores[symbols[j]]
+		if a != b {
+			return a < b
+		}
+		return symbols[i] < symbols[j]
+	})
+
+	out := map[string]float64{}
+	n := len(symbols)
+	if n == 0 {
+		return out
+	}
+
+	mean := 0.0
+	for _, symbol := range symbols {
+		mean += *scores[symbol]
+	}
+	mean /= float64(n)
+
+	switch term.Operator {
+	case "rank", "percentile":
+		// ties share the average of their ranks
+		for i := 0; i < n; {
+			j := i
+			for j < n && *scores[symbols[j]] == *scores[symbols[i]] {
+				j++
+			}
+			rank := float64(i+j+1) / 2
+			for _, symbol := range symbols[i:j] {
+				if term.Operator == "rank" {
+					out[symbol] = rank
+				} else if n == 1 {
+					out[symbol] = 0.5
+				} else {
+					out[symbol] = (rank - 1) / float64(n-1)
+				}
+			}
+			i = j
+		}
+	case "zscore":
+		variance := 0.0
+		for _, symbol := range symbols {
+			variance += math.Pow(*scores[symbol]-mean, 2)
+		}
+		stdev := math.Sqrt(variance / float64(n))
+		for _, symbol := range symbols {
+			if stdev == 0 {
+				out[symbol] = 0
+			} else {
+				out[symbol] = (*scores[symbol] - mean) / stdev
+			}
+		}
+	case "demean":
+		for _, symbol := range symbols {
+			out[symbol] = *scores[symbol] - mean
+		}
+	case "winsorize":
+		sorted := make([]float64, n)
+		for i, symbol := range symbols {
+			sorted[i] = *scores[symbol]
+		}
+		low, high := percentileOfSorted(sorted, term.Limit), percentileOfSorted(sorted, 1-term.Limit)
+		for _, symbol := range symbols {
+			out[symbol] = math.Min(math.Max(*scores[symbol], low), high)
+		}
+	}
+	return out
+}
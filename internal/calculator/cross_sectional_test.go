@@ -0,0 +1,96 @@
+package calculator
+
+import (
+	"context"
+	"factorbacktest/internal/util"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindCrossSectional(t *testing.T) {
+	find := func(t *testing.T, expression string) []crossSectionalTerm {
+		parsed, err := parseFactorExpression(expression)
+		require.NoError(t, err)
+		return findCrossSectional(parsed)
+	}
+
+	t.Run("finds outermost calls", func(t *testing.T) {
+		terms := find(t, `zscore(pricePercentChange(nYearsAgo(1), currentDate)) + 0.5 * zscore(-stdev(nYearsAgo(1), currentDate)) - zscore(pricePercentChange(nYearsAgo(1), currentDate))`)
+		require.Len(t, terms, 2)
+		require.Equal(t, "zscore", terms[0].Operator)
+		require.Equal(t, "pricePercentChange(nYearsAgo(1), currentDate)", terms[0].Argument.text())
+		require.Len(t, terms[0].calls, 2)
+		require.Equal(t, "-stdev(nYearsAgo(1), currentDate)", terms[1].Argument.text())
+		require.Len(t, terms[1].calls, 1)
+	})
+
+	t.Run("leaves nested calls", func(t *testing.T) {
+		terms := find(t, `rank(zscore(beta("SPY", nYearsAgo(1), currentDate))) * price(currentDate)`)
+		require.Len(t, terms, 1)
+		require.Equal(t, "rank", terms[0].Operator)
+		require.Equal(t, `zscore(beta("SPY", nYearsAgo(1), currentDate))`, terms[0].Argument.text())
+		require.Len(t, findCrossSectional(terms[0].Argument), 1)
+	})
+
+	t.Run("winsorize limit", func(t *testing.T) {
+		terms := find(t, "winsorize(price(currentDate), 0.1) + winsorize(price(currentDate))")
+		require.Len(t, terms, 2)
+		require.Equal(t, 0.1, terms[0].Limit)
+		require.Equal(t, defaultWinsorizeLimit, terms[1].Limit)
+	})
+
+	t.Run("plain expressions have none", func(t *testing.T) {
+		require.Empty(t, find(t, "1/pbRatio(currentDate)"))
+	})
+
+	t.Run("calls stand in for their results", func(t *testing.T) {
+		parsed, err := parseFactorExpression("zscore(price(currentDate)) * 2 + rank(price(currentDate))")
+		require.NoError(t, err)
+		crossSectional := map[span]float64{}
+		for i, term := range findCrossSectional(parsed) {
+			for _, call := range term.calls {
+				crossSectional[call] = float64(i + 1)
+			}
+		}
+		result, err := evaluateFactorExpression(context.Background(), nil, nil, parsed, "AAPL", &DryRunFactorMetricsHandler{}, util.NewDate(2020, 6, 1), crossSectional)
+		require.NoError(t, err)
+		require.Equal(t, 4.0, result.Value)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		require.ErrorContains(t, ValidateFactorExpression("zscore(price(currentDate)"), "expected ')'")
+		require.ErrorContains(t, ValidateFactorExpression("rank(price(currentDate), 2)"), "rank needs 1 args")
+		require.ErrorContains(t, ValidateFactorExpression("winsorize(price(currentDate), 0.5)"), "between 0 and 0.5")
+	})
+}
+
+func TestApplyCrossSectional(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	scores := map[string]*float64{
+		"AAPL": f(1),
+		"MSFT": f(2),
+		"GOOG": f(2),
+		"META": f(7),
+		"NFLX": nil,
+	}
+
+	require.Equal(t, map[string]float64{"AAPL": 1, "MSFT": 2.5, "GOOG": 2.5, "META": 4}, applyCrossSectional(crossSectionalTerm{Operator: "rank"}, scores))
+	require.Equal(t, map[string]float64{"AAPL": 0, "MSFT": 0.5, "GOOG": 0.5, "META": 1}, applyCrossSectional(crossSectionalTerm{Operator: "percentile"}, scores))
+	require.Equal(t, map[string]float64{"AAPL": -2, "MSFT": -1, "GOOG": -1, "META": 4}, applyCrossSectional(crossSectionalTerm{Operator: "demean"}, scores))
+
+	z := applyCrossSectional(crossSectionalTerm{Operator: "zscore"}, scores)
+	require.InDelta(t, -2/math.Sqrt(5.5), z["AAPL"], 1e-9)
+	require.InDelta(t, 4/math.Sqrt(5.5), z["META"], 1e-9)
+	require.NotContains(t, z, "NFLX")
+
+	// sorted values are 1, 2, 2, 7, so the 25th and 75th percentiles
+	// are 1.75 and 3.25
+	w := applyCrossSectional(crossSectionalTerm{Operator: "winsorize", Limit: 0.25}, scores)
+	require.Equal(t, map[string]float64{"AAPL": 1.75, "MSFT": 2, "GOOG": 2, "META": 3.25}, w)
+
+	// every name the same
+	require.Equal(t, map[string]float64{"AAPL": 0}, applyCrossSectional(crossSectionalTerm{Operator: "zscore"}, map[string]*float64{"AAPL": f(3)}))
+	require.Empty(t, applyCrossSectional(crossSectionalTerm{Operator: "rank"}, map[string]*float64{}))
+}
@@ -0,0 +1,54 @@
+package calculator
+
+import (
+	"context"
+	"factorbacktest/internal/util"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// documentedExpressions are the example expressions in the api docs. they
+// have to still appear in the file, still parse, and still evaluate
+var documentedExpressions = []struct {
+	file       string
+	expression string
+	// true or false rather than a number, e.g. universe filter conditions
+	condition bool
+}{
+	{"api/backtest_sweep.resolver.go", "pricePercentChange(nMonthsAgo($lookback), currentDate)", false},
+	{"api/backtest.resolver.go", "price(currentDate) > 5 && stdev(nYearsAgo(1), currentDate) < 0.6", true},
+	{"api/backtest.resolver.go", "marketCap(currentDate)", false},
+	{"internal/service/universe_filter.go", "price(currentDate) > 5 && stdev(nYearsAgo(1), currentDate) < 0.6", true},
+}
+
+func checkDocumentedExpression(expression string, condition bool) error {
+	// sweep placeholders get a value before they're parsed
+	expression = strings.ReplaceAll(expression, "$lookback", "6")
+	if condition {
+		if err := ValidateConditionExpression(expression); err != nil {
+			return err
+		}
+		// so it can be dry run like a factor
+		expression = fmt.Sprintf("(%s) ? 1.0 : 0.0", expression)
+	}
+	parsed, err := parseFactorExpression(expression)
+	if err != nil {
+		return err
+	}
+	_, err = evaluateFactorExpression(context.Background(), nil, nil, parsed, "AAPL", &DryRunFactorMetricsHandler{}, util.NewDate(2020, 6, 1), nil)
+	return err
+}
+
+func TestDocumentedExpressions(t *testing.T) {
+	for _, doc := range documentedExpressions {
+		src, err := os.ReadFile(filepath.Join("..", "..", doc.file))
+		require.NoError(t, err)
+		require.Contains(t, string(src), doc.expression, "example is no longer in %s", doc.file)
+		require.NoError(t, checkDocumentedExpression(doc.expression, doc.condition), doc.expression)
+	}
+}
@@ -0,0 +1,981 @@
+package calculator
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the factor language: numbers, "strings", true/false, variables like
+// currentDate, function calls, + - * / %, comparisons, && || !, and
+// cond ? a : b. expressions are parsed and type checked once, then the
+// tree is evaluated for every (ticker, date)
+
+// ExpressionError points at the part of an expression that's wrong.
+// Start and End are byte offsets, End exclusive
+type ExpressionError struct {
+	Start   int
+	End     int
+	Message string
+}
+
+func (e *ExpressionError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Message, e.Start)
+}
+
+func expressionErrorf(start, end int, format string, args ...interface{}) *ExpressionError {
+	return &ExpressionError{
+		Start:   start,
+		End:     end,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+type valueType int
+
+const (
+	typeInt valueType = iota
+	typeFloat
+	typeDate
+	typeString
+	typeBool
+)
+
+func (t valueType) String() string {
+	switch t {
+	case typeInt, typeFloat:
+		return "number"
+	case typeDate:
+		return "date"
+	case typeString:
+		return "string"
+	}
+	return "bool"
+}
+
+func (t valueType) isNumber() bool {
+	return t == typeInt || t == typeFloat
+}
+
+// argKind is what a function accepts in an argument
+type argKind int
+
+const (
+	argNumber argKind = iota
+	argInt
+	// a number written out, e.g. 0.05
+	argNumberLiteral
+	// a date, or a date written out in quotes, e.g. "2020-01-01"
+	argDate
+	argString
+)
+
+type functionSignature struct {
+	args []argKind
+	// how many trailing args can be left out
+	optional int
+	returns  valueType
+	// checks string literals, e.g. fundamental field names
+	validateString func(string) error
+	// checks numbers written out, e.g. winsorize's limit
+	validateNumber func(float64) error
+}
+
+// functionSignatures has every function constructFunctionMap provides, plus
+// the cross-sectional operators
+var functionSignatures = map[string]functionSignature{
+	"addDate":    {args: []argKind{argDate, argInt, argInt, argInt}, returns: typeDate},
+	"nDaysAgo":   {args: []argKind{argInt}, returns: typeDate},
+	"nMonthsAgo": {args: []argKind{argInt}, returns: typeDate},
+	"nYearsAgo":  {args: []argKind{argInt}, returns: typeDate},
+
+	"price":              {args: []argKind{argDate}, returns: typeFloat},
+	"pricePercentChange": {args: []argKind{argDate, argDate}, returns: typeFloat},
+	"stdev":              {args: []argKind{argDate, argDate}, returns: typeFloat},
+	"marketCap":          {args: []argKind{argDate}, returns: typeFloat},
+	"pbRatio":            {args: []argKind{argDate}, returns: typeFloat},
+	"peRatio":            {args: []argKind{argDate}, returns: typeFloat},
+
+	"fundamental": {args: []argKind{argString, argDate}, returns: typeFloat, validateString: validateFundamentalField},
+	"ttm":         {args: []argKind{argString, argDate}, returns: typeFloat, validateString: validateFundamentalField},
+	"yoyGrowth":   {args: []argKind{argString, argDate}, returns: typeFloat, validateString: validateFundamentalField},
+
+	"sma":              {args: []argKind{argDate, argDate}, returns: typeFloat},
+	"ema":              {args: []argKind{argDate, argDate}, returns: typeFloat},
+	"rsi":              {args: []argKind{argDate, argDate}, returns: typeFloat},
+	"macd":             {args: []argKind{argDate, argDate}, returns: typeFloat},
+	"maxDrawdown":      {args: []argKind{argDate, argDate}, returns: typeFloat},
+	"distanceFromHigh": {args: []argKind{argDate, argDate}, returns: typeFloat},
+	"skew":             {args: []argKind{argDate, argDate}, returns: typeFloat},
+	"beta":             {args: []argKind{argString, argDate, argDate}, returns: typeFloat},
+	"correlation":      {args: []argKind{argString, argDate, argDate}, returns: typeFloat},
+
+	"rank":       {args: []argKind{argNumber}, returns: typeFloat},
+	"zscore":     {args: []argKind{argNumber}, returns: typeFloat},
+	"percentile": {args: []argKind{argNumber}, returns: typeFloat},
+	"demean":     {args: []argKind{argNumber}, returns: typeFloat},
+	"winsorize":  {args: []argKind{argNumber, argNumberLiteral}, optional: 1, returns: typeFloat, validateNumber: validateWinsorizeLimit},
+}
+
+type expressionFunction func(args ...interface{}) (interface{}, error)
+
+type evalEnv struct {
+	variables map[string]interface{}
+	functions map[string]expressionFunction
+	// results of cross-sectional calls, by where the call is
+	crossSectional map[span]float64
+	// take both sides of every && || and ?:, so a dry run sees every
+	// price the expression could need
+	allBranches bool
+}
+
+// parsedExpression is a type checked tree, safe to evaluate from many
+// goroutines at once
+type parsedExpression struct {
+	root       exprNode
+	resultType valueType
+	// the text the tree's spans point into
+	source string
+}
+
+// subExpression is the part of e rooted at n, e.g. a cross-sectional
+// call's argument
+func (e *parsedExpression) subExpression(n exprNode) *parsedExpression {
+	return &parsedExpression{
+		root:       n,
+		resultType: n.valueType(),
+		source:     e.source,
+	}
+}
+
+// text is the source of the expression, without surrounding whitespace
+func (e *parsedExpression) text() string {
+	s := e.root.position()
+	return e.source[s.start:s.end]
+}
+
+// ValidateFactorExpression checks that an expression parses, only calls
+// functions that exist with the right arguments, and produces a number.
+// errors are *ExpressionError
+func ValidateFactorExpression(expression string) error {
+	_, err := parseFactorExpression(expression)
+	return err
+}
+
+// ValidateConditionExpression is ValidateFactorExpression for expressions
+// that should be true or false, e.g. price(currentDate) > 5
+func ValidateConditionExpression(expression string) error {
+	parsed, err := parseExpression(expression, expressionVariables(nil))
+	if err != nil {
+		return err
+	}
+	if parsed.resultType != typeBool {
+		return expressionErrorf(0, len(expression), "condition must be true or false, got a %s", parsed.resultType)
+	}
+	return nil
+}
+
+func expressionVariables(numberVariables []string) map[string]valueType {
+	out := map[string]valueType{
+		"currentDate": typeDate,
+	}
+	for _, name := range numberVariables {
+		out[name] = typeFloat
+	}
+	return out
+}
+
+// parseFactorExpression parses an expression that scores a ticker
+func parseFactorExpression(expression string) (*parsedExpression, error) {
+	parsed, err := parseExpression(expression, expressionVariables(nil))
+	if err != nil {
+		return nil, err
+	}
+	if !parsed.resultType.isNumber() {
+		return nil, expressionErrorf(0, len(expression), "factor expression must be a number, got a %s", parsed.resultType)
+	}
+	return parsed, nil
+}
+
+func parseExpression(expression string, variables map[string]valueType) (*parsedExpression, error) {
+	tokens, err := lex(expression)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{
+		tokens:    tokens,
+		variables: variables,
+	}
+	if p.peek().kind == tokenEOF {
+		return nil, expressionErrorf(0, 0, "expression is empty")
+	}
+	root, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, expressionErrorf(t.start, t.end, "unexpected %s", t.describe())
+	}
+	return &parsedExpression{
+		root:       root,
+		resultType: root.valueType(),
+		source:     expression,
+	}, nil
+}
+
+func (e *parsedExpression) evaluate(env evalEnv) (interface{}, error) {
+	return e.root.eval(env)
+}
+
+// lexer
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdentifier
+	tokenOperator
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	start int
+	end   int
+}
+
+func (t token) describe() string {
+	if t.kind == tokenEOF {
+		return "end of expression"
+	}
+	return fmt.Sprintf("'%s'", t.text)
+}
+
+// longest first, so <= isn't read as <
+var operators = []string{"==", "!=", "<=", ">=", "&&", "||", "+", "-", "*", "/", "%", "(", ")", ",", "?", ":", "!", "<", ">"}
+
+func lex(expression string) ([]token, error) {
+	tokens := []token{}
+	for i := 0; i < len(expression); {
+		c := expression[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case isDigit(c) || (c == '.' && i+1 < len(expression) && isDigit(expression[i+1])):
+			j := i
+			for j < len(expression) && (isDigit(expression[j]) || expression[j] == '.') {
+				j++
+			}
+			// exponent, e.g. 1e-5
+			if j < len(expression) && (expression[j] == 'e' || expression[j] == 'E') {
+				k := j + 1
+				if k < len(expression) && (expression[k] == '+' || expression[k] == '-') {
+					k++
+				}
+				if k < len(expression) && isDigit(expression[k]) {
+					for k < len(expression) && isDigit(expression[k]) {
+						k++
+					}
+					j = k
+				}
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: expression[i:j], start: i, end: j})
+			i = j
+		case c == '"':
+			end, err := skipString(expression, i)
+			if err != nil {
+				return nil, expressionErrorf(i, len(expression), "string is missing its closing quote")
+			}
+			tokens = append(tokens, token{kind: tokenString, text: expression[i:end], start: i, end: end})
+			i = end
+		case isIdentifierStart(c):
+			j := i
+			for j < len(expression) && (isIdentifierStart(expression[j]) || isDigit(expression[j])) {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokenIdentifier, text: expression[i:j], start: i, end: j})
+			i = j
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(expression[i:], op) {
+					tokens = append(tokens, token{kind: tokenOperator, text: op, start: i, end: i + len(op)})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, expressionErrorf(i, i+1, "unexpected character '%c'", c)
+			}
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, start: len(expression), end: len(expression)}), nil
+}
+
+// skipString returns the index just past the string literal starting at i
+func skipString(expression string, i int) (int, error) {
+	for j := i + 1; j < len(expression); j++ {
+		switch expression[j] {
+		case '\\':
+			j++
+		case '"':
+			return j + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("unterminated string at position %d", i)
+}
+
+func isIdentifierStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// parser. lowest precedence first: ?:, ||, &&, == !=, < <= > >=, + -,
+// * / %, then unary - + !
+
+type parser struct {
+	tokens    []token
+	i         int
+	variables map[string]valueType
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.i]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.i]
+	if t.kind != tokenEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *parser) isOperator(ops ...string) bool {
+	t := p.peek()
+	if t.kind != tokenOperator {
+		return false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) expect(op string) (token, error) {
+	t := p.next()
+	if t.kind != tokenOperator || t.text != op {
+		return t, expressionErrorf(t.start, t.end, "expected '%s' but found %s", op, t.describe())
+	}
+	return t, nil
+}
+
+func (p *parser) parseTernary() (exprNode, error) {
+	cond, err := p.parseBinary(0)
+	if err != nil {
+		return nil, err
+	}
+	if !p.isOperator("?") {
+		return cond, nil
+	}
+	p.next()
+	then, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	otherwise, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	return newTernaryNode(cond, then, otherwise)
+}
+
+var binaryPrecedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!="},
+	{"<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *parser) parseBinary(level int) (exprNode, error) {
+	if level == len(binaryPrecedence) {
+		return p.parseUnary()
+	}
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator(binaryPrecedence[level]...) {
+		op := p.next()
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left, err = newBinaryNode(op, left, right)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (exprNode, error) {
+	if p.isOperator("-", "+", "!") {
+		op := p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return newUnaryNode(op, operand)
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (exprNode, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenNumber:
+		return newNumberNode(t)
+	case tokenString:
+		value, err := strconv.Unquote(t.text)
+		if err != nil {
+			return nil, expressionErrorf(t.start, t.end, "invalid string %s", t.text)
+		}
+		return stringNode{span: span{t.start, t.end}, value: value}, nil
+	case tokenIdentifier:
+		if p.isOperator("(") {
+			return p.parseCall(t)
+		}
+		if t.text == "true" || t.text == "false" {
+			return boolNode{span: span{t.start, t.end}, value: t.text == "true"}, nil
+		}
+		variableType, ok := p.variables[t.text]
+		if !ok {
+			if _, isFunction := functionSignatures[t.text]; isFunction {
+				return nil, expressionErrorf(t.start, t.end, "%s is a function, call it like %s(...)", t.text, t.text)
+			}
+			return nil, expressionErrorf(t.start, t.end, "unknown variable %s", t.text)
+		}
+		return variableNode{span: span{t.start, t.end}, name: t.text, typ: variableType}, nil
+	case tokenOperator:
+		if t.text == "(" {
+			inner, err := p.parseTernary()
+			if err != nil {
+				return nil, err
+			}
+			closing, err := p.expect(")")
+			if err != nil {
+				return nil, err
+			}
+			return parenNode{span: span{t.start, closing.end}, inner: inner}, nil
+		}
+	}
+	return nil, expressionErrorf(t.start, t.end, "unexpected %s", t.describe())
+}
+
+func (p *parser) parseCall(name token) (exprNode, error) {
+	p.next() // (
+	args := []exprNode{}
+	if !p.isOperator(")") {
+		for {
+			arg, err := p.parseTernary()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if !p.isOperator(",") {
+				break
+			}
+			p.next()
+		}
+	}
+	closing, err := p.expect(")")
+	if err != nil {
+		return nil, err
+	}
+	return newCallNode(name, args, closing.end)
+}
+
+// nodes
+
+type span struct {
+	start int
+	end   int
+}
+
+func (s span) position() span {
+	return s
+}
+
+type exprNode interface {
+	position() span
+	valueType() valueType
+	eval(env evalEnv) (interface{}, error)
+}
+
+type numberNode struct {
+	span
+	value interface{}
+}
+
+func newNumberNode(t token) (exprNode, error) {
+	if !strings.ContainsAny(t.text, ".eE") {
+		if v, err := strconv.Atoi(t.text); err == nil {
+			return numberNode{span: span{t.start, t.end}, value: v}, nil
+		}
+	}
+	v, err := strconv.ParseFloat(t.text, 64)
+	if err != nil {
+		return nil, expressionErrorf(t.start, t.end, "invalid number %s", t.text)
+	}
+	return numberNode{span: span{t.start, t.end}, value: v}, nil
+}
+
+func (n numberNode) valueType() valueType {
+	if _, ok := n.value.(int); ok {
+		return typeInt
+	}
+	return typeFloat
+}
+
+func (n numberNode) eval(env evalEnv) (interface{}, error) {
+	return n.value, nil
+}
+
+type boolNode struct {
+	span
+	value bool
+}
+
+func (n boolNode) valueType() valueType {
+	return typeBool
+}
+
+func (n boolNode) eval(env evalEnv) (interface{}, error) {
+	return n.value, nil
+}
+
+type stringNode struct {
+	span
+	value string
+}
+
+func (n stringNode) valueType() valueType {
+	return typeString
+}
+
+func (n stringNode) eval(env evalEnv) (interface{}, error) {
+	return n.value, nil
+}
+
+type variableNode struct {
+	span
+	name string
+	typ  valueType
+}
+
+func (n variableNode) valueType() valueType {
+	return n.typ
+}
+
+func (n variableNode) eval(env evalEnv) (interface{}, error) {
+	v, ok := env.variables[n.name]
+	if !ok {
+		return nil, fmt.Errorf("variable %s has no value", n.name)
+	}
+	return v, nil
+}
+
+type parenNode struct {
+	span
+	inner exprNode
+}
+
+func (n parenNode) valueType() valueType {
+	return n.inner.valueType()
+}
+
+func (n parenNode) eval(env evalEnv) (interface{}, error) {
+	return n.inner.eval(env)
+}
+
+type unaryNode struct {
+	span
+	op      string
+	operand exprNode
+}
+
+func newUnaryNode(op token, operand exprNode) (exprNode, error) {
+	s := span{op.start, operand.position().end}
+	t := operand.valueType()
+	if op.text == "!" && t != typeBool {
+		return nil, expressionErrorf(s.start, s.end, "! needs true or false, got a %s", t)
+	}
+	if op.text != "!" && !t.isNumber() {
+		return nil, expressionErrorf(s.start, s.end, "%s needs a number, got a %s", op.text, t)
+	}
+	return unaryNode{span: s, op: op.text, operand: operand}, nil
+}
+
+func (n unaryNode) valueType() valueType {
+	return n.operand.valueType()
+}
+
+func (n unaryNode) eval(env evalEnv) (interface{}, error) {
+	v, err := n.operand.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "!":
+		return !v.(bool), nil
+	case "-":
+		if i, ok := v.(int); ok {
+			return -i, nil
+		}
+		return -toFloat(v), nil
+	}
+	return v, nil
+}
+
+type binaryNode struct {
+	span
+	op    string
+	left  exprNode
+	right exprNode
+	typ   valueType
+}
+
+func newBinaryNode(op token, left, right exprNode) (exprNode, error) {
+	s := span{left.position().start, right.position().end}
+	l, r := left.valueType(), right.valueType()
+	n := binaryNode{span: s, op: op.text, left: left, right: right}
+	mismatch := expressionErrorf(op.start, op.end, "cannot use %s between a %s and a %s", op.text, l, r)
+
+	switch op.text {
+	case "+", "-", "*", "/", "%":
+		if !l.isNumber() || !r.isNumber() {
+			return nil, mismatch
+		}
+		// int / int truncates, like goval did, so e.g. nDaysAgo(365/2)
+		// still takes an int
+		n.typ = typeFloat
+		if l == typeInt && r == typeInt {
+			n.typ = typeInt
+		}
+	case "<", "<=", ">", ">=":
+		if !(l.isNumber() && r.isNumber()) && !(l == typeDate && r == typeDate) {
+			return nil, mismatch
+		}
+		n.typ = typeBool
+	case "==", "!=":
+		if !(l.isNumber() && r.isNumber()) && l != r {
+			return nil, mismatch
+		}
+		n.typ = typeBool
+	case "&&", "||":
+		if l != typeBool || r != typeBool {
+			return nil, mismatch
+		}
+		n.typ = typeBool
+	}
+	return n, nil
+}
+
+func (n binaryNode) valueType() valueType {
+	return n.typ
+}
+
+// && and || skip the right side when the left decides it, so e.g.
+// price(currentDate) > 0 && ... doesn't read data it won't use
+func (n binaryNode) eval(env evalEnv) (interface{}, error) {
+	l, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "&&" || n.op == "||" {
+		if decided := l.(bool) == (n.op == "||"); decided && !env.allBranches {
+			return l, nil
+		}
+	}
+	r, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "&&":
+		return l.(bool) && r.(bool), nil
+	case "||":
+		return l.(bool) || r.(bool), nil
+	case "==", "!=":
+		equal := l == r
+		if n.left.valueType().isNumber() {
+			equal = toFloat(l) == toFloat(r)
+		}
+		return equal == (n.op == "=="), nil
+	case "<", "<=", ">", ">=":
+		var cmp int
+		if n.left.valueType() == typeDate {
+			cmp = strings.Compare(l.(string), r.(string))
+		} else if a, b := toFloat(l), toFloat(r); a < b {
+			cmp = -1
+		} else if a > b {
+			cmp = 1
+		}
+		switch n.op {
+		case "<":
+			return cmp < 0, nil
+		case "<=":
+			return cmp <= 0, nil
+		case ">":
+			return cmp > 0, nil
+		}
+		return cmp >= 0, nil
+	}
+
+	if n.typ == typeInt {
+		a, b := l.(int), r.(int)
+		switch n.op {
+		case "+":
+			return a + b, nil
+		case "-":
+			return a - b, nil
+		case "*":
+			return a * b, nil
+		}
+		if b == 0 {
+			if n.op == "/" {
+				return nil, fmt.Errorf("division by zero")
+			}
+			return nil, fmt.Errorf("modulo by zero")
+		}
+		if n.op == "/" {
+			return a / b, nil
+		}
+		return a % b, nil
+	}
+	a, b := toFloat(l), toFloat(r)
+	switch n.op {
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	case "/":
+		return a / b, nil
+	}
+	return math.Mod(a, b), nil
+}
+
+type ternaryNode struct {
+	span
+	cond      exprNode
+	then      exprNode
+	otherwise exprNode
+	typ       valueType
+}
+
+func newTernaryNode(cond, then, otherwise exprNode) (exprNode, error) {
+	s := span{cond.position().start, otherwise.position().end}
+	if t := cond.valueType(); t != typeBool {
+		c := cond.position()
+		return nil, expressionErrorf(c.start, c.end, "condition before ? must be true or false, got a %s", t)
+	}
+	a, b := then.valueType(), otherwise.valueType()
+	typ := a
+	if a.isNumber() && b.isNumber() {
+		if a != b {
+			typ = typeFloat
+		}
+	} else if a != b {
+		return nil, expressionErrorf(s.start, s.end, "both sides of : must be the same type, got a %s and a %s", a, b)
+	}
+	return ternaryNode{span: s, cond: cond, then: then, otherwise: otherwise, typ: typ}, nil
+}
+
+func (n ternaryNode) valueType() valueType {
+	return n.typ
+}
+
+// only evaluates the branch that's taken
+func (n ternaryNode) eval(env evalEnv) (interface{}, error) {
+	cond, err := n.cond.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	taken, skipped := n.otherwise, n.then
+	if cond.(bool) {
+		taken, skipped = n.then, n.otherwise
+	}
+	if env.allBranches {
+		if _, err := skipped.eval(env); err != nil {
+			return nil, err
+		}
+	}
+	out, err := taken.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if n.typ == typeFloat {
+		return toFloat(out), nil
+	}
+	return out, nil
+}
+
+type callNode struct {
+	span
+	name string
+	args []exprNode
+	typ  valueType
+}
+
+func newCallNode(name token, args []exprNode, end int) (exprNode, error) {
+	s := span{name.start, end}
+	signature, ok := functionSignatures[name.text]
+	if !ok {
+		return nil, expressionErrorf(name.start, name.end, "unknown function %s", name.text)
+	}
+	if len(args) < len(signature.args)-signature.optional || len(args) > len(signature.args) {
+		want := fmt.Sprintf("%d", len(signature.args))
+		if signature.optional > 0 {
+			want = fmt.Sprintf("%d to %d", len(signature.args)-signature.optional, len(signature.args))
+		}
+		return nil, expressionErrorf(s.start, s.end, "%s needs %s args, got %d", name.text, want, len(args))
+	}
+
+	for i, arg := range args {
+		if err := checkArg(name.text, i, signature, arg); err != nil {
+			return nil, err
+		}
+	}
+	return callNode{span: s, name: name.text, args: args, typ: signature.returns}, nil
+}
+
+func checkArg(function string, i int, signature functionSignature, arg exprNode) error {
+	s := arg.position()
+	t := arg.valueType()
+	literal, isString := arg.(stringNode)
+	wrongType := func(want string) error {
+		return expressionErrorf(s.start, s.end, "%s needs %s as argument %d, got a %s", function, want, i+1, t)
+	}
+
+	switch signature.args[i] {
+	case argNumber:
+		if !t.isNumber() {
+			return wrongType("a number")
+		}
+	case argInt:
+		if t != typeInt {
+			return wrongType("a whole number")
+		}
+	case argNumberLiteral:
+		number, ok := arg.(numberNode)
+		if !ok {
+			return expressionErrorf(s.start, s.end, "%s needs a number written out as argument %d, e.g. 0.05", function, i+1)
+		}
+		if signature.validateNumber != nil {
+			if err := signature.validateNumber(toFloat(number.value)); err != nil {
+				return expressionErrorf(s.start, s.end, "%s", err.Error())
+			}
+		}
+	case argDate:
+		if isString {
+			if _, err := time.Parse(time.DateOnly, literal.value); err != nil {
+				return expressionErrorf(s.start, s.end, "%s is not a date, expected YYYY-MM-DD", literal.value)
+			}
+		} else if t != typeDate {
+			return wrongType("a date")
+		}
+		// addDate only moves a date around, it doesn't read anything
+		if function != "addDate" {
+			if err := checkLookahead(function, arg); err != nil {
+				return err
+			}
+		}
+	case argString:
+		if t != typeString {
+			return wrongType("a string in quotes")
+		}
+		if isString && signature.validateString != nil {
+			if err := signature.validateString(literal.value); err != nil {
+				return expressionErrorf(s.start, s.end, "%s", err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+func (n callNode) valueType() valueType {
+	return n.typ
+}
+
+func (n callNode) eval(env evalEnv) (interface{}, error) {
+	if v, ok := env.crossSectional[n.span]; ok && crossSectionalOperators[n.name] {
+		return v, nil
+	}
+	f, ok := env.functions[n.name]
+	if !ok {
+		return nil, fmt.Errorf("%s cannot be evaluated for a single ticker", n.name)
+	}
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		v, err := arg.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	out, err := f(args...)
+	if err != nil {
+		return nil, err
+	}
+	// functions return float64 or ints interchangeably
+	if n.typ == typeFloat {
+		return toFloat(out), nil
+	}
+	return out, nil
+}
+
+// childNodes returns the nodes directly under n
+func childNodes(n exprNode) []exprNode {
+	switch n := n.(type) {
+	case parenNode:
+		return []exprNode{n.inner}
+	case unaryNode:
+		return []exprNode{n.operand}
+	case binaryNode:
+		return []exprNode{n.left, n.right}
+	case ternaryNode:
+		return []exprNode{n.cond, n.then, n.otherwise}
+	case callNode:
+		return n.args
+	}
+	return nil
+}
+
+func toFloat(v interface{}) float64 {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case float64:
+		return n
+	}
+	return math.NaN()
+}
@@ -0,0 +1,160 @@
+package calculator
+
+import (
+	"context"
+	"errors"
+	"factorbacktest/internal/util"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFactorExpression(t *testing.T) {
+	evaluate := func(t *testing.T, expression string) interface{} {
+		parsed, err := parseExpression(expression, expressionVariables([]string{"x"}))
+		require.NoError(t, err)
+		out, err := parsed.evaluate(evalEnv{
+			variables: map[string]interface{}{
+				"currentDate": "2020-06-01",
+				"x":           2.5,
+			},
+			functions: map[string]expressionFunction{
+				"price": func(args ...interface{}) (interface{}, error) {
+					return 10.0, nil
+				},
+				"nDaysAgo": func(args ...interface{}) (interface{}, error) {
+					return "2020-05-01", nil
+				},
+			},
+		})
+		require.NoError(t, err)
+		return out
+	}
+
+	t.Run("arithmetic", func(t *testing.T) {
+		require.Equal(t, 7, evaluate(t, "1 + 2 * 3"))
+		require.Equal(t, 9, evaluate(t, "(1 + 2) * 3"))
+		require.Equal(t, 0, evaluate(t, "1 / 2"))
+		require.Equal(t, 182, evaluate(t, "365 / 2"))
+		require.Equal(t, 0.5, evaluate(t, "1.0 / 2"))
+		require.Equal(t, -1, evaluate(t, "-(3 % 2)"))
+		require.Equal(t, 1.5e-3, evaluate(t, "1.5e-3"))
+		require.Equal(t, 12.5, evaluate(t, "price(currentDate) + x"))
+		require.Equal(t, -10.0, evaluate(t, "-price(nDaysAgo(7))"))
+	})
+
+	t.Run("comparisons and ternary", func(t *testing.T) {
+		require.Equal(t, true, evaluate(t, "price(currentDate) > 5 && !(x == 3)"))
+		require.Equal(t, true, evaluate(t, "nDaysAgo(7) < currentDate"))
+		require.Equal(t, false, evaluate(t, "2 >= 3 || 1 != 1"))
+		require.Equal(t, 1.0, evaluate(t, "(price(currentDate) > 5) ? 1.0 : 0"))
+		require.Equal(t, 3, evaluate(t, "false ? 1 : true ? 3 : 4"))
+	})
+
+	t.Run("only evaluates what's needed", func(t *testing.T) {
+		for expression, want := range map[string]interface{}{
+			"false && stdev(currentDate, currentDate) > 1": false,
+			"true || stdev(currentDate, currentDate) > 1":  true,
+			"true ? 1 : stdev(currentDate, currentDate)":   1.0,
+		} {
+			parsed, err := parseExpression(expression, expressionVariables(nil))
+			require.NoError(t, err)
+			out, err := parsed.evaluate(evalEnv{
+				functions: map[string]expressionFunction{
+					"stdev": func(args ...interface{}) (interface{}, error) {
+						return nil, errors.New("shouldn't be evaluated")
+					},
+				},
+				variables: map[string]interface{}{"currentDate": "2020-06-01"},
+			})
+			require.NoError(t, err, expression)
+			require.Equal(t, want, out, expression)
+		}
+	})
+
+	t.Run("existing expressions", func(t *testing.T) {
+		for _, expression := range []string{
+			"pricePercentChange(\n  nDaysAgo(7),\n  currentDate\n) ",
+			"-pricePercentChange(nDaysAgo(7), currentDate) * 0.6 + pricePercentChange(nMonthsAgo(3), currentDate) * 0.3 + pricePercentChange(nYearsAgo(1), currentDate) * 0.1",
+			"1/pbRatio(currentDate)",
+			`ttm("Revenue", addDate(currentDate, 0, -3, 0)) / fundamental("TotalAssets", currentDate)`,
+			`zscore(pricePercentChange(nYearsAgo(1), currentDate)) + zscore(-stdev(nYearsAgo(1), currentDate))`,
+			`winsorize(beta("SPY", nYearsAgo(1), currentDate), 0.1)`,
+			`price("2020-01-02")`,
+			"pricePercentChange(nDaysAgo(365/2), currentDate)",
+		} {
+			require.NoError(t, ValidateFactorExpression(expression), expression)
+		}
+		require.NoError(t, ValidateConditionExpression("marketCap(currentDate) > 1e9"))
+	})
+
+	t.Run("errors point at the problem", func(t *testing.T) {
+		for _, tc := range []struct {
+			expression string
+			message    string
+			start, end int
+		}{
+			{"price(currentDate) + foo(currentDate)", "unknown function foo", 21, 24},
+			{"price(currentDate) + currentDat", "unknown variable currentDat", 21, 31},
+			{"price(currentDate, currentDate)", "price needs 1 args, got 2", 0, 31},
+			{"pricePercentChange(nDaysAgo(7), 5)", "pricePercentChange needs a date as argument 2, got a number", 32, 33},
+			{"nDaysAgo(1.5)", "nDaysAgo needs a whole number", 9, 12},
+			{`fundamental("Revenu", currentDate)`, "unknown fundamental Revenu", 12, 20},
+			{`price("2020-13-01")`, "2020-13-01 is not a date", 6, 18},
+			{"winsorize(price(currentDate), 0.05 * 2)", "winsorize needs a number written out", 30, 38},
+			{"price(currentDate) +", "unexpected end of expression", 20, 20},
+			{"(price(currentDate)", "expected ')' but found end of expression", 19, 19},
+			{"price(currentDate) # 2", "unexpected character '#'", 19, 20},
+			{"currentDate + 1", "cannot use + between a date and a number", 12, 13},
+			{`price(currentDate) + "abc`, "string is missing its closing quote", 21, 25},
+			{"price(currentDate) > 1", "factor expression must be a number, got a bool", 0, 22},
+		} {
+			err := ValidateFactorExpression(tc.expression)
+			var expressionErr *ExpressionError
+			require.ErrorAs(t, err, &expressionErr, tc.expression)
+			require.Contains(t, expressionErr.Message, tc.message, tc.expression)
+			require.Equal(t, tc.start, expressionErr.Start, tc.expression)
+			require.Equal(t, tc.end, expressionErr.End, tc.expression)
+		}
+
+		require.ErrorContains(t, ValidateConditionExpression("price(currentDate)"), "condition must be true or false")
+	})
+
+	t.Run("signatures match the functions", func(t *testing.T) {
+		names := []string{}
+		for name := range constructFunctionMap(context.Background(), nil, nil, "AAPL", &DryRunFactorMetricsHandler{}, formulaDebugger{}, util.NewDate(2020, 1, 1)) {
+			names = append(names, name)
+		}
+		for name := range crossSectionalOperators {
+			names = append(names, name)
+		}
+		signatures := []string{}
+		for name := range functionSignatures {
+			signatures = append(signatures, name)
+		}
+		slices.Sort(names)
+		slices.Sort(signatures)
+		require.Equal(t, signatures, names)
+	})
+
+	t.Run("evaluates with the dry run", func(t *testing.T) {
+		parsed, err := parseFactorExpression(`pricePercentChange(nYearsAgo(1), currentDate) / stdev(nYearsAgo(1), currentDate) + rsi(nMonthsAgo(1), currentDate) * 0`)
+		require.NoError(t, err)
+		h := &DryRunFactorMetricsHandler{}
+		result, err := evaluateFactorExpression(context.Background(), nil, nil, parsed, "AAPL", h, util.NewDate(2020, 6, 1), nil)
+		require.NoError(t, err)
+		require.Equal(t, 1.0, result.Value)
+		require.Len(t, h.Prices, 4)
+		require.Len(t, h.Stdevs, 1)
+	})
+
+	t.Run("dry run takes every branch", func(t *testing.T) {
+		parsed, err := parseFactorExpression(`false && price(nYearsAgo(1)) > 0 ? 1 : price(currentDate)`)
+		require.NoError(t, err)
+		h := &DryRunFactorMetricsHandler{}
+		_, err = evaluateFactorExpression(context.Background(), nil, nil, parsed, "AAPL", h, util.NewDate(2020, 6, 1), nil)
+		require.NoError(t, err)
+		require.Len(t, h.Prices, 2)
+	})
+}
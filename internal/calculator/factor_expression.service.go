@@ -17,22 +17,8 @@ import (
 	"time"
 
 	"github.com/go-jet/jet/v2/qrm"
-	"github.com/maja42/goval"
 )
 
-// sharedEvaluator is reused across all evaluateFactorExpression calls.
-//
-// goval v1.3.1 doesn't expose a separate Parse step — Evaluator.Evaluate calls
-// internal.Evaluate which builds a fresh lexer/parser per call, so the
-// expression text is re-parsed every time regardless of what we do at this
-// layer. The library's docs explicitly state Evaluator is stateless and safe
-// for concurrent use, so the only useful thing we can do without adding a
-// dependency or forking is stop allocating one Evaluator per (ticker, date)
-// in the 4000-iteration fanout. The struct is currently zero-sized, so the
-// allocation is cheap, but reusing makes intent explicit and future-proofs
-// us if the upstream lib ever caches AST internals on the Evaluator.
-var sharedEvaluator = goval.NewEvaluator()
-
 type ScoresResultsOnDay struct {
 	SymbolScores map[string]*float64
 	Errors       []error
@@ -43,6 +29,7 @@ type ScoresResultsOnDay struct {
 type FactorExpressionService interface {
 	CalculateFactorScores(ctx context.Context, tradingDays []time.Time, tickers []model.Ticker, factorExpression string) (map[time.Time]*ScoresResultsOnDay, error)
 	CalculateFactorScoresWithCache(ctx context.Context, tradingDays []time.Time, tickers []model.Ticker, factorExpression string) (map[time.Time]*ScoresResultsOnDay, *data.PriceCache, error)
+	CalculateFactorScoresForMembers(ctx context.Context, tradingDays []time.Time, tickersByDay map[time.Time][]model.Ticker, factorExpression string) (map[time.Time]*ScoresResultsOnDay, *data.PriceCache, error)
 	CalculateLatestFactorScores(ctx context.Context, tickers []model.Ticker, factorExpression string) (*ScoresResultsOnDay, error)
 }
 
@@ -74,6 +61,10 @@ type workInput struct {
 	Ticker           model.Ticker
 	Date             time.Time
 	FactorExpression string
+	// FactorExpression, parsed once for every input
+	Parsed *parsedExpression
+	// results of the expression's cross-sectional calls
+	CrossSectional map[span]float64
 }
 
 type workResult struct {
@@ -97,6 +88,32 @@ func (h factorExpressionServiceHandler) CalculateFactorScores(ctx context.Contex
 // returns the *data.PriceCache it built. The cache is reused by the backtest
 // simulate loop to avoid a per-day db round-trip for prices.
 func (h factorExpressionServiceHandler) CalculateFactorScoresWithCache(ctx context.Context, tradingDays []time.Time, tickers []model.Ticker, factorExpression string) (map[time.Time]*ScoresResultsOnDay, *data.PriceCache, error) {
+	tickersByDay := map[time.Time][]model.Ticker{}
+	for _, tradingDay := range tradingDays {
+		tickersByDay[tradingDay] = tickers
+	}
+	return h.CalculateFactorScoresForMembers(ctx, tradingDays, tickersByDay, factorExpression)
+}
+
+// CalculateFactorScoresForMembers scores a different set of tickers on each
+// day, e.g. whoever was in the universe at the time. every trading day gets
+// a result, even if nobody was scored on it
+func (h factorExpressionServiceHandler) CalculateFactorScoresForMembers(ctx context.Context, tradingDays []time.Time, tickersByDay map[time.Time][]model.Ticker, factorExpression string) (map[time.Time]*ScoresResultsOnDay, *data.PriceCache, error) {
+	parsed, err := parseFactorExpression(factorExpression)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid factor expression: %w", err)
+	}
+	return h.calculateScores(ctx, tradingDays, tickersByDay, factorExpression, parsed)
+}
+
+// calculateScores is CalculateFactorScoresForMembers for an expression
+// that's already parsed. factorExpression is what its scores are saved
+// under
+func (h factorExpressionServiceHandler) calculateScores(ctx context.Context, tradingDays []time.Time, tickersByDay map[time.Time][]model.Ticker, factorExpression string, parsed *parsedExpression) (map[time.Time]*ScoresResultsOnDay, *data.PriceCache, error) {
+	if terms := findCrossSectional(parsed); len(terms) > 0 {
+		return h.calculateCrossSectionalScores(ctx, tradingDays, tickersByDay, parsed, terms)
+	}
+
 	log := logger.FromContext(ctx)
 	profile, endProfile := domain.GetProfile(ctx)
 	defer endProfile()
@@ -104,11 +121,12 @@ func (h factorExpressionServiceHandler) CalculateFactorScoresWithCache(ctx conte
 	// convert params to list of inputs
 	inputs := []workInput{}
 	for _, tradingDay := range tradingDays {
-		for _, ticker := range tickers {
+		for _, ticker := range tickersByDay[tradingDay] {
 			inputs = append(inputs, workInput{
 				Ticker:           ticker,
 				Date:             tradingDay,
 				FactorExpression: factorExpression,
+				Parsed:           parsed,
 			})
 		}
 	}
@@ -158,6 +176,65 @@ func (h factorExpressionServiceHandler) CalculateFactorScoresWithCache(ctx conte
 	}
 	endSpan()
 
+	_, endSpan = profile.StartNewSpan("evaluate factor expressions")
+	results := h.evaluateInputs(ctx, cache, inputs)
+	endSpan()
+
+	if err := checkFailureRate(results); err != nil {
+		return nil, nil, err
+	}
+
+	_, endSpan = profile.StartNewSpan("adding factor scores to db")
+	addManyInput := []*model.FactorScore{}
+	for _, res := range results {
+		if _, ok := out[res.Date]; !ok {
+			out[res.Date] = &ScoresResultsOnDay{
+				SymbolScores: map[string]*float64{},
+				Errors:       []error{},
+			}
+		}
+
+		m := &model.FactorScore{
+			TickerID:             res.Ticker.TickerID,
+			FactorExpressionHash: util.HashFactorExpression(factorExpression),
+			Date:                 res.Date,
+		}
+
+		if res.Err != nil && !errors.As(res.Err, &factorMetricsMissingDataError{}) {
+			out[res.Date].Errors = append(out[res.Date].Errors, res.Err)
+			errString := res.Err.Error()
+			m.Error = &errString
+		} else if res.Err == nil {
+			out[res.Date].SymbolScores[res.Ticker.Symbol] = &res.ExpressionResult.Value
+			m.Score = &res.ExpressionResult.Value
+		}
+
+		addManyInput = append(addManyInput, m)
+	}
+
+	// if false {
+	err = h.FactorScoreRepository.AddMany(addManyInput)
+	if err != nil {
+		return nil, nil, err
+	}
+	endSpan()
+	// }
+
+	for _, tradingDay := range tradingDays {
+		if _, ok := out[tradingDay]; !ok {
+			out[tradingDay] = &ScoresResultsOnDay{
+				SymbolScores: map[string]*float64{},
+				Errors:       []error{},
+			}
+		}
+	}
+
+	return out, cache, nil
+}
+
+// evaluateInputs spreads the inputs over a pool of workers. every input
+// gets a result, with or without an error
+func (h factorExpressionServiceHandler) evaluateInputs(ctx context.Context, cache *data.PriceCache, inputs []workInput) []workResult {
 	inputCh := make(chan workInput, len(inputs))
 	resultCh := make(chan workResult, len(inputs))
 	numGoroutines := 10
@@ -168,8 +245,6 @@ func (h factorExpressionServiceHandler) CalculateFactorScoresWithCache(ctx conte
 	}
 	close(inputCh)
 
-	_, endSpan = profile.StartNewSpan("evaluate factor expressions")
-	// newProfile, endNewProfile := span.NewSubProfile()
 	// i want a list of spans - one for each element in this
 	for i := 0; i < numGoroutines; i++ {
 		go func() {
@@ -188,10 +263,11 @@ func (h factorExpressionServiceHandler) CalculateFactorScoresWithCache(ctx conte
 						context.WithValue(ctx, domain.ContextProfileKey, subProfile),
 						h.Db,
 						cache,
-						input.FactorExpression,
+						input.Parsed,
 						input.Ticker.Symbol,
 						h.FactorMetricsHandler,
 						input.Date,
+						input.CrossSectional,
 					)
 					if err != nil {
 						err = fmt.Errorf("failed to compute factor score for %s on %s: %w", input.Ticker.Symbol, input.Date.Format(time.DateOnly), err)
@@ -217,18 +293,15 @@ func (h factorExpressionServiceHandler) CalculateFactorScoresWithCache(ctx conte
 		close(resultCh)
 	}()
 
-	totalMs := 0.0
 	results := []workResult{}
 	for res := range resultCh {
 		results = append(results, res)
-		totalMs += float64(res.elapsedMs)
-		// newProfile.AddSpan(res.span)
 	}
-	// logger.Debug("avg score processing: %f", totalMs/float64(len(results)))
-	// endNewProfile()
-	endSpan()
+	return results
+}
 
-	numErrors = 0
+func checkFailureRate(results []workResult) error {
+	numErrors := 0
 	var lastErr error
 	for _, o := range results {
 		if o.Err != nil {
@@ -237,46 +310,107 @@ func (h factorExpressionServiceHandler) CalculateFactorScoresWithCache(ctx conte
 		}
 	}
 	if numErrors > 0 && numErrors >= int(len(results)/2) {
-		return nil, nil, fmt.Errorf("failed to evaluate expression: over 50%% of score calculations failed. last err: %w", lastErr)
+		return fmt.Errorf("failed to evaluate expression: over 50%% of score calculations failed. last err: %w", lastErr)
 	}
+	return nil
+}
 
-	_, endSpan = profile.StartNewSpan("adding factor scores to db")
-	addManyInput := []*model.FactorScore{}
-	for _, res := range results {
-		if _, ok := out[res.Date]; !ok {
-			out[res.Date] = &ScoresResultsOnDay{
-				SymbolScores: map[string]*float64{},
-				Errors:       []error{},
-			}
-		}
+// calculateCrossSectionalScores scores each term's argument across the
+// members, applies the operators day by day, then evaluates the
+// expression per ticker with each call standing in for its result. a
+// name needs a value from every term to be scored. the combined scores
+// depend on who else was in the universe, so unlike plain expressions
+// they aren't saved as factor scores - the arguments are
+func (h factorExpressionServiceHandler) calculateCrossSectionalScores(ctx context.Context, tradingDays []time.Time, tickersByDay map[time.Time][]model.Ticker, expression *parsedExpression, terms []crossSectionalTerm) (map[time.Time]*ScoresResultsOnDay, *data.PriceCache, error) {
+	profile, endProfile := domain.GetProfile(ctx)
+	defer endProfile()
 
-		m := &model.FactorScore{
-			TickerID:             res.Ticker.TickerID,
-			FactorExpressionHash: util.HashFactorExpression(factorExpression),
-			Date:                 res.Date,
+	out := map[time.Time]*ScoresResultsOnDay{}
+	for _, tradingDay := range tradingDays {
+		out[tradingDay] = &ScoresResultsOnDay{
+			SymbolScores: map[string]*float64{},
+			Errors:       []error{},
 		}
+	}
 
-		if res.Err != nil && !errors.As(res.Err, &factorMetricsMissingDataError{}) {
-			out[res.Date].Errors = append(out[res.Date].Errors, res.Err)
-			errString := res.Err.Error()
-			m.Error = &errString
-		} else if res.Err == nil {
-			out[res.Date].SymbolScores[res.Ticker.Symbol] = &res.ExpressionResult.Value
-			m.Score = &res.ExpressionResult.Value
+	var cache *data.PriceCache
+	valuesByTerm := make([]map[time.Time]map[string]float64, len(terms))
+	for i, term := range terms {
+		span, endSpan := profile.StartNewSpan(fmt.Sprintf("score %s argument", term.Operator))
+		argument := term.Argument.text()
+		scores, termCache, err := h.calculateScores(domain.NewCtxWithSubProfile(ctx, span), tradingDays, tickersByDay, argument, term.Argument)
+		endSpan()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to score %s argument %s: %w", term.Operator, argument, err)
+		}
+		// the backtest reads prices from the returned cache, so it
+		// gets everything any term loaded
+		if cache == nil {
+			cache = termCache
+		} else {
+			cache.Merge(termCache)
 		}
+		valuesByTerm[i] = map[time.Time]map[string]float64{}
+		for day, scoresOnDay := range scores {
+			valuesByTerm[i][day] = applyCrossSectional(term, scoresOnDay.SymbolScores)
+			if _, ok := out[day]; ok {
+				out[day].Errors = append(out[day].Errors, scoresOnDay.Errors...)
+			}
+		}
+	}
 
-		addManyInput = append(addManyInput, m)
+	inputs := []workInput{}
+	for _, tradingDay := range tradingDays {
+	tickers:
+		for _, ticker := range tickersByDay[tradingDay] {
+			crossSectional := map[span]float64{}
+			for i, term := range terms {
+				v, ok := valuesByTerm[i][tradingDay][ticker.Symbol]
+				if !ok {
+					continue tickers
+				}
+				for _, call := range term.calls {
+					crossSectional[call] = v
+				}
+			}
+			inputs = append(inputs, workInput{
+				Ticker:           ticker,
+				Date:             tradingDay,
+				FactorExpression: expression.source,
+				Parsed:           expression,
+				CrossSectional:   crossSectional,
+			})
+		}
+	}
+	if len(inputs) == 0 {
+		return out, cache, nil
 	}
 
-	// if false {
-	err = h.FactorScoreRepository.AddMany(addManyInput)
+	span, endSpan := profile.StartNewSpan("load price cache")
+	outerCache, err := h.loadPriceCache(domain.NewCtxWithSubProfile(ctx, span), inputs)
 	if err != nil {
 		return nil, nil, err
 	}
 	endSpan()
-	// }
+	outerCache.Merge(cache)
 
-	return out, cache, nil
+	_, endSpan = profile.StartNewSpan("evaluate factor expressions")
+	results := h.evaluateInputs(ctx, outerCache, inputs)
+	endSpan()
+
+	if err := checkFailureRate(results); err != nil {
+		return nil, nil, err
+	}
+
+	for _, res := range results {
+		if res.Err != nil && !errors.As(res.Err, &factorMetricsMissingDataError{}) {
+			out[res.Date].Errors = append(out[res.Date].Errors, res.Err)
+		} else if res.Err == nil {
+			out[res.Date].SymbolScores[res.Ticker.Symbol] = &res.ExpressionResult.Value
+		}
+	}
+
+	return out, outerCache, nil
 }
 
 // loadPriceCache "dry-runs" the factor expression to determine which dates are needed
@@ -292,10 +426,11 @@ func (h factorExpressionServiceHandler) loadPriceCache(ctx context.Context, in [
 			ctx,
 			nil,
 			nil,
-			n.FactorExpression,
+			n.Parsed,
 			n.Ticker.Symbol,
 			&dataHandler,
 			n.Date,
+			n.CrossSectional,
 		)
 		if err != nil {
 			return nil, err
@@ -396,8 +531,59 @@ func constructFunctionMap(
 	h factorMetricCalculations,
 	debug formulaDebugger,
 	currentDate time.Time,
-) map[string]goval.ExpressionFunction {
-	return map[string]goval.ExpressionFunction{
+) map[string]expressionFunction {
+	// name(start, end strDate), e.g. sma(nMonthsAgo(1), currentDate)
+	indicator := func(name string, calculate func(series []data.PricePoint) (float64, error)) expressionFunction {
+		return func(args ...interface{}) (interface{}, error) {
+			start, end, err := windowArgs(name, args)
+			if err != nil {
+				return 0, err
+			}
+			series, err := h.PriceSeries(pr, symbol, start, end)
+			if err != nil {
+				return 0, err
+			}
+			v, err := calculate(series)
+			if err != nil {
+				return 0, err
+			}
+			debug.Add(name, v)
+			return v, nil
+		}
+	}
+	// name(benchmark string, start, end strDate), e.g.
+	// beta("SPY", nYearsAgo(1), currentDate)
+	benchmarkIndicator := func(name string, calculate func(series, benchmark []data.PricePoint) (float64, error)) expressionFunction {
+		return func(args ...interface{}) (interface{}, error) {
+			if len(args) < 3 {
+				return 0, fmt.Errorf("%s needs 3 args, got %d", name, len(args))
+			}
+			benchmarkSymbol, ok := args[0].(string)
+			if !ok {
+				return 0, fmt.Errorf("%s needs a benchmark symbol in quotes, e.g. \"SPY\"", name)
+			}
+			start, end, err := windowArgs(name, args[1:])
+			if err != nil {
+				return 0, err
+			}
+			series, err := h.PriceSeries(pr, symbol, start, end)
+			if err != nil {
+				return 0, err
+			}
+			benchmark, err := h.PriceSeries(pr, benchmarkSymbol, start, end)
+			if err != nil {
+				return 0, err
+			}
+			v, err := calculate(series, benchmark)
+			if err != nil {
+				return 0, err
+			}
+			debug.Add(name, v)
+			return v, nil
+		}
+	}
+
+	return map[string]expressionFunction{
 		// we could break this up
 
 		// helper functions
@@ -550,9 +736,95 @@ func constructFunctionMap(
 
 			return h.PeRatio(db, symbol, date)
 		},
+
+		// fundamental(field string, date strDate), e.g.
+		// fundamental("NetIncome", currentDate). reads the latest
+		// quarter that was filed by date
+		"fundamental": func(args ...interface{}) (interface{}, error) {
+			field, date, err := fundamentalArgs("fundamental", args)
+			if err != nil {
+				return 0, err
+			}
+			return h.Fundamental(db, symbol, field, date)
+		},
+		// ttm(field string, date strDate) sums the last four quarters
+		// filed by date
+		"ttm": func(args ...interface{}) (interface{}, error) {
+			field, date, err := fundamentalArgs("ttm", args)
+			if err != nil {
+				return 0, err
+			}
+			return h.TrailingTwelveMonths(db, symbol, field, date)
+		},
+		// yoyGrowth(field string, date strDate) is the latest quarter
+		// filed by date vs the same quarter a year earlier, as a fraction
+		"yoyGrowth": func(args ...interface{}) (interface{}, error) {
+			field, date, err := fundamentalArgs("yoyGrowth", args)
+			if err != nil {
+				return 0, err
+			}
+			return h.YearOverYearGrowth(db, symbol, field, date)
+		},
+
+		// technical indicators, see indicators.go
+		"sma":              indicator("sma", movingAverage),
+		"ema":              indicator("ema", exponentialMovingAverage),
+		"rsi":              indicator("rsi", relativeStrengthIndex),
+		"macd":             indicator("macd", movingAverageConvergenceDivergence),
+		"maxDrawdown":      indicator("maxDrawdown", rollingMaxDrawdown),
+		"distanceFromHigh": indicator("distanceFromHigh", distanceFromHigh),
+		"skew":             indicator("skew", returnsSkewness),
+		"beta":             benchmarkIndicator("beta", betaTo),
+		"correlation":      benchmarkIndicator("correlation", correlationTo),
 	}
 }
 
+// windowArgs reads the start and end of an indicator's window
+func windowArgs(name string, args []interface{}) (time.Time, time.Time, error) {
+	if len(args) < 2 {
+		return time.Time{}, time.Time{}, fmt.Errorf("%s needs a start and end date, got %d args", name, len(args))
+	}
+	dates := []time.Time{}
+	for _, arg := range args[:2] {
+		dateStr, ok := arg.(string)
+		if !ok {
+			return time.Time{}, time.Time{}, fmt.Errorf("%s needs dates, e.g. nYearsAgo(1) and currentDate", name)
+		}
+		date, err := time.Parse(time.DateOnly, dateStr)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		dates = append(dates, date)
+	}
+	start, end := dates[0], dates[1]
+	if !start.Before(end) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%s window must start before it ends, got %s to %s", name, start.Format(time.DateOnly), end.Format(time.DateOnly))
+	}
+	return start, end, nil
+}
+
+func fundamentalArgs(name string, args []interface{}) (string, time.Time, error) {
+	if len(args) < 2 {
+		return "", time.Time{}, fmt.Errorf("%s needs 2 args, got %d", name, len(args))
+	}
+	field, ok := args[0].(string)
+	if !ok {
+		return "", time.Time{}, fmt.Errorf("%s needs a field name in quotes, e.g. \"NetIncome\"", name)
+	}
+	if err := validateFundamentalField(field); err != nil {
+		return "", time.Time{}, err
+	}
+	dateStr, ok := args[1].(string)
+	if !ok {
+		return "", time.Time{}, fmt.Errorf("%s needs a date, e.g. currentDate", name)
+	}
+	date, err := time.Parse(time.DateOnly, dateStr)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return field, date, nil
+}
+
 type expressionResult struct {
 	Value  float64
 	Reason formulaDebugger
@@ -562,28 +834,36 @@ func evaluateFactorExpression(
 	ctx context.Context,
 	db *sql.DB,
 	pr *data.PriceCache,
-	expression string,
+	expression *parsedExpression,
 	symbol string,
 	factorMetricsHandler factorMetricCalculations,
 	date time.Time, // expressions are evaluated on the given date
+	crossSectional map[span]float64, // see findCrossSectional
 ) (*expressionResult, error) {
 	variables := map[string]interface{}{
 		"currentDate": date.Format(time.DateOnly),
 	}
 
 	debug := formulaDebugger{}
-	functions := constructFunctionMap(ctx, db, pr, symbol, factorMetricsHandler, debug, date)
-	result, err := sharedEvaluator.Evaluate(expression, variables, functions)
+	_, dryRun := factorMetricsHandler.(*DryRunFactorMetricsHandler)
+	metrics := pointInTimeMetrics{
+		factorMetricCalculations: factorMetricsHandler,
+		currentDate:              date,
+	}
+	functions := constructFunctionMap(ctx, db, pr, symbol, metrics, debug, date)
+	result, err := expression.evaluate(evalEnv{
+		variables:      variables,
+		functions:      functions,
+		crossSectional: crossSectional,
+		allBranches:    dryRun,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to evaluate factor expression: %w", err)
 	}
 
-	// TODO - if it's a dry-run, we're not computing real results
-	// and should allow any value to be processed here
-	r, ok := result.(float64)
-	if !ok {
-		return nil, fmt.Errorf("failed to convert to float")
-	} else if math.IsNaN(r) {
+	// parsing checked that it's a number
+	r := toFloat(result)
+	if math.IsNaN(r) {
 		return nil, fmt.Errorf("calculated NaN as expression result")
 	} else if math.IsInf(r, 0) {
 		return nil, fmt.Errorf("calculated infinity as expression result")
@@ -619,6 +899,10 @@ type factorMetricCalculations interface {
 	MarketCap(tx qrm.Queryable, symbol string, date time.Time) (float64, error)
 	PeRatio(tx qrm.Queryable, symbol string, date time.Time) (float64, error)
 	PbRatio(tx qrm.Queryable, symbol string, date time.Time) (float64, error)
+	Fundamental(tx qrm.Queryable, symbol, field string, date time.Time) (float64, error)
+	TrailingTwelveMonths(tx qrm.Queryable, symbol, field string, date time.Time) (float64, error)
+	YearOverYearGrowth(tx qrm.Queryable, symbol, field string, date time.Time) (float64, error)
+	PriceSeries(pr *data.PriceCache, symbol string, start, end time.Time) ([]data.PricePoint, error)
 }
 
 type factorMetricsHandler struct {
@@ -682,6 +966,40 @@ func (h *DryRunFactorMetricsHandler) PbRatio(tx qrm.Queryable, symbol string, da
 	return 1, nil
 }
 
+func (h *DryRunFactorMetricsHandler) Fundamental(tx qrm.Queryable, symbol, field string, date time.Time) (float64, error) {
+	return 1, nil
+}
+
+func (h *DryRunFactorMetricsHandler) TrailingTwelveMonths(tx qrm.Queryable, symbol, field string, date time.Time) (float64, error) {
+	return 1, nil
+}
+
+func (h *DryRunFactorMetricsHandler) YearOverYearGrowth(tx qrm.Queryable, symbol, field string, date time.Time) (float64, error) {
+	return 1, nil
+}
+
+// PriceSeries hands back a made up series so the indicators have
+// something to compute from. it has to be long enough for all of them
+func (h *DryRunFactorMetricsHandler) PriceSeries(pr *data.PriceCache, symbol string, start, end time.Time) ([]data.PricePoint, error) {
+	h.Prices = append(h.Prices, data.LoadPriceCacheInput{
+		Date:   start,
+		Symbol: symbol,
+	})
+	h.Prices = append(h.Prices, data.LoadPriceCacheInput{
+		Date:   end,
+		Symbol: symbol,
+	})
+
+	out := []data.PricePoint{}
+	for i := range 30 {
+		out = append(out, data.PricePoint{
+			Date:  start.AddDate(0, 0, i),
+			Price: 1 + 0.1*float64(i%3),
+		})
+	}
+	return out, nil
+}
+
 func (h factorMetricsHandler) Price(pr *data.PriceCache, symbol string, date time.Time) (float64, error) {
 	return pr.Get(symbol, date)
 }
@@ -709,9 +1027,9 @@ func (h factorMetricsHandler) AnnualizedStdevOfDailyReturns(ctx context.Context,
 }
 
 func (h factorMetricsHandler) MarketCap(tx qrm.Queryable, symbol string, date time.Time) (float64, error) {
-	out, err := h.AssetFundamentalsRepository.Get(tx, symbol, date)
+	out, err := h.latestFiling(tx, symbol, date)
 	if err != nil {
-		return 0, factorMetricsMissingDataError{err}
+		return 0, err
 	}
 
 	price, err := h.AdjustedPriceRepository.Get(symbol, date)
@@ -731,9 +1049,9 @@ func (h factorMetricsHandler) PeRatio(tx qrm.Queryable, symbol string, date time
 		return 0, err
 	}
 
-	out, err := h.AssetFundamentalsRepository.Get(tx, symbol, date)
+	out, err := h.latestFiling(tx, symbol, date)
 	if err != nil {
-		return 0, factorMetricsMissingDataError{err}
+		return 0, err
 	}
 	if out.EpsBasic == nil {
 		return 0, factorMetricsMissingDataError{fmt.Errorf("%s does not have eps on %v", symbol, date)}
@@ -749,9 +1067,9 @@ func (h factorMetricsHandler) PbRatio(tx qrm.Queryable, symbol string, date time
 		return 0, err
 	}
 
-	out, err := h.AssetFundamentalsRepository.Get(tx, symbol, date)
+	out, err := h.latestFiling(tx, symbol, date)
 	if err != nil {
-		return 0, factorMetricsMissingDataError{err}
+		return 0, err
 	}
 
 	if out.TotalAssets == nil {
@@ -766,3 +1084,46 @@ func (h factorMetricsHandler) PbRatio(tx qrm.Queryable, symbol string, date time
 
 	return price.InexactFloat64() / ((*out.TotalAssets - *out.TotalLiabilities) / *out.SharesOutstandingBasic), nil
 }
+
+// latestFiling is the most recent quarter that had been filed by date,
+// not the quarter date falls in, which wouldn't be public yet
+func (h factorMetricsHandler) latestFiling(tx qrm.Queryable, symbol string, date time.Time) (*model.AssetFundamental, error) {
+	filings, err := h.AssetFundamentalsRepository.ListAvailable(tx, symbol, date, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(filings) == 0 {
+		return nil, factorMetricsMissingDataError{fmt.Errorf("%s has no filings by %s", symbol, date.Format(time.DateOnly))}
+	}
+	return &filings[0], nil
+}
+
+func (h factorMetricsHandler) Fundamental(tx qrm.Queryable, symbol, field string, date time.Time) (float64, error) {
+	filing, err := h.latestFiling(tx, symbol, date)
+	if err != nil {
+		return 0, err
+	}
+	return fundamentalValue(*filing, field)
+}
+
+func (h factorMetricsHandler) TrailingTwelveMonths(tx qrm.Queryable, symbol, field string, date time.Time) (float64, error) {
+	filings, err := h.AssetFundamentalsRepository.ListAvailable(tx, symbol, date, 4)
+	if err != nil {
+		return 0, err
+	}
+	return trailingTwelveMonths(filings, field)
+}
+
+func (h factorMetricsHandler) YearOverYearGrowth(tx qrm.Queryable, symbol, field string, date time.Time) (float64, error) {
+	// enough to find the same quarter a year back, even if the
+	// company changed its fiscal calendar
+	filings, err := h.AssetFundamentalsRepository.ListAvailable(tx, symbol, date, 5)
+	if err != nil {
+		return 0, err
+	}
+	return yearOverYearGrowth(filings, field)
+}
+
+func (h factorMetricsHandler) PriceSeries(pr *data.PriceCache, symbol string, start, end time.Time) ([]data.PricePoint, error) {
+	return pr.GetSeries(symbol, start, end)
+}
@@ -0,0 +1,108 @@
+package calculator
+
+import (
+	"factorbacktest/internal/db/models/postgres/public/model"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// fundamentalFieldIndex maps lowercased field names, e.g. "netincome",
+// to their index in model.AssetFundamental. only the reported numbers
+// are included, not ids or dates
+var fundamentalFieldIndex = func() map[string]int {
+	out := map[string]int{}
+	t := reflect.TypeOf(model.AssetFundamental{})
+	floatPtr := reflect.TypeOf((*float64)(nil))
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Type == floatPtr {
+			out[strings.ToLower(t.Field(i).Name)] = i
+		}
+	}
+	return out
+}()
+
+// FundamentalFields lists every field fundamental(), ttm() and yoyGrowth()
+// accept, e.g. NetIncome
+func FundamentalFields() []string {
+	t := reflect.TypeOf(model.AssetFundamental{})
+	out := []string{}
+	for _, i := range fundamentalFieldIndex {
+		out = append(out, t.Field(i).Name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func validateFundamentalField(field string) error {
+	if _, ok := fundamentalFieldIndex[strings.ToLower(field)]; !ok {
+		return fmt.Errorf("unknown fundamental %s, expected one of %s", field, strings.Join(FundamentalFields(), ", "))
+	}
+	return nil
+}
+
+// fundamentalValue reads a field from a filing. field names are case
+// insensitive, so netIncome and NetIncome both work
+func fundamentalValue(f model.AssetFundamental, field string) (float64, error) {
+	if err := validateFundamentalField(field); err != nil {
+		return 0, err
+	}
+	v := reflect.ValueOf(f).Field(fundamentalFieldIndex[strings.ToLower(field)])
+	if v.IsNil() {
+		return 0, factorMetricsMissingDataError{fmt.Errorf("%s has no %s for the quarter ending %s", f.Symbol, field, f.EndDate.Format(time.DateOnly))}
+	}
+	return v.Elem().Float(), nil
+}
+
+// trailingTwelveMonths sums the four most recent quarters, which must
+// be back to back. filings must be most recent first
+func trailingTwelveMonths(filings []model.AssetFundamental, field string) (float64, error) {
+	if len(filings) < 4 {
+		return 0, factorMetricsMissingDataError{fmt.Errorf("ttm needs 4 quarters of filings, got %d", len(filings))}
+	}
+	total := 0.0
+	for i, f := range filings[:4] {
+		if i > 0 && !f.EndDate.Equal(filings[i-1].StartDate) {
+			return 0, factorMetricsMissingDataError{fmt.Errorf("%s is missing the filing before the quarter ending %s", f.Symbol, filings[i-1].EndDate.Format(time.DateOnly))}
+		}
+		v, err := fundamentalValue(f, field)
+		if err != nil {
+			return 0, err
+		}
+		total += v
+	}
+	return total, nil
+}
+
+// yearOverYearGrowth compares the most recent quarter to the same
+// quarter a year earlier, as a fraction. filings must be most recent
+// first
+func yearOverYearGrowth(filings []model.AssetFundamental, field string) (float64, error) {
+	if len(filings) == 0 {
+		return 0, factorMetricsMissingDataError{fmt.Errorf("yoyGrowth needs filings, got none")}
+	}
+	latest := filings[0]
+	yearAgoEnd := latest.EndDate.AddDate(-1, 0, 0)
+	for _, f := range filings[1:] {
+		if !f.EndDate.Equal(yearAgoEnd) {
+			continue
+		}
+		current, err := fundamentalValue(latest, field)
+		if err != nil {
+			return 0, err
+		}
+		previous, err := fundamentalValue(f, field)
+		if err != nil {
+			return 0, err
+		}
+		if previous == 0 {
+			return 0, factorMetricsMissingDataError{fmt.Errorf("%s had 0 %s a year earlier", f.Symbol, field)}
+		}
+		// abs so growth from a loss to a smaller loss is positive
+		return (current - previous) / math.Abs(previous), nil
+	}
+	return 0, factorMetricsMissingDataError{fmt.Errorf("%s is missing the filing for the quarter ending %s", latest.Symbol, yearAgoEnd.Format(time.DateOnly))}
+}
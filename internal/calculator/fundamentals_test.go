@@ -0,0 +1,60 @@
+package calculator
+
+import (
+	"factorbacktest/internal/db/models/postgres/public/model"
+	"factorbacktest/internal/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFundamentals(t *testing.T) {
+	quarter := func(year, q int, netIncome float64) model.AssetFundamental {
+		start := util.NewDate(year, 3*(q-1)+1, 1)
+		return model.AssetFundamental{
+			Symbol:    "AAPL",
+			StartDate: start,
+			EndDate:   start.AddDate(0, 3, 0),
+			NetIncome: &netIncome,
+		}
+	}
+	// most recent first
+	filings := []model.AssetFundamental{
+		quarter(2021, 1, 30),
+		quarter(2020, 4, 25),
+		quarter(2020, 3, 20),
+		quarter(2020, 2, 15),
+		quarter(2020, 1, 20),
+	}
+
+	t.Run("field names", func(t *testing.T) {
+		v, err := fundamentalValue(filings[0], "netIncome")
+		require.NoError(t, err)
+		require.Equal(t, 30.0, v)
+
+		_, err = fundamentalValue(filings[0], "Revenue")
+		require.ErrorAs(t, err, &factorMetricsMissingDataError{})
+
+		_, err = fundamentalValue(filings[0], "Symbol")
+		require.ErrorContains(t, err, "unknown fundamental")
+	})
+
+	t.Run("ttm", func(t *testing.T) {
+		v, err := trailingTwelveMonths(filings, "NetIncome")
+		require.NoError(t, err)
+		require.Equal(t, 90.0, v)
+
+		// a missing quarter breaks the trailing year
+		_, err = trailingTwelveMonths(append([]model.AssetFundamental{filings[0]}, filings[2:]...), "NetIncome")
+		require.ErrorContains(t, err, "missing the filing")
+	})
+
+	t.Run("yoy growth", func(t *testing.T) {
+		v, err := yearOverYearGrowth(filings, "NetIncome")
+		require.NoError(t, err)
+		require.InDelta(t, 0.5, v, 1e-9)
+
+		_, err = yearOverYearGrowth(filings[:4], "NetIncome")
+		require.ErrorContains(t, err, "2020-04-01")
+	})
+}
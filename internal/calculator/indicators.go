@@ -0,0 +1,197 @@
+package calculator
+
+import (
+	"factorbacktest/internal/data"
+	"fmt"
+	"math"
+	"time"
+)
+
+// price based indicators for the factor expressions. each one reads a
+// series of prices from the start to the end of its window, oldest first.
+// windows are dates rather than a number of days, like stdev
+
+// TODO - average true range needs high/low prices, we only store closes
+
+func notEnoughPrices(name string, want int, series []data.PricePoint) error {
+	return factorMetricsMissingDataError{fmt.Errorf("%s needs at least %d prices, got %d", name, want, len(series))}
+}
+
+func movingAverage(series []data.PricePoint) (float64, error) {
+	if len(series) == 0 {
+		return 0, notEnoughPrices("sma", 1, series)
+	}
+	total := 0.0
+	for _, p := range series {
+		total += p.Price
+	}
+	return total / float64(len(series)), nil
+}
+
+// emaOf seeds with the first price, so the oldest prices in a short
+// window count for more than they would with a longer history
+func emaOf(series []data.PricePoint, span int) float64 {
+	alpha := 2 / (float64(span) + 1)
+	ema := series[0].Price
+	for _, p := range series[1:] {
+		ema = alpha*p.Price + (1-alpha)*ema
+	}
+	return ema
+}
+
+// exponentialMovingAverage spans the whole window
+func exponentialMovingAverage(series []data.PricePoint) (float64, error) {
+	if len(series) == 0 {
+		return 0, notEnoughPrices("ema", 1, series)
+	}
+	return emaOf(series, len(series)), nil
+}
+
+// relativeStrengthIndex uses the simple average gain and loss over the
+// window, from 0 to 100
+func relativeStrengthIndex(series []data.PricePoint) (float64, error) {
+	if len(series) < 2 {
+		return 0, notEnoughPrices("rsi", 2, series)
+	}
+	gain, loss := 0.0, 0.0
+	for i := 1; i < len(series); i++ {
+		change := series[i].Price - series[i-1].Price
+		if change > 0 {
+			gain += change
+		} else {
+			loss -= change
+		}
+	}
+	if loss == 0 {
+		if gain == 0 {
+			return 50, nil
+		}
+		return 100, nil
+	}
+	return 100 - 100/(1+gain/loss), nil
+}
+
+// movingAverageConvergenceDivergence is the usual 12 day ema minus the 26
+// day ema at the end of the window
+func movingAverageConvergenceDivergence(series []data.PricePoint) (float64, error) {
+	if len(series) < 26 {
+		return 0, notEnoughPrices("macd", 26, series)
+	}
+	return emaOf(series, 12) - emaOf(series, 26), nil
+}
+
+// rollingMaxDrawdown is the largest fall from a peak in the window, as a
+// positive fraction
+func rollingMaxDrawdown(series []data.PricePoint) (float64, error) {
+	if len(series) == 0 {
+		return 0, notEnoughPrices("maxDrawdown", 1, series)
+	}
+	peak, out := series[0].Price, 0.0
+	for _, p := range series {
+		peak = math.Max(peak, p.Price)
+		out = math.Max(out, 1-p.Price/peak)
+	}
+	return out, nil
+}
+
+// distanceFromHigh is how far the last price is below the window's high,
+// as a fraction. 0 means it's at the high
+func distanceFromHigh(series []data.PricePoint) (float64, error) {
+	if len(series) == 0 {
+		return 0, notEnoughPrices("distanceFromHigh", 1, series)
+	}
+	high := 0.0
+	for _, p := range series {
+		high = math.Max(high, p.Price)
+	}
+	return series[len(series)-1].Price/high - 1, nil
+}
+
+func dailyReturns(series []data.PricePoint) []float64 {
+	out := []float64{}
+	for i := 1; i < len(series); i++ {
+		out = append(out, series[i].Price/series[i-1].Price-1)
+	}
+	return out
+}
+
+func meanOf(values []float64) float64 {
+	total := 0.0
+	for _, v := range values {
+		total += v
+	}
+	return total / float64(len(values))
+}
+
+// returnsSkewness is the sample skewness of daily returns
+func returnsSkewness(series []data.PricePoint) (float64, error) {
+	returns := dailyReturns(series)
+	n := float64(len(returns))
+	if len(returns) < 3 {
+		return 0, notEnoughPrices("skew", 4, series)
+	}
+	mean := meanOf(returns)
+	m2, m3 := 0.0, 0.0
+	for _, r := range returns {
+		m2 += math.Pow(r-mean, 2)
+		m3 += math.Pow(r-mean, 3)
+	}
+	stdev := math.Sqrt(m2 / (n - 1))
+	if stdev == 0 {
+		return 0, factorMetricsMissingDataError{fmt.Errorf("skew is undefined when prices don't move")}
+	}
+	return n / ((n - 1) * (n - 2)) * m3 / math.Pow(stdev, 3), nil
+}
+
+// alignedReturns pairs up daily returns on the days both series have
+// prices for
+func alignedReturns(series, benchmark []data.PricePoint) ([]float64, []float64) {
+	benchmarkByDate := map[time.Time]float64{}
+	for _, p := range benchmark {
+		benchmarkByDate[p.Date] = p.Price
+	}
+	assetPrices, benchmarkPrices := []data.PricePoint{}, []data.PricePoint{}
+	for _, p := range series {
+		if b, ok := benchmarkByDate[p.Date]; ok {
+			assetPrices = append(assetPrices, p)
+			benchmarkPrices = append(benchmarkPrices, data.PricePoint{Date: p.Date, Price: b})
+		}
+	}
+	return dailyReturns(assetPrices), dailyReturns(benchmarkPrices)
+}
+
+func covariance(a, b []float64) float64 {
+	meanA, meanB := meanOf(a), meanOf(b)
+	total := 0.0
+	for i := range a {
+		total += (a[i] - meanA) * (b[i] - meanB)
+	}
+	return total / float64(len(a)-1)
+}
+
+// betaTo is the slope of the asset's daily returns against the
+// benchmark's
+func betaTo(series, benchmark []data.PricePoint) (float64, error) {
+	a, b := alignedReturns(series, benchmark)
+	if len(a) < 2 {
+		return 0, factorMetricsMissingDataError{fmt.Errorf("beta needs at least 2 days of returns in common with the benchmark, got %d", len(a))}
+	}
+	variance := covariance(b, b)
+	if variance == 0 {
+		return 0, factorMetricsMissingDataError{fmt.Errorf("beta is undefined when the benchmark doesn't move")}
+	}
+	return covariance(a, b) / variance, nil
+}
+
+// correlationTo is the pearson correlation of daily returns
+func correlationTo(series, benchmark []data.PricePoint) (float64, error) {
+	a, b := alignedReturns(series, benchmark)
+	if len(a) < 2 {
+		return 0, factorMetricsMissingDataError{fmt.Errorf("correlation needs at least 2 days of returns in common with the benchmark, got %d", len(a))}
+	}
+	denominator := math.Sqrt(covariance(a, a) * covariance(b, b))
+	if denominator == 0 {
+		return 0, factorMetricsMissingDataError{fmt.Errorf("correlation is undefined when prices don't move")}
+	}
+	return covariance(a, b) / denominator, nil
+}
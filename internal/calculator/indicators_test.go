@@ -0,0 +1,92 @@
+package calculator
+
+import (
+	"factorbacktest/internal/data"
+	"factorbacktest/internal/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndicators(t *testing.T) {
+	seriesOf := func(prices ...float64) []data.PricePoint {
+		out := []data.PricePoint{}
+		for i, p := range prices {
+			out = append(out, data.PricePoint{
+				Date:  util.NewDate(2020, 1, 1).AddDate(0, 0, i),
+				Price: p,
+			})
+		}
+		return out
+	}
+	series := seriesOf(10, 12, 9, 11, 6, 8)
+
+	t.Run("moving averages", func(t *testing.T) {
+		v, err := movingAverage(series)
+		require.NoError(t, err)
+		require.InDelta(t, 56.0/6, v, 1e-9)
+
+		v, err = exponentialMovingAverage(seriesOf(10, 20))
+		require.NoError(t, err)
+		// alpha is 2/3
+		require.InDelta(t, 10+20.0/3, v, 1e-9)
+
+		_, err = movingAverageConvergenceDivergence(series)
+		require.ErrorAs(t, err, &factorMetricsMissingDataError{})
+	})
+
+	t.Run("rsi", func(t *testing.T) {
+		// gains of 2 + 2 + 2, losses of 3 + 5
+		v, err := relativeStrengthIndex(series)
+		require.NoError(t, err)
+		require.InDelta(t, 100-100/(1+6.0/8), v, 1e-9)
+
+		v, err = relativeStrengthIndex(seriesOf(1, 2, 3))
+		require.NoError(t, err)
+		require.Equal(t, 100.0, v)
+	})
+
+	t.Run("drawdown and distance from high", func(t *testing.T) {
+		v, err := rollingMaxDrawdown(series)
+		require.NoError(t, err)
+		require.InDelta(t, 0.5, v, 1e-9)
+
+		v, err = distanceFromHigh(series)
+		require.NoError(t, err)
+		require.InDelta(t, 8.0/12-1, v, 1e-9)
+	})
+
+	t.Run("skew", func(t *testing.T) {
+		v, err := returnsSkewness(seriesOf(100, 101, 102, 103, 120))
+		require.NoError(t, err)
+		require.Greater(t, v, 0.0)
+
+		_, err = returnsSkewness(seriesOf(1, 1, 1, 1))
+		require.ErrorContains(t, err, "undefined")
+	})
+
+	t.Run("beta and correlation", func(t *testing.T) {
+		benchmark := seriesOf(100, 110, 99, 99, 108.9)
+		// twice the benchmark's moves, with a day the benchmark is missing
+		asset := seriesOf(50, 60, 48, 48, 57.6)
+		asset = append(asset, data.PricePoint{Date: util.NewDate(2021, 1, 1), Price: 1})
+
+		v, err := betaTo(asset, benchmark)
+		require.NoError(t, err)
+		require.InDelta(t, 2.0, v, 1e-9)
+
+		v, err = correlationTo(asset, benchmark)
+		require.NoError(t, err)
+		require.InDelta(t, 1.0, v, 1e-9)
+	})
+
+	t.Run("window", func(t *testing.T) {
+		start, end, err := windowArgs("sma", []interface{}{"2020-01-01", "2020-06-01"})
+		require.NoError(t, err)
+		require.Equal(t, util.NewDate(2020, 1, 1), start)
+		require.Equal(t, util.NewDate(2020, 6, 1), end)
+
+		_, _, err = windowArgs("sma", []interface{}{"2020-06-01", "2020-01-01"})
+		require.ErrorContains(t, err, "must start before")
+	})
+}
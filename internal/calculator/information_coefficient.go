@@ -0,0 +1,193 @@
+package calculator
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/montanaflynn/stats"
+	"github.com/shopspring/decimal"
+)
+
+// trading days of forward returns to measure IC against
+var DefaultICHorizons = []int{1, 5, 21, 63}
+
+type InformationCoefficientInput struct {
+	// sorted. every trading day in the range, so horizons can
+	// be counted in trading days
+	TradingDays []time.Time
+	// sorted subset of TradingDays that were scored
+	ScoringDays []time.Time
+	Horizons    []int
+	ScoresByDay map[time.Time]*ScoresResultsOnDay
+	PricesByDay map[time.Time]map[string]decimal.Decimal
+}
+
+type ICPoint struct {
+	Date      time.Time
+	RankIC    float64
+	PearsonIC float64
+	NumAssets int
+}
+
+type HorizonIC struct {
+	Horizon int
+	Series  []ICPoint
+	// nil when there are too few points to compute
+	MeanRankIC     *float64
+	MeanPearsonIC  *float64
+	RankICTStat    *float64
+	PearsonICTStat *float64
+}
+
+type InformationCoefficientResult struct {
+	// one per horizon, in the same order as the input. the mean
+	// IC across horizons is the decay curve
+	Horizons []HorizonIC
+}
+
+// CalculateInformationCoefficients correlates factor scores on each scoring
+// day with forward returns over each horizon. dates whose horizon runs past
+// the last trading day are dropped, as are dates with fewer than 3 assets
+// that have both a score and prices
+func CalculateInformationCoefficients(in InformationCoefficientInput) (*InformationCoefficientResult, error) {
+	if len(in.Horizons) == 0 {
+		return nil, fmt.Errorf("no horizons given")
+	}
+
+	dayIndex := map[time.Time]int{}
+	for i, t := range in.TradingDays {
+		dayIndex[t] = i
+	}
+
+	out := &InformationCoefficientResult{}
+	for _, h := range in.Horizons {
+		if h <= 0 {
+			return nil, fmt.Errorf("horizon must be positive, got %d", h)
+		}
+
+		series := []ICPoint{}
+		for _, t := range in.ScoringDays {
+			i, ok := dayIndex[t]
+			if !ok {
+				return nil, fmt.Errorf("scoring day %s is not a trading day", t.Format(time.DateOnly))
+			}
+			if i+h >= len(in.TradingDays) {
+				break
+			}
+
+			assets := scoredAssetsForPeriod(in.ScoresByDay[t], in.PricesByDay[t], in.PricesByDay[in.TradingDays[i+h]])
+			point, err := icOnDay(t, assets)
+			if err != nil {
+				return nil, err
+			}
+			if point != nil {
+				series = append(series, *point)
+			}
+		}
+
+		horizonIC := HorizonIC{
+			Horizon: h,
+			Series:  series,
+		}
+		rankICs, pearsonICs := []float64{}, []float64{}
+		for _, p := range series {
+			rankICs = append(rankICs, p.RankIC)
+			pearsonICs = append(pearsonICs, p.PearsonIC)
+		}
+		horizonIC.MeanRankIC, horizonIC.RankICTStat = meanAndTStat(rankICs)
+		horizonIC.MeanPearsonIC, horizonIC.PearsonICTStat = meanAndTStat(pearsonICs)
+
+		out.Horizons = append(out.Horizons, horizonIC)
+	}
+
+	return out, nil
+}
+
+func icOnDay(t time.Time, assets []scoredAsset) (*ICPoint, error) {
+	if len(assets) < 3 {
+		return nil, nil
+	}
+	scores, returns := []float64{}, []float64{}
+	for _, a := range assets {
+		scores = append(scores, a.score)
+		returns = append(returns, a.ret)
+	}
+
+	// correlation is undefined when either side is flat, which
+	// happens with binary factors or halted prices
+	if isConstant(scores) || isConstant(returns) {
+		return nil, nil
+	}
+
+	pearson, err := stats.Correlation(scores, returns)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute pearson ic on %s: %w", t.Format(time.DateOnly), err)
+	}
+	rank, err := stats.Correlation(ranks(scores), ranks(returns))
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute rank ic on %s: %w", t.Format(time.DateOnly), err)
+	}
+
+	return &ICPoint{
+		Date:      t,
+		RankIC:    rank,
+		PearsonIC: pearson,
+		NumAssets: len(assets),
+	}, nil
+}
+
+// ranks returns the 1-based rank of each value, giving ties the
+// average of the ranks they span
+func ranks(values []float64) []float64 {
+	idx := make([]int, len(values))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.Slice(idx, func(a, b int) bool {
+		return values[idx[a]] < values[idx[b]]
+	})
+
+	out := make([]float64, len(values))
+	for i := 0; i < len(idx); {
+		j := i
+		for j+1 < len(idx) && values[idx[j+1]] == values[idx[i]] {
+			j++
+		}
+		avg := float64(i+j)/2 + 1
+		for k := i; k <= j; k++ {
+			out[idx[k]] = avg
+		}
+		i = j + 1
+	}
+	return out
+}
+
+func isConstant(values []float64) bool {
+	for _, v := range values {
+		if v != values[0] {
+			return false
+		}
+	}
+	return true
+}
+
+func meanAndTStat(values []float64) (*float64, *float64) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	mean, err := stats.Mean(values)
+	if err != nil {
+		return nil, nil
+	}
+	if len(values) < 2 {
+		return &mean, nil
+	}
+	stdev, err := stats.StandardDeviationSample(values)
+	if err != nil || stdev == 0 {
+		return &mean, nil
+	}
+	tStat := mean / (stdev / math.Sqrt(float64(len(values))))
+	return &mean, &tStat
+}
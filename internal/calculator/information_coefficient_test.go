@@ -0,0 +1,74 @@
+package calculator
+
+import (
+	"factorbacktest/internal/util"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalculateInformationCoefficients(t *testing.T) {
+	days := []time.Time{
+		util.NewDate(2020, 1, 2),
+		util.NewDate(2020, 1, 3),
+		util.NewDate(2020, 1, 6),
+		util.NewDate(2020, 1, 7),
+	}
+	scores := &ScoresResultsOnDay{SymbolScores: map[string]*float64{
+		"A": floatPtr(1),
+		"B": floatPtr(2),
+		"C": floatPtr(3),
+		"D": floatPtr(4),
+	}}
+	prices := func(a, b, c, d int64) map[string]decimal.Decimal {
+		return map[string]decimal.Decimal{
+			"A": decimal.NewFromInt(a),
+			"B": decimal.NewFromInt(b),
+			"C": decimal.NewFromInt(c),
+			"D": decimal.NewFromInt(d),
+		}
+	}
+
+	result, err := CalculateInformationCoefficients(InformationCoefficientInput{
+		TradingDays: days,
+		ScoringDays: []time.Time{days[0], days[1], days[2]},
+		Horizons:    []int{1, 3},
+		ScoresByDay: map[time.Time]*ScoresResultsOnDay{
+			days[0]: scores,
+			days[1]: scores,
+			days[2]: scores,
+		},
+		PricesByDay: map[time.Time]map[string]decimal.Decimal{
+			days[0]: prices(100, 100, 100, 100),
+			// monotonic in score, but not linear
+			days[1]: prices(100, 101, 102, 110),
+			// perfectly backwards
+			days[2]: prices(110, 102, 101, 100),
+			days[3]: prices(110, 102, 101, 100),
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Horizons, 2)
+
+	oneDay := result.Horizons[0]
+	require.Equal(t, 1, oneDay.Horizon)
+	// the last day has flat returns, so it's dropped
+	require.Len(t, oneDay.Series, 2)
+	require.InDelta(t, 1, oneDay.Series[0].RankIC, 1e-9)
+	require.Less(t, oneDay.Series[0].PearsonIC, 1.0)
+	require.InDelta(t, -1, oneDay.Series[1].RankIC, 1e-9)
+	require.InDelta(t, 0, *oneDay.MeanRankIC, 1e-9)
+	require.InDelta(t, 0, *oneDay.RankICTStat, 1e-9)
+
+	// only the first day has 3 days of forward returns
+	threeDay := result.Horizons[1]
+	require.Len(t, threeDay.Series, 1)
+	require.InDelta(t, -1, threeDay.Series[0].RankIC, 1e-9)
+}
+
+func Test_ranks(t *testing.T) {
+	require.Equal(t, []float64{3, 1, 2}, ranks([]float64{5, 1, 2}))
+	require.Equal(t, []float64{1, 2.5, 2.5, 4}, ranks([]float64{1, 2, 2, 3}))
+}
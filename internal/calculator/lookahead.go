@@ -0,0 +1,297 @@
+package calculator
+
+import (
+	"context"
+	"errors"
+	"factorbacktest/internal/data"
+	"fmt"
+	"time"
+
+	"github.com/go-jet/jet/v2/qrm"
+)
+
+// a backtest can't use anything it wouldn't have known on the day it's
+// scoring. parsing rejects data functions that are handed a date after
+// currentDate, and every read is checked again at runtime for anything
+// parsing can't work out, like dates written out in quotes
+
+// dateStep is one AddDate call on the way from currentDate to a date
+type dateStep struct {
+	years  int
+	months int
+	days   int
+}
+
+// dateOffset is how a date expression gets from currentDate to its
+// value. steps are applied in order, since AddDate doesn't commute
+// around month ends
+type dateOffset []dateStep
+
+// past this many possibilities we can't check them all, so the
+// expression is rejected
+const maxLookaheadCandidates = 64
+
+var errTooManyOffsets = errors.New("too many possible offsets to verify")
+
+// lookaheadSampleDays are the currentDates offsets are tried on. two years
+// covers every month end and a leap day
+var lookaheadSampleDays = func() []time.Time {
+	out := []time.Time{}
+	for d := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC); d.Year() < 2025; d = d.AddDate(0, 0, 1) {
+		out = append(out, d)
+	}
+	return out
+}()
+
+func (o dateOffset) looksAhead() bool {
+	for _, currentDate := range lookaheadSampleDays {
+		d := currentDate
+		for _, step := range o {
+			d = d.AddDate(step.years, step.months, step.days)
+		}
+		if d.After(currentDate) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkLookahead errors if a date handed to a data function could be
+// after currentDate
+func checkLookahead(function string, arg exprNode) error {
+	s := arg.position()
+	offsets, err := possibleOffsets(arg)
+	if err != nil {
+		return expressionErrorf(s.start, s.end, "%s's date has %s that it's not after currentDate", function, err.Error())
+	}
+	for _, offset := range offsets {
+		if offset.looksAhead() {
+			return expressionErrorf(s.start, s.end, "%s would read data from after currentDate, which the backtest couldn't have known", function)
+		}
+	}
+	return nil
+}
+
+// possibleOffsets lists every offset from currentDate a date expression
+// could have. dates that don't come from currentDate, e.g. "2020-01-01",
+// aren't included
+func possibleOffsets(n exprNode) ([]dateOffset, error) {
+	switch n := n.(type) {
+	case variableNode:
+		if n.name == "currentDate" {
+			return []dateOffset{{}}, nil
+		}
+	case parenNode:
+		return possibleOffsets(n.inner)
+	case ternaryNode:
+		then, err := possibleOffsets(n.then)
+		if err != nil {
+			return nil, err
+		}
+		otherwise, err := possibleOffsets(n.otherwise)
+		if err != nil {
+			return nil, err
+		}
+		return capCandidates(append(then, otherwise...))
+	case callNode:
+		switch n.name {
+		case "nDaysAgo", "nMonthsAgo", "nYearsAgo":
+			values, err := possibleInts(n.args[0])
+			if err != nil {
+				return nil, err
+			}
+			out := []dateOffset{}
+			for _, v := range values {
+				step := map[string]dateStep{
+					"nDaysAgo":   {days: -v},
+					"nMonthsAgo": {months: -v},
+					"nYearsAgo":  {years: -v},
+				}[n.name]
+				out = append(out, dateOffset{step})
+			}
+			return out, nil
+		case "addDate":
+			bases, err := possibleOffsets(n.args[0])
+			if err != nil {
+				return nil, err
+			}
+			steps := [3][]int{}
+			for i := range steps {
+				if steps[i], err = possibleInts(n.args[i+1]); err != nil {
+					return nil, err
+				}
+			}
+			out := []dateOffset{}
+			for _, base := range bases {
+				for _, years := range steps[0] {
+					for _, months := range steps[1] {
+						for _, days := range steps[2] {
+							offset := append(append(dateOffset{}, base...), dateStep{years, months, days})
+							out = append(out, offset)
+						}
+					}
+				}
+			}
+			return capCandidates(out)
+		}
+	}
+	return nil, nil
+}
+
+// possibleInts lists every value a whole number expression could have.
+// they're all built from numbers written out, so it's only more than one
+// when there's a ?: involved
+func possibleInts(n exprNode) ([]int, error) {
+	switch n := n.(type) {
+	case numberNode:
+		if v, ok := n.value.(int); ok {
+			return []int{v}, nil
+		}
+	case parenNode:
+		return possibleInts(n.inner)
+	case unaryNode:
+		values, err := possibleInts(n.operand)
+		if err != nil {
+			return nil, err
+		}
+		out := []int{}
+		for _, v := range values {
+			if n.op == "-" {
+				v = -v
+			}
+			out = append(out, v)
+		}
+		return out, nil
+	case ternaryNode:
+		then, err := possibleInts(n.then)
+		if err != nil {
+			return nil, err
+		}
+		otherwise, err := possibleInts(n.otherwise)
+		if err != nil {
+			return nil, err
+		}
+		return capCandidates(append(then, otherwise...))
+	case binaryNode:
+		left, err := possibleInts(n.left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := possibleInts(n.right)
+		if err != nil {
+			return nil, err
+		}
+		out := []int{}
+		for _, a := range left {
+			for _, b := range right {
+				switch n.op {
+				case "+":
+					out = append(out, a+b)
+				case "-":
+					out = append(out, a-b)
+				case "*":
+					out = append(out, a*b)
+				case "%":
+					if b != 0 {
+						out = append(out, a%b)
+					}
+				}
+			}
+		}
+		return capCandidates(out)
+	}
+	return nil, nil
+}
+
+func capCandidates[T any](candidates []T) ([]T, error) {
+	if len(candidates) > maxLookaheadCandidates {
+		return nil, errTooManyOffsets
+	}
+	return candidates, nil
+}
+
+// pointInTimeMetrics is the runtime half of the check. expressions read
+// all their data through it, and it refuses anything after currentDate
+type pointInTimeMetrics struct {
+	factorMetricCalculations
+	currentDate time.Time
+}
+
+func (m pointInTimeMetrics) check(metric string, dates ...time.Time) error {
+	for _, date := range dates {
+		if date.After(m.currentDate) {
+			return fmt.Errorf("cannot read %s from %s, after currentDate %s", metric, date.Format(time.DateOnly), m.currentDate.Format(time.DateOnly))
+		}
+	}
+	return nil
+}
+
+func (m pointInTimeMetrics) Price(pr *data.PriceCache, symbol string, date time.Time) (float64, error) {
+	if err := m.check("price", date); err != nil {
+		return 0, err
+	}
+	return m.factorMetricCalculations.Price(pr, symbol, date)
+}
+
+func (m pointInTimeMetrics) PricePercentChange(pr *data.PriceCache, symbol string, start, end time.Time) (float64, error) {
+	if err := m.check("price", start, end); err != nil {
+		return 0, err
+	}
+	return m.factorMetricCalculations.PricePercentChange(pr, symbol, start, end)
+}
+
+func (m pointInTimeMetrics) AnnualizedStdevOfDailyReturns(ctx context.Context, pr *data.PriceCache, symbol string, start, end time.Time) (float64, error) {
+	if err := m.check("prices", start, end); err != nil {
+		return 0, err
+	}
+	return m.factorMetricCalculations.AnnualizedStdevOfDailyReturns(ctx, pr, symbol, start, end)
+}
+
+func (m pointInTimeMetrics) PriceSeries(pr *data.PriceCache, symbol string, start, end time.Time) ([]data.PricePoint, error) {
+	if err := m.check("prices", start, end); err != nil {
+		return nil, err
+	}
+	return m.factorMetricCalculations.PriceSeries(pr, symbol, start, end)
+}
+
+func (m pointInTimeMetrics) MarketCap(tx qrm.Queryable, symbol string, date time.Time) (float64, error) {
+	if err := m.check("market cap", date); err != nil {
+		return 0, err
+	}
+	return m.factorMetricCalculations.MarketCap(tx, symbol, date)
+}
+
+func (m pointInTimeMetrics) PeRatio(tx qrm.Queryable, symbol string, date time.Time) (float64, error) {
+	if err := m.check("pe ratio", date); err != nil {
+		return 0, err
+	}
+	return m.factorMetricCalculations.PeRatio(tx, symbol, date)
+}
+
+func (m pointInTimeMetrics) PbRatio(tx qrm.Queryable, symbol string, date time.Time) (float64, error) {
+	if err := m.check("pb ratio", date); err != nil {
+		return 0, err
+	}
+	return m.factorMetricCalculations.PbRatio(tx, symbol, date)
+}
+
+func (m pointInTimeMetrics) Fundamental(tx qrm.Queryable, symbol, field string, date time.Time) (float64, error) {
+	if err := m.check(field, date); err != nil {
+		return 0, err
+	}
+	return m.factorMetricCalculations.Fundamental(tx, symbol, field, date)
+}
+
+func (m pointInTimeMetrics) TrailingTwelveMonths(tx qrm.Queryable, symbol, field string, date time.Time) (float64, error) {
+	if err := m.check(field, date); err != nil {
+		return 0, err
+	}
+	return m.factorMetricCalculations.TrailingTwelveMonths(tx, symbol, field, date)
+}
+
+func (m pointInTimeMetrics) YearOverYearGrowth(tx qrm.Queryable, symbol, field string, date time.Time) (float64, error) {
+	if err := m.check(field, date); err != nil {
+		return 0, err
+	}
+	return m.factorMetricCalculations.YearOverYearGrowth(tx, symbol, field, date)
+}
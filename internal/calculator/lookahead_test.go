@@ -0,0 +1,79 @@
+package calculator
+
+import (
+	"context"
+	"factorbacktest/internal/util"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookahead(t *testing.T) {
+	t.Run("rejected when parsing", func(t *testing.T) {
+		for _, tc := range []struct {
+			expression string
+			// what the error should point at
+			arg string
+		}{
+			{"price(addDate(currentDate, 0, 1, 0))", "addDate(currentDate, 0, 1, 0)"},
+			{"price(nDaysAgo(-5))", "nDaysAgo(-5)"},
+			{"pricePercentChange(nYearsAgo(1), nDaysAgo(1 - 3))", "nDaysAgo(1 - 3)"},
+			{"stdev(nYearsAgo(1), addDate(nMonthsAgo(1), 0, 0, 40))", "addDate(nMonthsAgo(1), 0, 0, 40)"},
+			{"price(price(currentDate) > 5 ? currentDate : nMonthsAgo(-1))", "price(currentDate) > 5 ? currentDate : nMonthsAgo(-1)"},
+			{`ttm("Revenue", nYearsAgo(2 * -1))`, "nYearsAgo(2 * -1)"},
+			// a month is usually more than 28 days
+			{"price(addDate(currentDate, 0, 1, -28))", "addDate(currentDate, 0, 1, -28)"},
+		} {
+			err := ValidateFactorExpression(tc.expression)
+			var expressionErr *ExpressionError
+			require.ErrorAs(t, err, &expressionErr, tc.expression)
+			require.Contains(t, expressionErr.Message, "from after currentDate", tc.expression)
+			require.Equal(t, tc.arg, tc.expression[expressionErr.Start:expressionErr.End], tc.expression)
+		}
+	})
+
+	t.Run("past and present are fine", func(t *testing.T) {
+		for _, expression := range []string{
+			"price(currentDate)",
+			"price(nDaysAgo(0))",
+			"pricePercentChange(addDate(nMonthsAgo(1), 0, 0, 7), currentDate)",
+			"price(addDate(currentDate, 1, -12, 0))",
+			"price(addDate(currentDate, 0, 1, -32))",
+			// moving forward is fine as long as nothing is read there
+			"(addDate(currentDate, 0, 1, 0) > currentDate) ? 1 : 0",
+		} {
+			require.NoError(t, ValidateFactorExpression(expression), expression)
+		}
+	})
+
+	t.Run("too many possibilities to check", func(t *testing.T) {
+		// 2^7 possible values
+		choice := "(price(currentDate) > 5 ? 1 : 2)"
+		expression := "price(nDaysAgo(" + strings.Repeat(choice+" * ", 6) + choice + "))"
+		err := ValidateFactorExpression(expression)
+		var expressionErr *ExpressionError
+		require.ErrorAs(t, err, &expressionErr)
+		require.Contains(t, expressionErr.Message, "too many possible offsets to verify")
+	})
+
+	t.Run("dates written out are checked at runtime", func(t *testing.T) {
+		currentDate := util.NewDate(2020, 6, 1)
+		for _, expression := range []string{
+			`price("2020-06-02")`,
+			`stdev("2020-01-01", "2020-07-01")`,
+			`fundamental("NetIncome", "2021-01-01")`,
+			`rsi("2020-01-01", "2020-06-02")`,
+		} {
+			parsed, err := parseFactorExpression(expression)
+			require.NoError(t, err, expression)
+			_, err = evaluateFactorExpression(context.Background(), nil, nil, parsed, "AAPL", &DryRunFactorMetricsHandler{}, currentDate, nil)
+			require.ErrorContains(t, err, "after currentDate 2020-06-01", expression)
+		}
+
+		parsed, err := parseFactorExpression(`price("2020-06-01")`)
+		require.NoError(t, err)
+		_, err = evaluateFactorExpression(context.Background(), nil, nil, parsed, "AAPL", &DryRunFactorMetricsHandler{}, currentDate, nil)
+		require.NoError(t, err)
+	})
+}
@@ -1,107 +1,262 @@
 package calculator
 
 import (
-	"factorbacktest/internal/domain"
 	"fmt"
 	"math"
 	"sort"
 	"time"
 
 	"github.com/montanaflynn/stats"
-	"github.com/shopspring/decimal"
 )
 
+const tradingDaysPerYear = 252
+
 type CalculateMetricsResult struct {
 	AnnualizedStdev  float64
 	AnnualizedReturn float64
-	SharpeRatio      float64
+	// annualized mean excess return over the risk free rate, divided
+	// by the annualized stdev of excess returns
+	SharpeRatio float64
+	// same as sharpe, but only penalizes returns below the risk free rate
+	SortinoRatio *float64
+	// annualized
+	DownsideDeviation float64
+	MaxDrawdown       Drawdown
+	// annualized return over max drawdown. nil when there was no drawdown
+	CalmarRatio *float64
+	Skewness    float64
+	// excess kurtosis, so a normal distribution is 0
+	Kurtosis float64
+
+	BestPeriod             PeriodReturn
+	WorstPeriod            PeriodReturn
+	PercentPositivePeriods float64
+	// one day historical VaR and CVaR at 95%, as positive losses
+	ValueAtRisk95            float64
+	ConditionalValueAtRisk95 float64
 }
 
-// redefined here - should rename and improve
-type BacktestResult struct {
-	Date       time.Time
-	Portfolio  domain.Portfolio
-	TotalValue float64
-	// might be less memory to join these in one map, but
-	// it's also cleaner to have these seperated so i don't
-	// need to define another struct for this, and because
-	// these can be computed seperately without needing
-	// to join them back together
-	AssetWeights                 map[string]float64
-	FactorScores                 map[string]float64
-	PriceChangeTilNextResampling map[string]float64
+type Drawdown struct {
+	// fraction of the peak lost, e.g. 0.2 for a 20% drawdown
+	Depth  float64
+	Peak   time.Time
+	Trough time.Time
+	// nil when the curve never got back to the peak
+	Recovery *time.Time
 }
 
-// calculateMetrics calculates metrics for the given snapshots. it assumes
-// the snapshots sufficiently cover the expected range, which should be
-// like 2 or three years
-func CalculateMetrics(backtestResults []BacktestResult, relevantTradingDays []time.Time, totalPriceMap map[time.Time]map[string]decimal.Decimal) (*CalculateMetricsResult, error) {
-	returns, err := calculateReturns(backtestResults, relevantTradingDays, totalPriceMap)
-	if err != nil {
-		return nil, fmt.Errorf("failed to calculate returns: %w", err)
+type PeriodReturn struct {
+	Date   time.Time
+	Return float64
+}
+
+// CalculateMetrics calculates metrics from the daily value of the
+// portfolio. dates and values must be the same length and sorted. it
+// assumes the curve sufficiently covers the expected range, which
+// should be like 2 or three years.
+//
+// riskFreeRates are annualized rates on each date, e.g. 0.05 for 5%.
+// nil treats the risk free rate as 0
+func CalculateMetrics(dates []time.Time, values []float64, riskFreeRates []float64) (*CalculateMetricsResult, error) {
+	if len(values) < 2 || len(dates) != len(values) {
+		return nil, fmt.Errorf("cannot calculate metrics on < 2 equity curve points")
+	}
+	if riskFreeRates != nil && len(riskFreeRates) != len(values) {
+		return nil, fmt.Errorf("expected %d risk free rates, got %d", len(values), len(riskFreeRates))
 	}
 
-	stdev, err := stats.StandardDeviationSample(returns)
+	returns := []float64{}
+	excessReturns := []float64{}
+	for i := 1; i < len(values); i++ {
+		if values[i-1] == 0 {
+			return nil, fmt.Errorf("portfolio value is 0 on %s", dates[i-1].Format(time.DateOnly))
+		}
+		r := (values[i] - values[i-1]) / values[i-1]
+		returns = append(returns, r)
+
+		rf := 0.0
+		if riskFreeRates != nil {
+			rf = riskFreeRates[i-1] / tradingDaysPerYear
+		}
+		excessReturns = append(excessReturns, r-rf)
+	}
+
+	stdev, err := sampleStdev(returns)
 	if err != nil {
 		return nil, err
 	}
+	annualizedStdev := stdev * math.Sqrt(tradingDaysPerYear)
 
-	annualizedStdev := stdev * math.Sqrt(252)
-
-	startValue := backtestResults[0].TotalValue
-	endValue := backtestResults[len(backtestResults)-1].TotalValue
-	numHours := backtestResults[len(backtestResults)-1].Date.Sub(backtestResults[0].Date).Hours()
+	startValue := values[0]
+	endValue := values[len(values)-1]
+	numHours := dates[len(dates)-1].Sub(dates[0]).Hours()
 	numYears := numHours / (365 * 24)
 	annualizedReturn := math.Pow((endValue/startValue), 1/numYears) - 1
 
-	sharpeRatio := annualizedReturn / stdev
+	meanExcess, err := stats.Mean(excessReturns)
+	if err != nil {
+		return nil, err
+	}
+	excessStdev, err := sampleStdev(excessReturns)
+	if err != nil {
+		return nil, err
+	}
+	sharpeRatio := 0.0
+	if excessStdev != 0 {
+		sharpeRatio = meanExcess / excessStdev * math.Sqrt(tradingDaysPerYear)
+	}
+
+	downsideDeviation := downsideDeviation(excessReturns)
+	var sortinoRatio *float64
+	if downsideDeviation != 0 {
+		s := meanExcess * tradingDaysPerYear / (downsideDeviation * math.Sqrt(tradingDaysPerYear))
+		sortinoRatio = &s
+	}
+
+	maxDrawdown := maxDrawdown(dates, values)
+	var calmarRatio *float64
+	if maxDrawdown.Depth != 0 {
+		c := annualizedReturn / maxDrawdown.Depth
+		calmarRatio = &c
+	}
+
+	best := PeriodReturn{Date: dates[1], Return: returns[0]}
+	worst := best
+	numPositive := 0
+	for i, r := range returns {
+		if r > best.Return {
+			best = PeriodReturn{Date: dates[i+1], Return: r}
+		}
+		if r < worst.Return {
+			worst = PeriodReturn{Date: dates[i+1], Return: r}
+		}
+		if r > 0 {
+			numPositive++
+		}
+	}
+
+	valueAtRisk, conditionalValueAtRisk := historicalVaR(returns, 0.95)
+	skewness, kurtosis := moments(returns)
 
 	return &CalculateMetricsResult{
-		AnnualizedStdev:  annualizedStdev,
-		AnnualizedReturn: annualizedReturn,
-		SharpeRatio:      sharpeRatio,
+		AnnualizedStdev:          annualizedStdev,
+		AnnualizedReturn:         annualizedReturn,
+		SharpeRatio:              sharpeRatio,
+		SortinoRatio:             sortinoRatio,
+		DownsideDeviation:        downsideDeviation * math.Sqrt(tradingDaysPerYear),
+		MaxDrawdown:              maxDrawdown,
+		CalmarRatio:              calmarRatio,
+		Skewness:                 skewness,
+		Kurtosis:                 kurtosis,
+		BestPeriod:               best,
+		WorstPeriod:              worst,
+		PercentPositivePeriods:   float64(numPositive) / float64(len(returns)),
+		ValueAtRisk95:            valueAtRisk,
+		ConditionalValueAtRisk95: conditionalValueAtRisk,
 	}, nil
 }
 
-func calculateReturns(backtestResults []BacktestResult, relevantTradingDays []time.Time, totalPriceMap map[time.Time]map[string]decimal.Decimal) ([]float64, error) {
-	if len(backtestResults) < 2 {
-		return nil, fmt.Errorf("cannot calculate metrics on < 2 backtest results")
+// sampleStdev is 0 for a single return rather than the NaN you get
+// from dividing by n-1, so a two point curve doesn't report a NaN
+// stdev or sharpe
+func sampleStdev(returns []float64) (float64, error) {
+	if len(returns) < 2 {
+		return 0, nil
 	}
-	sort.Slice(backtestResults, func(i, j int) bool {
-		return backtestResults[i].Date.Before(backtestResults[j].Date)
-	})
+	return stats.StandardDeviationSample(returns)
+}
 
-	if !backtestResults[0].Date.Equal(relevantTradingDays[0]) {
-		return nil, fmt.Errorf("assumption violated: first backtest should align with first trading day")
+// downsideDeviation is the root mean square of the negative returns,
+// counting positive returns as 0
+func downsideDeviation(returns []float64) float64 {
+	total := 0.0
+	for _, r := range returns {
+		if r < 0 {
+			total += r * r
+		}
 	}
+	return math.Sqrt(total / float64(len(returns)))
+}
 
-	returns := []float64{}
+func maxDrawdown(dates []time.Time, values []float64) Drawdown {
+	out := Drawdown{Peak: dates[0], Trough: dates[0]}
 
-	currentPortfolio := backtestResults[0].Portfolio
-	// we can't always use TotalValue because we don't have it calculated
-	// for every day
-	lastValue := decimal.NewFromFloat(backtestResults[0].TotalValue)
-
-	nextBacktestIndex := 1
-	for _, t := range relevantTradingDays {
-		if nextBacktestIndex < len(backtestResults) {
-			nextBacktest := backtestResults[nextBacktestIndex]
-			if t.Equal(nextBacktest.Date) || t.After(nextBacktest.Date) {
-				currentPortfolio = nextBacktest.Portfolio
-				nextBacktestIndex++
+	peakIndex := 0
+	for i, v := range values {
+		if v >= values[peakIndex] {
+			peakIndex = i
+			continue
+		}
+		depth := (values[peakIndex] - v) / values[peakIndex]
+		if depth > out.Depth {
+			out = Drawdown{
+				Depth:  depth,
+				Peak:   dates[peakIndex],
+				Trough: dates[i],
 			}
 		}
+	}
 
-		value, err := currentPortfolio.TotalValue(totalPriceMap[t])
-		if err != nil {
-			return nil, fmt.Errorf("failed to calculate portfolio value on %v: %w", t, err)
+	if out.Depth == 0 {
+		return out
+	}
+	peakValue := 0.0
+	for i, d := range dates {
+		if d.Equal(out.Peak) {
+			peakValue = values[i]
 		}
+		if d.After(out.Trough) && values[i] >= peakValue {
+			recovery := d
+			out.Recovery = &recovery
+			break
+		}
+	}
+
+	return out
+}
+
+// historicalVaR returns the loss at the given confidence, and the
+// average loss on days at least that bad
+func historicalVaR(returns []float64, confidence float64) (float64, float64) {
+	sorted := append([]float64{}, returns...)
+	sort.Float64s(sorted)
+
+	// the tail is the worst ceil((1-c)*n) days, and cutoff is the
+	// best of them. 1-0.95 isn't exactly 0.05, so allow some slack
+	// before rounding up
+	cutoff := int(math.Ceil((1-confidence)*float64(len(sorted))-1e-9)) - 1
+	if cutoff < 0 {
+		cutoff = 0
+	}
+	if cutoff >= len(sorted) {
+		cutoff = len(sorted) - 1
+	}
+	valueAtRisk := -sorted[cutoff]
+
+	total := 0.0
+	for _, r := range sorted[:cutoff+1] {
+		total += r
+	}
+	conditionalValueAtRisk := -total / float64(cutoff+1)
 
-		ret := (value.Sub(lastValue)).Div(lastValue).InexactFloat64()
-		lastValue = value
+	return valueAtRisk, conditionalValueAtRisk
+}
 
-		returns = append(returns, ret)
+// moments returns the skewness and excess kurtosis of the returns
+func moments(returns []float64) (float64, float64) {
+	mean, _ := stats.Mean(returns)
+	m2, m3, m4 := 0.0, 0.0, 0.0
+	for _, r := range returns {
+		d := r - mean
+		m2 += d * d
+		m3 += d * d * d
+		m4 += d * d * d * d
+	}
+	n := float64(len(returns))
+	m2, m3, m4 = m2/n, m3/n, m4/n
+	if m2 == 0 {
+		return 0, 0
 	}
 
-	return returns, nil
+	return m3 / math.Pow(m2, 1.5), m4/(m2*m2) - 3
 }
@@ -0,0 +1,86 @@
+package calculator
+
+import (
+	"factorbacktest/internal/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalculateMetrics(t *testing.T) {
+	dates := []time.Time{
+		util.NewDate(2020, 1, 2),
+		util.NewDate(2020, 1, 3),
+		util.NewDate(2020, 1, 6),
+		util.NewDate(2020, 1, 7),
+		util.NewDate(2020, 1, 8),
+		util.NewDate(2020, 1, 9),
+	}
+	values := []float64{100, 110, 99, 88, 99, 121}
+
+	t.Run("drawdown and period stats", func(t *testing.T) {
+		result, err := CalculateMetrics(dates, values, nil)
+		require.NoError(t, err)
+
+		require.InDelta(t, 0.2, result.MaxDrawdown.Depth, 1e-9)
+		require.Equal(t, dates[1], result.MaxDrawdown.Peak)
+		require.Equal(t, dates[3], result.MaxDrawdown.Trough)
+		require.NotNil(t, result.MaxDrawdown.Recovery)
+		require.Equal(t, dates[5], *result.MaxDrawdown.Recovery)
+
+		require.Equal(t, dates[5], result.BestPeriod.Date)
+		require.InDelta(t, 2.0/9, result.BestPeriod.Return, 1e-9)
+		require.Equal(t, dates[3], result.WorstPeriod.Date)
+		require.InDelta(t, -1.0/9, result.WorstPeriod.Return, 1e-9)
+		require.InDelta(t, 0.6, result.PercentPositivePeriods, 1e-9)
+
+		// 5 returns, so the 5th percentile is the worst day
+		require.InDelta(t, 1.0/9, result.ValueAtRisk95, 1e-9)
+		require.InDelta(t, 1.0/9, result.ConditionalValueAtRisk95, 1e-9)
+
+		require.NotNil(t, result.CalmarRatio)
+		require.InDelta(t, result.AnnualizedReturn/0.2, *result.CalmarRatio, 1e-9)
+		require.NotNil(t, result.SortinoRatio)
+	})
+
+	t.Run("risk free rate lowers sharpe", func(t *testing.T) {
+		without, err := CalculateMetrics(dates, values, nil)
+		require.NoError(t, err)
+
+		rates := []float64{0.05, 0.05, 0.05, 0.05, 0.05, 0.05}
+		with, err := CalculateMetrics(dates, values, rates)
+		require.NoError(t, err)
+		require.Less(t, with.SharpeRatio, without.SharpeRatio)
+	})
+
+	t.Run("never recovers", func(t *testing.T) {
+		result, err := CalculateMetrics(dates[:4], values[:4], nil)
+		require.NoError(t, err)
+		require.Nil(t, result.MaxDrawdown.Recovery)
+	})
+
+	t.Run("a single return has no stdev", func(t *testing.T) {
+		result, err := CalculateMetrics(dates[:2], values[:2], nil)
+		require.NoError(t, err)
+		require.Equal(t, 0.0, result.AnnualizedStdev)
+		require.Equal(t, 0.0, result.SharpeRatio)
+	})
+}
+
+func Test_historicalVaR(t *testing.T) {
+	returns := []float64{}
+	for i := 1; i <= 40; i++ {
+		returns = append(returns, -float64(i)/100)
+	}
+
+	// 5% of 40 days is the worst 2
+	valueAtRisk, conditionalValueAtRisk := historicalVaR(returns, 0.95)
+	require.InDelta(t, 0.39, valueAtRisk, 1e-9)
+	require.InDelta(t, 0.395, conditionalValueAtRisk, 1e-9)
+
+	// 5% of 20 days is just the worst one
+	valueAtRisk, conditionalValueAtRisk = historicalVaR(returns[:20], 0.95)
+	require.InDelta(t, 0.2, valueAtRisk, 1e-9)
+	require.InDelta(t, 0.2, conditionalValueAtRisk, 1e-9)
+}
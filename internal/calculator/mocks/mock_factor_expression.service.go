@@ -59,6 +59,22 @@ func (mr *MockFactorExpressionServiceMockRecorder) CalculateFactorScores(ctx, tr
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CalculateFactorScores", reflect.TypeOf((*MockFactorExpressionService)(nil).CalculateFactorScores), ctx, tradingDays, tickers, factorExpression)
 }
 
+// CalculateFactorScoresForMembers mocks base method.
+func (m *MockFactorExpressionService) CalculateFactorScoresForMembers(ctx context.Context, tradingDays []time.Time, tickersByDay map[time.Time][]model.Ticker, factorExpression string) (map[time.Time]*calculator.ScoresResultsOnDay, *data.PriceCache, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CalculateFactorScoresForMembers", ctx, tradingDays, tickersByDay, factorExpression)
+	ret0, _ := ret[0].(map[time.Time]*calculator.ScoresResultsOnDay)
+	ret1, _ := ret[1].(*data.PriceCache)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CalculateFactorScoresForMembers indicates an expected call of CalculateFactorScoresForMembers.
+func (mr *MockFactorExpressionServiceMockRecorder) CalculateFactorScoresForMembers(ctx, tradingDays, tickersByDay, factorExpression any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CalculateFactorScoresForMembers", reflect.TypeOf((*MockFactorExpressionService)(nil).CalculateFactorScoresForMembers), ctx, tradingDays, tickersByDay, factorExpression)
+}
+
 // CalculateFactorScoresWithCache mocks base method.
 func (m *MockFactorExpressionService) CalculateFactorScoresWithCache(ctx context.Context, tradingDays []time.Time, tickers []model.Ticker, factorExpression string) (map[time.Time]*calculator.ScoresResultsOnDay, *data.PriceCache, error) {
 	m.ctrl.T.Helper()
@@ -128,6 +144,21 @@ func (mr *MockfactorMetricCalculationsMockRecorder) AnnualizedStdevOfDailyReturn
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AnnualizedStdevOfDailyReturns", reflect.TypeOf((*MockfactorMetricCalculations)(nil).AnnualizedStdevOfDailyReturns), ctx, pr, symbol, start, end)
 }
 
+// Fundamental mocks base method.
+func (m *MockfactorMetricCalculations) Fundamental(tx qrm.Queryable, symbol, field string, date time.Time) (float64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Fundamental", tx, symbol, field, date)
+	ret0, _ := ret[0].(float64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Fundamental indicates an expected call of Fundamental.
+func (mr *MockfactorMetricCalculationsMockRecorder) Fundamental(tx, symbol, field, date any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fundamental", reflect.TypeOf((*MockfactorMetricCalculations)(nil).Fundamental), tx, symbol, field, date)
+}
+
 // MarketCap mocks base method.
 func (m *MockfactorMetricCalculations) MarketCap(tx qrm.Queryable, symbol string, date time.Time) (float64, error) {
 	m.ctrl.T.Helper()
@@ -202,3 +233,48 @@ func (mr *MockfactorMetricCalculationsMockRecorder) PricePercentChange(pr, symbo
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PricePercentChange", reflect.TypeOf((*MockfactorMetricCalculations)(nil).PricePercentChange), pr, symbol, start, end)
 }
+
+// PriceSeries mocks base method.
+func (m *MockfactorMetricCalculations) PriceSeries(pr *data.PriceCache, symbol string, start, end time.Time) ([]data.PricePoint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PriceSeries", pr, symbol, start, end)
+	ret0, _ := ret[0].([]data.PricePoint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PriceSeries indicates an expected call of PriceSeries.
+func (mr *MockfactorMetricCalculationsMockRecorder) PriceSeries(pr, symbol, start, end any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PriceSeries", reflect.TypeOf((*MockfactorMetricCalculations)(nil).PriceSeries), pr, symbol, start, end)
+}
+
+// TrailingTwelveMonths mocks base method.
+func (m *MockfactorMetricCalculations) TrailingTwelveMonths(tx qrm.Queryable, symbol, field string, date time.Time) (float64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TrailingTwelveMonths", tx, symbol, field, date)
+	ret0, _ := ret[0].(float64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TrailingTwelveMonths indicates an expected call of TrailingTwelveMonths.
+func (mr *MockfactorMetricCalculationsMockRecorder) TrailingTwelveMonths(tx, symbol, field, date any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TrailingTwelveMonths", reflect.TypeOf((*MockfactorMetricCalculations)(nil).TrailingTwelveMonths), tx, symbol, field, date)
+}
+
+// YearOverYearGrowth mocks base method.
+func (m *MockfactorMetricCalculations) YearOverYearGrowth(tx qrm.Queryable, symbol, field string, date time.Time) (float64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "YearOverYearGrowth", tx, symbol, field, date)
+	ret0, _ := ret[0].(float64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// YearOverYearGrowth indicates an expected call of YearOverYearGrowth.
+func (mr *MockfactorMetricCalculationsMockRecorder) YearOverYearGrowth(tx, symbol, field, date any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "YearOverYearGrowth", reflect.TypeOf((*MockfactorMetricCalculations)(nil).YearOverYearGrowth), tx, symbol, field, date)
+}
@@ -0,0 +1,289 @@
+package calculator
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"time"
+
+	"github.com/montanaflynn/stats"
+)
+
+// most CSCV splits we'll use. C(16, 8) = 12870 combinations
+const maxOverfittingSplits = 16
+
+// fewest returns each CSCV split needs to compute a sharpe
+const minReturnsPerSplit = 5
+
+// euler-mascheroni constant, used in the expected max of normals
+const eulerGamma = 0.5772156649015329
+
+// OverfittingResult describes how much of a set of backtests' performance
+// could come from having tried many variants of the same strategy
+type OverfittingResult struct {
+	NumTrials int
+	// index of the trial with the highest sharpe
+	BestTrial int
+	// annualized sharpe we'd expect the best of NumTrials strategies
+	// to hit by luck alone, if none of them had any skill
+	ExpectedMaxSharpe float64
+	// per trial, the probability its true sharpe is above 0 after
+	// accounting for the number of trials, skew and fat tails
+	// (Bailey & López de Prado). same order as the input curves
+	DeflatedSharpeRatios []float64
+	// fraction of CSCV splits where the in-sample winner ranked in the
+	// bottom half out of sample. nil when there aren't enough trials
+	// or returns to split
+	ProbabilityOfBacktestOverfitting *float64
+	NumSplits                        int
+}
+
+// CalculateOverfitting computes the deflated sharpe ratio of each trial and
+// the probability of backtest overfitting across all of them. every curve
+// holds the daily value of one trial on the given dates.
+//
+// riskFreeRates are annualized rates on each date, e.g. 0.05 for 5%.
+// nil treats the risk free rate as 0
+func CalculateOverfitting(dates []time.Time, curves [][]float64, riskFreeRates []float64) (*OverfittingResult, error) {
+	if len(curves) == 0 {
+		return nil, fmt.Errorf("cannot calculate overfitting with no trials")
+	}
+	if len(dates) < 3 {
+		return nil, fmt.Errorf("cannot calculate overfitting on < 3 equity curve points")
+	}
+	if riskFreeRates != nil && len(riskFreeRates) != len(dates) {
+		return nil, fmt.Errorf("expected %d risk free rates, got %d", len(dates), len(riskFreeRates))
+	}
+
+	// excessReturns[trial][period]
+	excessReturns := [][]float64{}
+	for trial, values := range curves {
+		returns, err := trialExcessReturns(trial, dates, values, riskFreeRates)
+		if err != nil {
+			return nil, err
+		}
+		excessReturns = append(excessReturns, returns)
+	}
+
+	// sharpes are per period, not annualized, until reported
+	sharpes := []float64{}
+	for _, returns := range excessReturns {
+		sharpes = append(sharpes, periodSharpe(returns))
+	}
+	bestTrial := 0
+	for i, s := range sharpes {
+		if s > sharpes[bestTrial] {
+			bestTrial = i
+		}
+	}
+
+	expectedMax := expectedMaxSharpe(sharpes)
+	deflated := []float64{}
+	for i, sr := range sharpes {
+		deflated = append(deflated, deflatedSharpeRatio(excessReturns[i], sr, expectedMax))
+	}
+
+	out := &OverfittingResult{
+		NumTrials:            len(curves),
+		BestTrial:            bestTrial,
+		ExpectedMaxSharpe:    expectedMax * math.Sqrt(tradingDaysPerYear),
+		DeflatedSharpeRatios: deflated,
+	}
+
+	numSplits := min(maxOverfittingSplits, len(dates)/minReturnsPerSplit)
+	numSplits -= numSplits % 2
+	if len(curves) >= 2 && numSplits >= 2 {
+		pbo := probabilityOfBacktestOverfitting(excessReturns, numSplits)
+		out.ProbabilityOfBacktestOverfitting = &pbo
+		out.NumSplits = numSplits
+	}
+
+	return out, nil
+}
+
+// CalculateDeflatedSharpeRatio is CalculateOverfitting for a single
+// backtest, when the other trials it was picked from only have an
+// annualized sharpe, e.g. earlier runs of the same strategy. trial 0 is
+// this backtest and the rest follow in order, so DeflatedSharpeRatios
+// only has trial 0. there's no probability of backtest overfitting,
+// since that needs every trial's returns
+func CalculateDeflatedSharpeRatio(dates []time.Time, values []float64, riskFreeRates []float64, otherSharpes []float64) (*OverfittingResult, error) {
+	if len(dates) < 3 {
+		return nil, fmt.Errorf("cannot calculate deflated sharpe ratio on < 3 equity curve points")
+	}
+	if riskFreeRates != nil && len(riskFreeRates) != len(dates) {
+		return nil, fmt.Errorf("expected %d risk free rates, got %d", len(dates), len(riskFreeRates))
+	}
+	returns, err := trialExcessReturns(0, dates, values, riskFreeRates)
+	if err != nil {
+		return nil, err
+	}
+
+	// per period, like CalculateOverfitting
+	sharpes := []float64{periodSharpe(returns)}
+	for _, s := range otherSharpes {
+		sharpes = append(sharpes, s/math.Sqrt(tradingDaysPerYear))
+	}
+	bestTrial := 0
+	for i, s := range sharpes {
+		if s > sharpes[bestTrial] {
+			bestTrial = i
+		}
+	}
+
+	expectedMax := expectedMaxSharpe(sharpes)
+	return &OverfittingResult{
+		NumTrials:            len(sharpes),
+		BestTrial:            bestTrial,
+		ExpectedMaxSharpe:    expectedMax * math.Sqrt(tradingDaysPerYear),
+		DeflatedSharpeRatios: []float64{deflatedSharpeRatio(returns, sharpes[0], expectedMax)},
+	}, nil
+}
+
+// trialExcessReturns turns one trial's values into daily returns over
+// the risk free rate
+func trialExcessReturns(trial int, dates []time.Time, values []float64, riskFreeRates []float64) ([]float64, error) {
+	if len(values) != len(dates) {
+		return nil, fmt.Errorf("trial %d has %d values, expected %d", trial, len(values), len(dates))
+	}
+	returns := []float64{}
+	for i := 1; i < len(values); i++ {
+		if values[i-1] == 0 {
+			return nil, fmt.Errorf("trial %d value is 0 on %s", trial, dates[i-1].Format(time.DateOnly))
+		}
+		rf := 0.0
+		if riskFreeRates != nil {
+			rf = riskFreeRates[i-1] / tradingDaysPerYear
+		}
+		returns = append(returns, (values[i]-values[i-1])/values[i-1]-rf)
+	}
+	return returns, nil
+}
+
+// deflatedSharpeRatio is the probability that the true sharpe behind
+// returns is above expectedMax. both sharpes are per period
+func deflatedSharpeRatio(returns []float64, sr float64, expectedMax float64) float64 {
+	skewness, kurtosis := moments(returns)
+	// the sharpe's standard error widens with negative skew and
+	// fat tails. this wants raw kurtosis, and moments returns
+	// excess, so add the 3 back
+	variance := 1 - skewness*sr + (kurtosis+3-1)/4*sr*sr
+	if variance <= 0 {
+		variance = math.SmallestNonzeroFloat64
+	}
+	z := (sr - expectedMax) * math.Sqrt(float64(len(returns)-1)) / math.Sqrt(variance)
+	return stats.NormCdf(z, 0, 1)
+}
+
+func periodSharpe(returns []float64) float64 {
+	mean, _ := stats.Mean(returns)
+	stdev, _ := stats.StandardDeviationSample(returns)
+	if stdev == 0 {
+		return 0
+	}
+	return mean / stdev
+}
+
+// expectedMaxSharpe estimates the highest sharpe we'd see from this many
+// trials if their true sharpes were all 0, using the spread of the
+// sharpes we actually observed
+func expectedMaxSharpe(sharpes []float64) float64 {
+	if len(sharpes) < 2 {
+		return 0
+	}
+	stdev, _ := stats.StandardDeviationSample(sharpes)
+	n := float64(len(sharpes))
+	return stdev * ((1-eulerGamma)*stats.NormPpf(1-1/n, 0, 1) +
+		eulerGamma*stats.NormPpf(1-1/(n*math.E), 0, 1))
+}
+
+// splitSums holds running sums of one trial's returns in one CSCV split,
+// so sharpes over any set of splits can be built without rescanning
+type splitSums struct {
+	n, sum, sumSquares float64
+}
+
+func (s splitSums) sharpe() float64 {
+	if s.n < 2 {
+		return 0
+	}
+	mean := s.sum / s.n
+	variance := (s.sumSquares - s.n*mean*mean) / (s.n - 1)
+	if variance <= 0 {
+		return 0
+	}
+	return mean / math.Sqrt(variance)
+}
+
+// probabilityOfBacktestOverfitting runs combinatorially symmetric cross
+// validation: the returns are cut into numSplits contiguous blocks, and
+// every way of picking half the blocks as in-sample is tried. each time,
+// the best in-sample trial's out-of-sample rank is recorded, and the
+// result is how often that rank is at or below the median
+func probabilityOfBacktestOverfitting(excessReturns [][]float64, numSplits int) float64 {
+	numTrials := len(excessReturns)
+	numReturns := len(excessReturns[0])
+
+	// sums[trial][split]
+	sums := make([][]splitSums, numTrials)
+	for trial, returns := range excessReturns {
+		sums[trial] = make([]splitSums, numSplits)
+		for split := 0; split < numSplits; split++ {
+			for _, r := range returns[split*numReturns/numSplits : (split+1)*numReturns/numSplits] {
+				sums[trial][split].n++
+				sums[trial][split].sum += r
+				sums[trial][split].sumSquares += r * r
+			}
+		}
+	}
+
+	numCombinations, numOverfit := 0, 0
+	for mask := uint(0); mask < 1<<numSplits; mask++ {
+		if bits.OnesCount(mask) != numSplits/2 {
+			continue
+		}
+
+		inSample := make([]float64, numTrials)
+		outOfSample := make([]float64, numTrials)
+		for trial := range sums {
+			in, out := splitSums{}, splitSums{}
+			for split, s := range sums[trial] {
+				if mask&(1<<split) != 0 {
+					in.n, in.sum, in.sumSquares = in.n+s.n, in.sum+s.sum, in.sumSquares+s.sumSquares
+				} else {
+					out.n, out.sum, out.sumSquares = out.n+s.n, out.sum+s.sum, out.sumSquares+s.sumSquares
+				}
+			}
+			inSample[trial] = in.sharpe()
+			outOfSample[trial] = out.sharpe()
+		}
+
+		best := 0
+		for trial, s := range inSample {
+			if s > inSample[best] {
+				best = trial
+			}
+		}
+		// 1 is the worst out of sample, ties count as half
+		rank := 1.0
+		for trial, s := range outOfSample {
+			if trial == best {
+				continue
+			}
+			if s < outOfSample[best] {
+				rank++
+			} else if s == outOfSample[best] {
+				rank += 0.5
+			}
+		}
+		relativeRank := rank / float64(numTrials+1)
+		logit := math.Log(relativeRank / (1 - relativeRank))
+
+		numCombinations++
+		if logit <= 0 {
+			numOverfit++
+		}
+	}
+
+	return float64(numOverfit) / float64(numCombinations)
+}
@@ -0,0 +1,102 @@
+package calculator
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalculateOverfitting(t *testing.T) {
+	numDays := 500
+	dates := []time.Time{}
+	for i := 0; i < numDays; i++ {
+		dates = append(dates, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, i))
+	}
+	r := rand.New(rand.NewSource(1))
+	// daily returns with the given drift and 1% vol
+	curve := func(drift float64) []float64 {
+		out := []float64{100}
+		for i := 1; i < numDays; i++ {
+			out = append(out, out[i-1]*(1+drift+0.01*r.NormFloat64()))
+		}
+		return out
+	}
+
+	t.Run("single trial", func(t *testing.T) {
+		result, err := CalculateOverfitting(dates, [][]float64{curve(0.002)}, nil)
+		require.NoError(t, err)
+		require.Equal(t, 0.0, result.ExpectedMaxSharpe)
+		require.Greater(t, result.DeflatedSharpeRatios[0], 0.95)
+		require.Nil(t, result.ProbabilityOfBacktestOverfitting)
+	})
+
+	t.Run("noise", func(t *testing.T) {
+		curves := [][]float64{}
+		for i := 0; i < 30; i++ {
+			curves = append(curves, curve(0))
+		}
+		result, err := CalculateOverfitting(dates, curves, nil)
+		require.NoError(t, err)
+		require.Equal(t, 16, result.NumSplits)
+		require.Greater(t, result.ExpectedMaxSharpe, 0.0)
+		// the luckiest of 30 coin flips shouldn't look skilled
+		require.Less(t, result.DeflatedSharpeRatios[result.BestTrial], 0.95)
+		require.NotNil(t, result.ProbabilityOfBacktestOverfitting)
+		require.Greater(t, *result.ProbabilityOfBacktestOverfitting, 0.2)
+	})
+
+	t.Run("one real edge", func(t *testing.T) {
+		curves := [][]float64{}
+		for i := 0; i < 29; i++ {
+			curves = append(curves, curve(0))
+		}
+		curves = append(curves, curve(0.003))
+		result, err := CalculateOverfitting(dates, curves, nil)
+		require.NoError(t, err)
+		require.Equal(t, 29, result.BestTrial)
+		require.Greater(t, result.DeflatedSharpeRatios[29], 0.95)
+		require.Less(t, *result.ProbabilityOfBacktestOverfitting, 0.05)
+	})
+
+	t.Run("mismatched lengths", func(t *testing.T) {
+		_, err := CalculateOverfitting(dates, [][]float64{curve(0)[:10]}, nil)
+		require.Error(t, err)
+	})
+}
+
+func TestCalculateDeflatedSharpeRatio(t *testing.T) {
+	numDays := 500
+	dates := []time.Time{}
+	values := []float64{100}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < numDays; i++ {
+		dates = append(dates, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, i))
+		if i > 0 {
+			values = append(values, values[i-1]*(1+0.001+0.01*r.NormFloat64()))
+		}
+	}
+
+	alone, err := CalculateDeflatedSharpeRatio(dates, values, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, alone.NumTrials)
+	require.Equal(t, 0.0, alone.ExpectedMaxSharpe)
+	require.Nil(t, alone.ProbabilityOfBacktestOverfitting)
+
+	// matches CalculateOverfitting on the same single curve
+	overfitting, err := CalculateOverfitting(dates, [][]float64{values}, nil)
+	require.NoError(t, err)
+	require.InDelta(t, overfitting.DeflatedSharpeRatios[0], alone.DeflatedSharpeRatios[0], 1e-12)
+
+	// the same curve picked out of many tries is less convincing
+	others := []float64{}
+	for i := 0; i < 30; i++ {
+		others = append(others, r.NormFloat64())
+	}
+	picked, err := CalculateDeflatedSharpeRatio(dates, values, nil, others)
+	require.NoError(t, err)
+	require.Equal(t, 31, picked.NumTrials)
+	require.Greater(t, picked.ExpectedMaxSharpe, 0.0)
+	require.Less(t, picked.DeflatedSharpeRatios[0], alone.DeflatedSharpeRatios[0])
+}
@@ -0,0 +1,152 @@
+package calculator
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type QuantileReturnsInput struct {
+	NumQuantiles int
+	// sorted. each date is scored and held until the next one
+	Dates       []time.Time
+	ScoresByDay map[time.Time]*ScoresResultsOnDay
+	PricesByDay map[time.Time]map[string]decimal.Decimal
+}
+
+type QuantilePeriod struct {
+	Start time.Time
+	End   time.Time
+	// equal weighted return of each bucket, lowest scores first
+	BucketReturns []float64
+	BucketSizes   []int
+	// top bucket minus bottom bucket
+	Spread float64
+}
+
+type QuantileReturnsResult struct {
+	Periods []QuantilePeriod
+	// compounded across every period
+	CumulativeBucketReturns []float64
+	CumulativeSpread        float64
+	MeanBucketReturns       []float64
+}
+
+type scoredAsset struct {
+	symbol string
+	score  float64
+	ret    float64
+}
+
+// CalculateQuantileReturns sorts the universe into NumQuantiles buckets
+// by factor score on each date, then tracks each bucket's return until
+// the next date. assets missing a score or a price on either end are left
+// out of that period, and periods with fewer assets than buckets are skipped
+func CalculateQuantileReturns(in QuantileReturnsInput) (*QuantileReturnsResult, error) {
+	if in.NumQuantiles < 2 {
+		return nil, fmt.Errorf("need at least 2 quantiles, got %d", in.NumQuantiles)
+	}
+
+	out := &QuantileReturnsResult{
+		Periods:                 []QuantilePeriod{},
+		CumulativeBucketReturns: make([]float64, in.NumQuantiles),
+		MeanBucketReturns:       make([]float64, in.NumQuantiles),
+	}
+	growth := make([]float64, in.NumQuantiles)
+	for i := range growth {
+		growth[i] = 1
+	}
+	spreadGrowth := 1.0
+
+	for i := 0; i+1 < len(in.Dates); i++ {
+		start, end := in.Dates[i], in.Dates[i+1]
+		assets := scoredAssetsForPeriod(in.ScoresByDay[start], in.PricesByDay[start], in.PricesByDay[end])
+		if len(assets) < in.NumQuantiles {
+			continue
+		}
+
+		buckets := bucketAssets(assets, in.NumQuantiles)
+		period := QuantilePeriod{
+			Start:         start,
+			End:           end,
+			BucketReturns: make([]float64, in.NumQuantiles),
+			BucketSizes:   make([]int, in.NumQuantiles),
+		}
+		for b, bucket := range buckets {
+			total := 0.0
+			for _, a := range bucket {
+				total += a.ret
+			}
+			period.BucketReturns[b] = total / float64(len(bucket))
+			period.BucketSizes[b] = len(bucket)
+			growth[b] *= 1 + period.BucketReturns[b]
+		}
+		period.Spread = period.BucketReturns[in.NumQuantiles-1] - period.BucketReturns[0]
+		spreadGrowth *= 1 + period.Spread
+
+		out.Periods = append(out.Periods, period)
+	}
+
+	if len(out.Periods) == 0 {
+		return nil, fmt.Errorf("no periods had enough scored assets to fill %d quantiles", in.NumQuantiles)
+	}
+
+	for b := range growth {
+		out.CumulativeBucketReturns[b] = growth[b] - 1
+		total := 0.0
+		for _, p := range out.Periods {
+			total += p.BucketReturns[b]
+		}
+		out.MeanBucketReturns[b] = total / float64(len(out.Periods))
+	}
+	out.CumulativeSpread = spreadGrowth - 1
+
+	return out, nil
+}
+
+func scoredAssetsForPeriod(scores *ScoresResultsOnDay, startPrices, endPrices map[string]decimal.Decimal) []scoredAsset {
+	out := []scoredAsset{}
+	if scores == nil {
+		return out
+	}
+	for symbol, score := range scores.SymbolScores {
+		if score == nil || math.IsNaN(*score) || math.IsInf(*score, 0) {
+			continue
+		}
+		startPrice, ok := startPrices[symbol]
+		if !ok || startPrice.IsZero() {
+			continue
+		}
+		endPrice, ok := endPrices[symbol]
+		if !ok {
+			continue
+		}
+		out = append(out, scoredAsset{
+			symbol: symbol,
+			score:  *score,
+			ret:    endPrice.Sub(startPrice).Div(startPrice).InexactFloat64(),
+		})
+	}
+	return out
+}
+
+// bucketAssets splits assets into n buckets of (nearly) equal size by
+// ascending score. ties are broken by symbol so results are stable
+func bucketAssets(assets []scoredAsset, n int) [][]scoredAsset {
+	sort.Slice(assets, func(i, j int) bool {
+		if assets[i].score == assets[j].score {
+			return assets[i].symbol < assets[j].symbol
+		}
+		return assets[i].score < assets[j].score
+	})
+
+	buckets := make([][]scoredAsset, n)
+	for i, a := range assets {
+		b := i * n / len(assets)
+		buckets[b] = append(buckets[b], a)
+	}
+	return buckets
+}
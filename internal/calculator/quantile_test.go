@@ -0,0 +1,88 @@
+package calculator
+
+import (
+	"factorbacktest/internal/util"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+func TestCalculateQuantileReturns(t *testing.T) {
+	d1 := util.NewDate(2020, 1, 2)
+	d2 := util.NewDate(2020, 2, 3)
+	d3 := util.NewDate(2020, 3, 2)
+
+	in := QuantileReturnsInput{
+		NumQuantiles: 2,
+		Dates:        []time.Time{d1, d2, d3},
+		ScoresByDay: map[time.Time]*ScoresResultsOnDay{
+			d1: {SymbolScores: map[string]*float64{
+				"A": floatPtr(1),
+				"B": floatPtr(2),
+				"C": floatPtr(3),
+				"D": floatPtr(4),
+				"E": nil,
+			}},
+			d2: {SymbolScores: map[string]*float64{
+				"A": floatPtr(4),
+				"B": floatPtr(3),
+				"C": floatPtr(2),
+				"D": floatPtr(1),
+			}},
+		},
+		PricesByDay: map[time.Time]map[string]decimal.Decimal{
+			d1: {
+				"A": decimal.NewFromInt(100),
+				"B": decimal.NewFromInt(100),
+				"C": decimal.NewFromInt(100),
+				"D": decimal.NewFromInt(100),
+				"E": decimal.NewFromInt(100),
+			},
+			d2: {
+				"A": decimal.NewFromInt(90),
+				"B": decimal.NewFromInt(100),
+				"C": decimal.NewFromInt(110),
+				"D": decimal.NewFromInt(120),
+				"E": decimal.NewFromInt(500),
+			},
+			d3: {
+				"A": decimal.NewFromInt(99),
+				"B": decimal.NewFromInt(100),
+				"C": decimal.NewFromInt(110),
+				"D": decimal.NewFromInt(120),
+			},
+		},
+	}
+
+	result, err := CalculateQuantileReturns(in)
+	require.NoError(t, err)
+	require.Len(t, result.Periods, 2)
+
+	// A, B in the bottom bucket, C, D in the top
+	first := result.Periods[0]
+	require.Equal(t, []int{2, 2}, first.BucketSizes)
+	require.InDelta(t, -0.05, first.BucketReturns[0], 1e-9)
+	require.InDelta(t, 0.15, first.BucketReturns[1], 1e-9)
+	require.InDelta(t, 0.2, first.Spread, 1e-9)
+
+	// scores flipped, so A, B are now on top
+	second := result.Periods[1]
+	require.InDelta(t, 0, second.BucketReturns[0], 1e-9)
+	require.InDelta(t, 0.05, second.BucketReturns[1], 1e-9)
+
+	require.InDelta(t, 0.95*1-1, result.CumulativeBucketReturns[0], 1e-9)
+	require.InDelta(t, 1.15*1.05-1, result.CumulativeBucketReturns[1], 1e-9)
+	require.InDelta(t, 1.2*1.05-1, result.CumulativeSpread, 1e-9)
+
+	t.Run("too few assets", func(t *testing.T) {
+		in.NumQuantiles = 5
+		_, err := CalculateQuantileReturns(in)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,92 @@
+package calculator
+
+import (
+	"factorbacktest/internal/domain"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/montanaflynn/stats"
+	"github.com/shopspring/decimal"
+)
+
+// TransactionCostModel describes what it costs to execute a trade. Every
+// component is optional, and the zero value is free trading, which is
+// what the backtest assumed before this existed
+type TransactionCostModel struct {
+	// flat $ charged per share traded, e.g. 0.005
+	CommissionPerShare float64
+	// % of notional charged on every trade, in basis points
+	NotionalBps float64
+	// flat $ charged for every order, regardless of size
+	FixedFeePerOrder float64
+	// spread + market impact, modeled as a multiple of the
+	// symbol's daily volatility applied to notional. 0.1 means
+	// we lose a tenth of a daily stdev on every trade
+	SlippageVolatilityMultiplier float64
+}
+
+// UsesVolatility is used to skip loading price history when
+// the model doesn't need it
+func (m TransactionCostModel) UsesVolatility() bool {
+	return m.SlippageVolatilityMultiplier > 0
+}
+
+// TradeCost returns the total cost of executing the given trade. dailyVolatility
+// is the stdev of the symbol's daily returns, as a fraction (not %)
+func (m TransactionCostModel) TradeCost(trade domain.ProposedTrade, dailyVolatility float64) decimal.Decimal {
+	if trade.ExactQuantity.IsZero() {
+		return decimal.Zero
+	}
+	notional := trade.ExpectedAmount()
+	shares := trade.ExactQuantity.Abs()
+
+	cost := shares.Mul(decimal.NewFromFloat(m.CommissionPerShare))
+	cost = cost.Add(notional.Mul(decimal.NewFromFloat(m.NotionalBps / 10000)))
+	cost = cost.Add(decimal.NewFromFloat(m.FixedFeePerOrder))
+	cost = cost.Add(notional.Mul(decimal.NewFromFloat(m.SlippageVolatilityMultiplier * dailyVolatility)))
+
+	return cost
+}
+
+// TotalCost sums the cost of every trade. volatilityBySymbol may be nil
+// or missing symbols, in which case slippage is 0 for those trades
+func (m TransactionCostModel) TotalCost(trades []*domain.ProposedTrade, volatilityBySymbol map[string]float64) decimal.Decimal {
+	total := decimal.Zero
+	for _, t := range trades {
+		total = total.Add(m.TradeCost(*t, volatilityBySymbol[t.Symbol]))
+	}
+	return total
+}
+
+// TrailingDailyVolatility computes the sample stdev of daily returns over
+// the last `window` prices on or before date. prices must be sorted by
+// date ascending. returns 0 if there isn't enough history
+func TrailingDailyVolatility(prices []domain.AssetPrice, date time.Time, window int) float64 {
+	end := sort.Search(len(prices), func(i int) bool {
+		return prices[i].Date.After(date)
+	})
+	start := end - window - 1
+	if start < 0 {
+		start = 0
+	}
+	relevant := prices[start:end]
+	if len(relevant) < 3 {
+		return 0
+	}
+
+	returns := []float64{}
+	for i := 1; i < len(relevant); i++ {
+		prev := relevant[i-1].Price
+		if prev.IsZero() {
+			continue
+		}
+		returns = append(returns, relevant[i].Price.Sub(prev).Div(prev).InexactFloat64())
+	}
+
+	stdev, err := stats.StandardDeviationSample(returns)
+	if err != nil || math.IsNaN(stdev) {
+		return 0
+	}
+	return stdev
+}
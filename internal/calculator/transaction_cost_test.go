@@ -0,0 +1,66 @@
+package calculator
+
+import (
+	"factorbacktest/internal/domain"
+	"factorbacktest/internal/util"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactionCostModel_TradeCost(t *testing.T) {
+	t.Run("zero value is free", func(t *testing.T) {
+		cost := TransactionCostModel{}.TradeCost(domain.ProposedTrade{
+			Symbol:        "AAPL",
+			ExactQuantity: decimal.NewFromInt(10),
+			ExpectedPrice: decimal.NewFromInt(100),
+		}, 0.02)
+		require.True(t, cost.IsZero())
+	})
+
+	t.Run("all components on a sell", func(t *testing.T) {
+		model := TransactionCostModel{
+			CommissionPerShare:           0.01,
+			NotionalBps:                  10,
+			FixedFeePerOrder:             1,
+			SlippageVolatilityMultiplier: 0.5,
+		}
+		cost := model.TradeCost(domain.ProposedTrade{
+			Symbol:        "AAPL",
+			ExactQuantity: decimal.NewFromInt(-10),
+			ExpectedPrice: decimal.NewFromInt(100),
+		}, 0.02)
+
+		// 0.1 commission + 1 bps + 1 fixed + 10 slippage
+		require.Equal(t, 12.1, cost.InexactFloat64())
+	})
+
+	t.Run("no trade, no fee", func(t *testing.T) {
+		cost := TransactionCostModel{FixedFeePerOrder: 1}.TradeCost(domain.ProposedTrade{
+			Symbol:        "AAPL",
+			ExactQuantity: decimal.Zero,
+			ExpectedPrice: decimal.NewFromInt(100),
+		}, 0)
+		require.True(t, cost.IsZero())
+	})
+}
+
+func TestTrailingDailyVolatility(t *testing.T) {
+	prices := []domain.AssetPrice{
+		{Date: util.NewDate(2020, 1, 1), Price: decimal.NewFromInt(100)},
+		{Date: util.NewDate(2020, 1, 2), Price: decimal.NewFromInt(110)},
+		{Date: util.NewDate(2020, 1, 3), Price: decimal.NewFromInt(99)},
+		{Date: util.NewDate(2020, 1, 6), Price: decimal.NewFromInt(1000)},
+	}
+
+	t.Run("ignores prices after date", func(t *testing.T) {
+		// returns of +10% and -10%
+		vol := TrailingDailyVolatility(prices, util.NewDate(2020, 1, 3), 21)
+		require.InDelta(t, 0.1414, vol, 0.0001)
+	})
+
+	t.Run("not enough history", func(t *testing.T) {
+		require.Equal(t, 0.0, TrailingDailyVolatility(prices, util.NewDate(2020, 1, 2), 21))
+	})
+}
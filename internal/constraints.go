@@ -0,0 +1,223 @@
+package internal
+
+import (
+	"encoding/json"
+	"factorbacktest/internal/db/models/postgres/public/model"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// PositionConstraints limits how the target portfolio is built. zero
+// values are unconstrained. weights are fractions of portfolio value,
+// and apply to each side of a long/short book separately. stored on the
+// strategy as json
+type PositionConstraints struct {
+	MaxWeight float64 `json:"maxWeight,omitempty"`
+	MinWeight float64 `json:"minWeight,omitempty"`
+	// names without a known sector aren't limited
+	MaxNamesPerSector int `json:"maxNamesPerSector,omitempty"`
+	// positions that would be smaller than this are dropped,
+	// lowest score first
+	MinPositionDollars float64 `json:"minPositionDollars,omitempty"`
+	// fraction of portfolio value held back as cash
+	CashBuffer float64 `json:"cashBuffer,omitempty"`
+}
+
+// ParsePositionConstraints reads the constraints saved on a strategy,
+// if it has any
+func ParsePositionConstraints(s *string) (*PositionConstraints, error) {
+	if s == nil {
+		return nil, nil
+	}
+	out := PositionConstraints{}
+	if err := json.Unmarshal([]byte(*s), &out); err != nil {
+		return nil, fmt.Errorf("failed to parse position constraints: %w", err)
+	}
+	return &out, nil
+}
+
+// SectorBySymbol maps every ticker with a known sector
+func SectorBySymbol(tickers []model.Ticker) map[string]string {
+	out := map[string]string{}
+	for _, t := range tickers {
+		if t.Sector != nil {
+			out[t.Symbol] = *t.Sector
+		}
+	}
+	return out
+}
+
+func (c PositionConstraints) Validate() error {
+	if c.MaxWeight < 0 || c.MaxWeight > 1 {
+		return fmt.Errorf("max weight must be between 0 and 1, got %f", c.MaxWeight)
+	}
+	if c.MinWeight < 0 || c.MinWeight > 1 {
+		return fmt.Errorf("min weight must be between 0 and 1, got %f", c.MinWeight)
+	}
+	if c.MaxWeight > 0 && c.MinWeight > c.MaxWeight {
+		return fmt.Errorf("min weight %f cannot exceed max weight %f", c.MinWeight, c.MaxWeight)
+	}
+	if c.MaxNamesPerSector < 0 {
+		return fmt.Errorf("max names per sector cannot be negative")
+	}
+	if c.MinPositionDollars < 0 {
+		return fmt.Errorf("min position size cannot be negative")
+	}
+	if c.CashBuffer < 0 || c.CashBuffer >= 1 {
+		return fmt.Errorf("cash buffer must be at least 0 and less than 1, got %f", c.CashBuffer)
+	}
+	return nil
+}
+
+// calculateConstrainedWeights picks and weights numTickers names the usual
+// way, then bounds the weights so they sum to total
+func calculateConstrainedWeights(
+	numTickers int,
+	factorScoresBySymbol map[string]*float64,
+	weighting weightingInput,
+	constraints PositionConstraints,
+	sectorBySymbol map[string]string,
+	total float64,
+) (map[string]float64, error) {
+	scores := factorScoresBySymbol
+	if constraints.MaxNamesPerSector > 0 {
+		scores = capNamesPerSector(scores, constraints.MaxNamesPerSector, sectorBySymbol)
+		// hold fewer names rather than break the sector limit
+		numScored := 0
+		for _, s := range scores {
+			if s != nil {
+				numScored++
+			}
+		}
+		numTickers = min(numTickers, numScored)
+	}
+	if constraints.MinWeight > 0 {
+		numTickers = min(numTickers, int(total/constraints.MinWeight+1e-9))
+	}
+	if numTickers < 1 {
+		return nil, fmt.Errorf("constraints leave no names to hold")
+	}
+
+	weights, err := calculateWeights(numTickers, scores, weighting)
+	if err != nil {
+		return nil, err
+	}
+	for symbol, w := range weights {
+		weights[symbol] = w * total
+	}
+
+	return boundWeights(weights, total, constraints.MinWeight, constraints.MaxWeight)
+}
+
+// capNamesPerSector drops everything past the top maxPerSector
+// scores in each sector
+func capNamesPerSector(factorScoresBySymbol map[string]*float64, maxPerSector int, sectorBySymbol map[string]string) map[string]*float64 {
+	symbols := []string{}
+	for symbol, score := range factorScoresBySymbol {
+		if score != nil {
+			symbols = append(symbols, symbol)
+		}
+	}
+	sort.Slice(symbols, func(i, j int) bool {
+		a, b := *factorScoresBySymbol[symbols[i]], *factorScoresBySymbol[symbols[j]]
+		if a != b {
+			return a > b
+		}
+		return symbols[i] < symbols[j]
+	})
+
+	out := map[string]*float64{}
+	countBySector := map[string]int{}
+	for _, symbol := range symbols {
+		sector, ok := sectorBySymbol[symbol]
+		if ok && sector != "" {
+			if countBySector[sector] >= maxPerSector {
+				continue
+			}
+			countBySector[sector]++
+		}
+		out[symbol] = factorScoresBySymbol[symbol]
+	}
+	return out
+}
+
+// boundWeights clips weights to [minWeight, maxWeight] and hands the
+// difference to the unclipped names, in proportion to their weights,
+// until nothing is out of bounds. a bound of 0 is ignored
+func boundWeights(weights map[string]float64, total, minWeight, maxWeight float64) (map[string]float64, error) {
+	const tolerance = 1e-9
+	if maxWeight > 0 && maxWeight*float64(len(weights)) < total-tolerance {
+		return nil, fmt.Errorf("max weight %f is too low to invest %f across %d names", maxWeight, total, len(weights))
+	}
+	if minWeight*float64(len(weights)) > total+tolerance {
+		return nil, fmt.Errorf("min weight %f is too high to invest %f across %d names", minWeight, total, len(weights))
+	}
+
+	out := map[string]float64{}
+	for symbol, w := range weights {
+		out[symbol] = w
+	}
+	clipped := map[string]bool{}
+	// every pass clips at least one more name, so this ends
+	for range len(weights) + 1 {
+		clippedSum, freeSum, numFree := 0.0, 0.0, 0
+		for symbol, w := range out {
+			if clipped[symbol] {
+				clippedSum += w
+			} else {
+				freeSum += w
+				numFree++
+			}
+		}
+		remaining := total - clippedSum
+		if numFree == 0 {
+			if math.Abs(remaining) > tolerance {
+				return nil, fmt.Errorf("weights cannot sum to %f within bounds [%f, %f]", total, minWeight, maxWeight)
+			}
+			return out, nil
+		}
+		for symbol, w := range out {
+			if clipped[symbol] {
+				continue
+			}
+			if freeSum > 0 {
+				out[symbol] = w * remaining / freeSum
+			} else {
+				out[symbol] = remaining / float64(numFree)
+			}
+		}
+
+		// clip the top first, since giving away the excess can only
+		// push the rest up
+		over := []string{}
+		for symbol, w := range out {
+			if !clipped[symbol] && maxWeight > 0 && w > maxWeight+tolerance {
+				over = append(over, symbol)
+			}
+		}
+		if len(over) > 0 {
+			for _, symbol := range over {
+				out[symbol] = maxWeight
+				clipped[symbol] = true
+			}
+			continue
+		}
+
+		under := []string{}
+		for symbol, w := range out {
+			if !clipped[symbol] && w < minWeight-tolerance {
+				under = append(under, symbol)
+			}
+		}
+		if len(under) == 0 {
+			return out, nil
+		}
+		for _, symbol := range under {
+			out[symbol] = minWeight
+			clipped[symbol] = true
+		}
+	}
+
+	return nil, fmt.Errorf("failed to bound weights")
+}
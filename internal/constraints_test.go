@@ -0,0 +1,81 @@
+package internal
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPositionConstraints(t *testing.T) {
+	scores := map[string]*float64{}
+	for symbol, score := range map[string]float64{
+		"A": 10, "B": 4, "C": 3, "D": 2, "E": 1, "F": 0,
+	} {
+		s := score
+		scores[symbol] = &s
+	}
+	sum := func(weights map[string]float64) float64 {
+		total := 0.0
+		for _, w := range weights {
+			total += w
+		}
+		return total
+	}
+
+	t.Run("max weight redistributes", func(t *testing.T) {
+		weights, err := CalculateTargetAssetWeights(CalculateTargetAssetWeightsInput{
+			FactorScoresBySymbol: scores,
+			NumTickers:           4,
+			Constraints:          &PositionConstraints{MaxWeight: 0.3},
+		})
+		require.NoError(t, err)
+		require.Len(t, weights, 4)
+		for _, w := range weights {
+			require.LessOrEqual(t, w, 0.3+1e-9)
+		}
+		require.InDelta(t, 0.3, weights["A"], 1e-9)
+		require.InDelta(t, 1, sum(weights), 1e-9)
+	})
+
+	t.Run("min weight drops names", func(t *testing.T) {
+		weights, err := CalculateTargetAssetWeights(CalculateTargetAssetWeightsInput{
+			FactorScoresBySymbol: scores,
+			NumTickers:           5,
+			Constraints:          &PositionConstraints{MinWeight: 0.3},
+		})
+		require.NoError(t, err)
+		// only 3 names fit at 30% each
+		require.Len(t, weights, 3)
+		for _, w := range weights {
+			require.GreaterOrEqual(t, w, 0.3-1e-9)
+		}
+		require.InDelta(t, 1, sum(weights), 1e-9)
+	})
+
+	t.Run("sector limit and cash buffer", func(t *testing.T) {
+		weights, err := CalculateTargetAssetWeights(CalculateTargetAssetWeightsInput{
+			FactorScoresBySymbol: scores,
+			NumTickers:           3,
+			Constraints: &PositionConstraints{
+				MaxNamesPerSector: 1,
+				CashBuffer:        0.05,
+			},
+			SectorBySymbol: map[string]string{"A": "tech", "B": "tech", "C": "energy", "D": "energy"},
+		})
+		require.NoError(t, err)
+		// E has no sector, so it isn't limited
+		require.ElementsMatch(t, []string{"A", "C", "E"}, slices.Collect(maps.Keys(weights)))
+		require.InDelta(t, 0.95, sum(weights), 1e-9)
+	})
+
+	t.Run("infeasible max weight", func(t *testing.T) {
+		_, err := CalculateTargetAssetWeights(CalculateTargetAssetWeightsInput{
+			FactorScoresBySymbol: scores,
+			NumTickers:           3,
+			Constraints:          &PositionConstraints{MaxWeight: 0.2},
+		})
+		require.ErrorContains(t, err, "too low")
+	})
+}
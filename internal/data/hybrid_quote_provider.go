@@ -151,8 +151,9 @@ func (p *HybridQuoteProvider) GetDailyAdjCloses(ctx context.Context, symbol stri
 	for iter.Next() {
 		bar := iter.Bar()
 		out = append(out, DailyPricePoint{
-			Date:  time.Unix(int64(bar.Timestamp), 0).UTC(),
-			Price: bar.AdjClose,
+			Date:     time.Unix(int64(bar.Timestamp), 0).UTC(),
+			Price:    bar.AdjClose,
+			RawPrice: bar.Close,
 		})
 	}
 	if err := iter.Err(); err != nil {
@@ -160,3 +161,9 @@ func (p *HybridQuoteProvider) GetDailyAdjCloses(ctx context.Context, symbol stri
 	}
 	return out, nil
 }
+
+// GetCorporateActions only uses Yahoo - alpaca doesn't give us
+// dividend history on our plan
+func (p *HybridQuoteProvider) GetCorporateActions(ctx context.Context, symbol string, start, end time.Time) ([]CorporateActionPoint, error) {
+	return getYahooCorporateActions(ctx, symbol, start, end)
+}
@@ -9,6 +9,7 @@ import (
 	"factorbacktest/internal/repository"
 	"fmt"
 	"math"
+	"sort"
 	"sync"
 	"time"
 
@@ -50,10 +51,11 @@ type LoadStdevCacheInput struct {
 }
 
 type priceServiceHandler struct {
-	AdjPriceRepository repository.AdjustedPriceRepository
-	Db                 *sql.DB
-	AlpacaRepository   repository.AlpacaRepository
-	QuoteProvider      QuoteProvider
+	AdjPriceRepository        repository.AdjustedPriceRepository
+	Db                        *sql.DB
+	AlpacaRepository          repository.AlpacaRepository
+	QuoteProvider             QuoteProvider
+	CorporateActionRepository repository.CorporateActionRepository
 }
 
 type stdevCache struct {
@@ -238,17 +240,94 @@ func (pr *PriceCache) GetStdev(ctx context.Context, symbol string, start, end ti
 	return 0, fmt.Errorf("stdev cache miss %s %s to %s", symbol, start.Format(time.DateOnly), end.Format(time.DateOnly))
 }
 
+// Merge copies everything other has preloaded into pr, for when a
+// calculation loads more than one cache and its caller wants a single
+// one. where both have a value, pr's is kept
+func (pr *PriceCache) Merge(other *PriceCache) {
+	if other == nil {
+		return
+	}
+	for symbol, prices := range other.prices {
+		if _, ok := pr.prices[symbol]; !ok {
+			pr.prices[symbol] = map[string]float64{}
+		}
+		for date, price := range prices {
+			if _, ok := pr.prices[symbol][date]; !ok {
+				pr.prices[symbol][date] = price
+			}
+		}
+	}
+	for symbol, byStart := range other.stdevs.cache {
+		for start, byEnd := range byStart {
+			for end, stdev := range byEnd {
+				if _, ok := pr.stdevs.get(symbol, start, end); ok {
+					continue
+				}
+				if _, ok := pr.stdevs.cache[symbol]; !ok {
+					pr.stdevs.cache[symbol] = map[time.Time]map[time.Time]float64{}
+				}
+				if _, ok := pr.stdevs.cache[symbol][start]; !ok {
+					pr.stdevs.cache[symbol][start] = map[time.Time]float64{}
+				}
+				pr.stdevs.cache[symbol][start][end] = stdev
+			}
+		}
+	}
+
+	days := map[time.Time]bool{}
+	for _, t := range pr.tradingDays {
+		days[t] = true
+	}
+	for _, t := range other.tradingDays {
+		if !days[t] {
+			pr.tradingDays = append(pr.tradingDays, t)
+		}
+	}
+	sort.Slice(pr.tradingDays, func(i, j int) bool {
+		return pr.tradingDays[i].Before(pr.tradingDays[j])
+	})
+}
+
+type PricePoint struct {
+	Date  time.Time
+	Price float64
+}
+
+// GetSeries returns the symbol's price on each trading day from start to
+// end, oldest first. days without a price are skipped. like the stdev
+// cache, it's a miss if the prices don't reach within a week of both ends
+func (pr *PriceCache) GetSeries(symbol string, start, end time.Time) ([]PricePoint, error) {
+	out := []PricePoint{}
+	for _, t := range pr.tradingDays {
+		if t.Before(start) || t.After(end) {
+			continue
+		}
+		if price, ok := pr.prices[symbol][t.Format(time.DateOnly)]; ok {
+			out = append(out, PricePoint{
+				Date:  t,
+				Price: price,
+			})
+		}
+	}
+	if len(out) == 0 || out[0].Date.After(start.AddDate(0, 0, 7)) || out[len(out)-1].Date.Before(end.AddDate(0, 0, -7)) {
+		return nil, fmt.Errorf("price series cache miss %s %s to %s", symbol, start.Format(time.DateOnly), end.Format(time.DateOnly))
+	}
+	return out, nil
+}
+
 func NewPriceService(
 	db *sql.DB,
 	adjPriceRepository repository.AdjustedPriceRepository,
 	alpacaRepository repository.AlpacaRepository,
 	quoteProvider QuoteProvider,
+	corporateActionRepository repository.CorporateActionRepository,
 ) PriceService {
 	return &priceServiceHandler{
-		AdjPriceRepository: adjPriceRepository,
-		Db:                 db,
-		AlpacaRepository:   alpacaRepository,
-		QuoteProvider:      quoteProvider,
+		AdjPriceRepository:        adjPriceRepository,
+		Db:                        db,
+		AlpacaRepository:          alpacaRepository,
+		QuoteProvider:             quoteProvider,
+		CorporateActionRepository: corporateActionRepository,
 	}
 }
 
@@ -476,10 +555,16 @@ func (h priceServiceHandler) IngestPrices(
 	models := []model.AdjustedPrice{}
 	createdAt := time.Now().UTC()
 	for _, pt := range points {
+		var rawPrice *decimal.Decimal
+		if !pt.RawPrice.IsZero() {
+			rp := pt.RawPrice
+			rawPrice = &rp
+		}
 		models = append(models, model.AdjustedPrice{
 			Symbol:    symbol,
 			Date:      pt.Date,
 			Price:     pt.Price,
+			RawPrice:  rawPrice,
 			CreatedAt: createdAt,
 		})
 	}
@@ -488,9 +573,43 @@ func (h priceServiceHandler) IngestPrices(
 		return err
 	}
 
+	if h.CorporateActionRepository == nil {
+		return nil
+	}
+	// corporate actions are only needed for raw-price backtests, so
+	// don't fail the whole ingest if yahoo won't give them to us. a
+	// failed insert does fail it, since it aborts tx
+	actions, err := h.getCorporateActions(ctx, symbol, s, now)
+	if err != nil {
+		logger.FromContext(ctx).Warnf("failed to get corporate actions for %s: %s", symbol, err)
+		return nil
+	}
+	if err := h.CorporateActionRepository.Add(tx, actions); err != nil {
+		return fmt.Errorf("failed to add corporate actions for %s: %w", symbol, err)
+	}
+
 	return nil
 }
 
+func (h priceServiceHandler) getCorporateActions(ctx context.Context, symbol string, start, end time.Time) ([]model.CorporateAction, error) {
+	points, err := h.QuoteProvider.GetCorporateActions(ctx, symbol, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	models := []model.CorporateAction{}
+	for _, pt := range points {
+		models = append(models, model.CorporateAction{
+			Symbol:              symbol,
+			ExDate:              pt.ExDate,
+			CorporateActionType: pt.Type,
+			Amount:              pt.Amount,
+		})
+	}
+
+	return models, nil
+}
+
 const (
 	priceUpdateWorkers  = 5
 	priceRefreshOverlap = 7 * 24 * time.Hour
@@ -201,6 +201,75 @@ func Test_constructMinMaxMap(t *testing.T) {
 	})
 }
 
+func TestPriceCache_GetSeries(t *testing.T) {
+	pr := PriceCache{
+		prices: map[string]map[string]float64{
+			"AAPL": {
+				"2020-01-02": 1,
+				"2020-01-06": 3,
+				"2020-01-07": 4,
+			},
+		},
+		tradingDays: []time.Time{
+			util.NewDate(2020, 1, 2),
+			util.NewDate(2020, 1, 3),
+			util.NewDate(2020, 1, 6),
+			util.NewDate(2020, 1, 7),
+		},
+	}
+
+	series, err := pr.GetSeries("AAPL", util.NewDate(2020, 1, 1), util.NewDate(2020, 1, 6))
+	require.NoError(t, err)
+	require.Equal(t, []PricePoint{
+		{Date: util.NewDate(2020, 1, 2), Price: 1},
+		{Date: util.NewDate(2020, 1, 6), Price: 3},
+	}, series)
+
+	// nothing near the end of the window
+	_, err = pr.GetSeries("AAPL", util.NewDate(2020, 1, 1), util.NewDate(2020, 2, 1))
+	require.ErrorContains(t, err, "cache miss")
+	_, err = pr.GetSeries("MSFT", util.NewDate(2020, 1, 1), util.NewDate(2020, 1, 6))
+	require.ErrorContains(t, err, "cache miss")
+}
+
+func TestPriceCache_Merge(t *testing.T) {
+	newCache := func(prices map[string]map[string]float64, tradingDays ...time.Time) *PriceCache {
+		return &PriceCache{
+			prices:      prices,
+			stdevs:      &stdevCache{cache: map[string]map[time.Time]map[time.Time]float64{}},
+			tradingDays: tradingDays,
+		}
+	}
+	pr := newCache(map[string]map[string]float64{
+		"AAPL": {"2020-01-03": 1},
+	}, util.NewDate(2020, 1, 3))
+	other := newCache(map[string]map[string]float64{
+		"AAPL": {"2020-01-02": 2, "2020-01-03": 5},
+		"MSFT": {"2020-01-02": 3},
+	}, util.NewDate(2020, 1, 2), util.NewDate(2020, 1, 3))
+	other.stdevs.cache["MSFT"] = map[time.Time]map[time.Time]float64{
+		util.NewDate(2020, 1, 2): {util.NewDate(2020, 1, 3): 0.2},
+	}
+
+	pr.Merge(other)
+
+	price, err := pr.Get("AAPL", util.NewDate(2020, 1, 2))
+	require.NoError(t, err)
+	require.Equal(t, 2.0, price)
+	// keeps its own value
+	price, err = pr.Get("AAPL", util.NewDate(2020, 1, 3))
+	require.NoError(t, err)
+	require.Equal(t, 1.0, price)
+	price, err = pr.Get("MSFT", util.NewDate(2020, 1, 2))
+	require.NoError(t, err)
+	require.Equal(t, 3.0, price)
+
+	stdev, err := pr.GetStdev(context.Background(), "MSFT", util.NewDate(2020, 1, 2), util.NewDate(2020, 1, 3))
+	require.NoError(t, err)
+	require.Equal(t, 0.2, stdev)
+	require.Equal(t, []time.Time{util.NewDate(2020, 1, 2), util.NewDate(2020, 1, 3)}, pr.tradingDays)
+}
+
 func Test_priceServiceHandler_GetLatestPrices(t *testing.T) {
 
 	t.Run("test", func(t *testing.T) {
@@ -250,9 +319,55 @@ func TestPriceServiceUpdatePricesCommitsSuccessfulSymbolsIndependently(t *testin
 	require.Equal(t, latestDate.Add(-priceRefreshOverlap), provider.startFor("AAPL"))
 }
 
+func TestPriceServiceIngestPricesCorporateActions(t *testing.T) {
+	date := time.Date(2026, 7, 10, 0, 0, 0, 0, time.UTC)
+	newProvider := func() *recordingQuoteProvider {
+		return &recordingQuoteProvider{
+			points: map[string][]DailyPricePoint{
+				"AAPL": {{Date: date, Price: decimal.NewFromInt(200)}},
+			},
+			corporateActions: []CorporateActionPoint{
+				{ExDate: date, Type: model.CorporateActionType_Dividend, Amount: decimal.NewFromFloat(0.25)},
+			},
+		}
+	}
+
+	t.Run("tolerates missing corporate actions", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		prices := mock_repository.NewMockAdjustedPriceRepository(ctrl)
+		actions := mock_repository.NewMockCorporateActionRepository(ctrl)
+		prices.EXPECT().Add(nil, gomock.Any()).Return(nil)
+
+		provider := newProvider()
+		provider.actionsErr = fmt.Errorf("not found")
+		service := priceServiceHandler{QuoteProvider: provider, CorporateActionRepository: actions}
+
+		require.NoError(t, service.IngestPrices(context.Background(), nil, "AAPL", prices, &date))
+	})
+
+	t.Run("fails when corporate actions can't be saved", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		prices := mock_repository.NewMockAdjustedPriceRepository(ctrl)
+		actions := mock_repository.NewMockCorporateActionRepository(ctrl)
+		prices.EXPECT().Add(nil, gomock.Any()).Return(nil)
+		actions.EXPECT().Add(nil, []model.CorporateAction{{
+			Symbol:              "AAPL",
+			ExDate:              date,
+			CorporateActionType: model.CorporateActionType_Dividend,
+			Amount:              decimal.NewFromFloat(0.25),
+		}}).Return(fmt.Errorf("insert failed"))
+
+		service := priceServiceHandler{QuoteProvider: newProvider(), CorporateActionRepository: actions}
+
+		require.ErrorContains(t, service.IngestPrices(context.Background(), nil, "AAPL", prices, &date), "insert failed")
+	})
+}
+
 type recordingQuoteProvider struct {
-	points map[string][]DailyPricePoint
-	errors map[string]error
+	points           map[string][]DailyPricePoint
+	errors           map[string]error
+	corporateActions []CorporateActionPoint
+	actionsErr       error
 
 	mu     sync.Mutex
 	starts map[string]time.Time
@@ -277,6 +392,10 @@ func (p *recordingQuoteProvider) GetDailyAdjCloses(_ context.Context, symbol str
 	return p.points[symbol], nil
 }
 
+func (p *recordingQuoteProvider) GetCorporateActions(context.Context, string, time.Time, time.Time) ([]CorporateActionPoint, error) {
+	return p.corporateActions, p.actionsErr
+}
+
 func (p *recordingQuoteProvider) startFor(symbol string) time.Time {
 	p.mu.Lock()
 	defer p.mu.Unlock()
@@ -2,6 +2,7 @@ package data
 
 import (
 	"context"
+	"factorbacktest/internal/db/models/postgres/public/model"
 	"time"
 
 	"github.com/shopspring/decimal"
@@ -16,6 +17,7 @@ type QuoteProvider interface {
 	ProviderName() string
 	GetLatestQuotes(ctx context.Context, symbols []string) (*QuoteResponse, error)
 	GetDailyAdjCloses(ctx context.Context, symbol string, start, end time.Time) ([]DailyPricePoint, error)
+	GetCorporateActions(ctx context.Context, symbol string, start, end time.Time) ([]CorporateActionPoint, error)
 }
 type Quote struct {
 	Symbol string
@@ -25,6 +27,16 @@ type Quote struct {
 type DailyPricePoint struct {
 	Date  time.Time
 	Price decimal.Decimal
+	// unadjusted close
+	RawPrice decimal.Decimal
+}
+
+// CorporateActionPoint is a dividend or split. Amount is cash
+// per share for dividends, and new shares per old share for splits
+type CorporateActionPoint struct {
+	ExDate time.Time
+	Type   model.CorporateActionType
+	Amount decimal.Decimal
 }
 
 // QuoteResponse is designed to make partial success explicit.
@@ -3,12 +3,17 @@ package data
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strconv"
 	"time"
 
+	"factorbacktest/internal/db/models/postgres/public/model"
 	"factorbacktest/internal/logger"
 
+	finance "github.com/piquette/finance-go"
 	"github.com/piquette/finance-go/chart"
 	"github.com/piquette/finance-go/datetime"
+	"github.com/piquette/finance-go/form"
 	"github.com/shopspring/decimal"
 )
 
@@ -123,8 +128,9 @@ func (p *YahooQuoteProvider) GetDailyAdjCloses(ctx context.Context, symbol strin
 	for iter.Next() {
 		bar := iter.Bar()
 		out = append(out, DailyPricePoint{
-			Date:  time.Unix(int64(bar.Timestamp), 0).UTC(),
-			Price: bar.AdjClose,
+			Date:     time.Unix(int64(bar.Timestamp), 0).UTC(),
+			Price:    bar.AdjClose,
+			RawPrice: bar.Close,
 		})
 	}
 	if err := iter.Err(); err != nil {
@@ -132,3 +138,81 @@ func (p *YahooQuoteProvider) GetDailyAdjCloses(ctx context.Context, symbol strin
 	}
 	return out, nil
 }
+
+func (p *YahooQuoteProvider) GetCorporateActions(ctx context.Context, symbol string, start, end time.Time) ([]CorporateActionPoint, error) {
+	return getYahooCorporateActions(ctx, symbol, start, end)
+}
+
+// yahooEventsResponse is the subset of the v8 chart response that
+// carries dividend and split events. finance-go's chart client drops
+// these, so we call the endpoint directly
+type yahooEventsResponse struct {
+	Chart struct {
+		Result []struct {
+			Events struct {
+				Dividends map[string]struct {
+					Amount float64 `json:"amount"`
+					Date   int64   `json:"date"`
+				} `json:"dividends"`
+				Splits map[string]struct {
+					Date        int64   `json:"date"`
+					Numerator   float64 `json:"numerator"`
+					Denominator float64 `json:"denominator"`
+				} `json:"splits"`
+			} `json:"events"`
+		} `json:"result"`
+		Error *finance.YfinError `json:"error"`
+	} `json:"chart"`
+}
+
+func getYahooCorporateActions(ctx context.Context, symbol string, start, end time.Time) ([]CorporateActionPoint, error) {
+	s := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	e := time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC)
+
+	body := &form.Values{}
+	body.Set("period1", strconv.FormatInt(s.Unix(), 10))
+	body.Set("period2", strconv.FormatInt(e.Unix(), 10))
+	body.Set("interval", string(datetime.OneDay))
+	body.Set("events", "div|split")
+	body.Set("region", "US")
+	body.Set("corsDomain", "com.finance.yahoo")
+
+	resp := yahooEventsResponse{}
+	err := finance.GetBackend(finance.YFinBackend).Call("v8/finance/chart/"+symbol, body, &ctx, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("[yahoo_quote_provider] failed to get corporate actions for %s: %w", symbol, err)
+	}
+	if resp.Chart.Error != nil {
+		return nil, fmt.Errorf("[yahoo_quote_provider] failed to get corporate actions for %s: %w", symbol, resp.Chart.Error)
+	}
+
+	out := []CorporateActionPoint{}
+	for _, result := range resp.Chart.Result {
+		for _, d := range result.Events.Dividends {
+			out = append(out, CorporateActionPoint{
+				ExDate: toDate(time.Unix(d.Date, 0).UTC()),
+				Type:   model.CorporateActionType_Dividend,
+				Amount: decimal.NewFromFloat(d.Amount),
+			})
+		}
+		for _, split := range result.Events.Splits {
+			if split.Denominator == 0 {
+				continue
+			}
+			out = append(out, CorporateActionPoint{
+				ExDate: toDate(time.Unix(split.Date, 0).UTC()),
+				Type:   model.CorporateActionType_Split,
+				Amount: decimal.NewFromFloat(split.Numerator / split.Denominator),
+			})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].ExDate.Before(out[j].ExDate)
+	})
+
+	return out, nil
+}
+
+func toDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -19,4 +19,5 @@ type AdjustedPrice struct {
 	Symbol    string
 	Price     decimal.Decimal
 	CreatedAt time.Time
+	RawPrice  *decimal.Decimal
 }
@@ -58,4 +58,5 @@ type AssetFundamental struct {
 	TotalLongTermLiabilities            *float64
 	Goodwill                            *float64
 	IntangibleAssetsExcludingGoodwill   *float64
+	AvailableDate                       time.Time
 }
@@ -9,10 +9,13 @@ package model
 
 import (
 	"github.com/google/uuid"
+	"time"
 )
 
 type AssetUniverseTicker struct {
 	AssetUniverseTicker uuid.UUID `sql:"primary_key"`
 	TickerID            uuid.UUID
 	AssetUniverseID     uuid.UUID
+	StartDate           *time.Time
+	EndDate             *time.Time
 }
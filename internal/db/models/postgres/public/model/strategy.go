@@ -13,16 +13,24 @@ import (
 )
 
 type Strategy struct {
-	StrategyID        uuid.UUID `sql:"primary_key"`
-	StrategyName      string
-	FactorExpression  string
-	RebalanceInterval string
-	NumAssets         int32
-	AssetUniverse     string
-	Saved             bool
-	UserAccountID     *uuid.UUID
-	CreatedAt         time.Time
-	ModifiedAt        time.Time
-	Published         bool
-	Description       *string
+	StrategyID          uuid.UUID `sql:"primary_key"`
+	StrategyName        string
+	FactorExpression    string
+	RebalanceInterval   string
+	NumAssets           int32
+	AssetUniverse       string
+	Saved               bool
+	UserAccountID       *uuid.UUID
+	CreatedAt           time.Time
+	ModifiedAt          time.Time
+	Published           bool
+	Description         *string
+	PositionConstraints *string
+	WeightingScheme     *string
+	WeightingIntensity  *float64
+	LongShort           *string
+	ShortBorrowBps      *float64
+	UniverseFilter      *string
+	DividendMode        *string
+	ExecutionDelayDays  int32
 }
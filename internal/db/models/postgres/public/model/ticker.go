@@ -15,4 +15,5 @@ type Ticker struct {
 	Symbol   string
 	Name     string
 	TickerID uuid.UUID `sql:"primary_key"`
+	Sector   *string
 }
@@ -22,6 +22,7 @@ type adjustedPriceTable struct {
 	Symbol    postgres.ColumnString
 	Price     postgres.ColumnFloat
 	CreatedAt postgres.ColumnTimestampz
+	RawPrice  postgres.ColumnFloat
 
 	AllColumns     postgres.ColumnList
 	MutableColumns postgres.ColumnList
@@ -67,8 +68,9 @@ func newAdjustedPriceTableImpl(schemaName, tableName, alias string) adjustedPric
 		SymbolColumn    = postgres.StringColumn("symbol")
 		PriceColumn     = postgres.FloatColumn("price")
 		CreatedAtColumn = postgres.TimestampzColumn("created_at")
-		allColumns      = postgres.ColumnList{IDColumn, DateColumn, SymbolColumn, PriceColumn, CreatedAtColumn}
-		mutableColumns  = postgres.ColumnList{DateColumn, SymbolColumn, PriceColumn, CreatedAtColumn}
+		RawPriceColumn  = postgres.FloatColumn("raw_price")
+		allColumns      = postgres.ColumnList{IDColumn, DateColumn, SymbolColumn, PriceColumn, CreatedAtColumn, RawPriceColumn}
+		mutableColumns  = postgres.ColumnList{DateColumn, SymbolColumn, PriceColumn, CreatedAtColumn, RawPriceColumn}
 	)
 
 	return adjustedPriceTable{
@@ -80,6 +82,7 @@ func newAdjustedPriceTableImpl(schemaName, tableName, alias string) adjustedPric
 		Symbol:    SymbolColumn,
 		Price:     PriceColumn,
 		CreatedAt: CreatedAtColumn,
+		RawPrice:  RawPriceColumn,
 
 		AllColumns:     allColumns,
 		MutableColumns: mutableColumns,
@@ -62,6 +62,7 @@ type assetFundamentalTable struct {
 	TotalLongTermLiabilities            postgres.ColumnFloat
 	Goodwill                            postgres.ColumnFloat
 	IntangibleAssetsExcludingGoodwill   postgres.ColumnFloat
+	AvailableDate                       postgres.ColumnDate
 
 	AllColumns     postgres.ColumnList
 	MutableColumns postgres.ColumnList
@@ -147,8 +148,9 @@ func newAssetFundamentalTableImpl(schemaName, tableName, alias string) assetFund
 		TotalLongTermLiabilitiesColumn            = postgres.FloatColumn("total_long_term_liabilities")
 		GoodwillColumn                            = postgres.FloatColumn("goodwill")
 		IntangibleAssetsExcludingGoodwillColumn   = postgres.FloatColumn("intangible_assets_excluding_goodwill")
-		allColumns                                = postgres.ColumnList{AfIDColumn, SymbolColumn, StartDateColumn, EndDateColumn, CreatedAtColumn, RevenueColumn, CostOfRevenueColumn, GrossProfitColumn, OperatingIncomeColumn, TotalAssetsColumn, TotalCurrentAssetsColumn, PrepaidExpensesColumn, PropertyPlantAndEquipmentNetColumn, RetainedEarningsColumn, OtherAssetsNoncurrentColumn, TotalNonCurrentAssetsColumn, TotalLiabilitiesColumn, ShareholderEquityColumn, NetIncomeColumn, SharesOutstandingDilutedColumn, SharesOutstandingBasicColumn, EpsDilutedColumn, EpsBasicColumn, OperatingCashFlowColumn, InvestingCashFlowColumn, FinancingCashFlowColumn, NetCashFlowColumn, ResearchDevelopmentExpenseColumn, SellingGeneralAdministrativeExpenseColumn, OperatingExpensesColumn, NonOperatingIncomeColumn, PreTaxIncomeColumn, IncomeTaxColumn, DepreciationAmortizationColumn, StockBasedCompensationColumn, DividendsPaidColumn, CashOnHandColumn, CurrentNetReceivablesColumn, InventoryColumn, TotalCurrentLiabilitiesColumn, TotalNonCurrentLiabilitiesColumn, LongTermDebtColumn, TotalLongTermLiabilitiesColumn, GoodwillColumn, IntangibleAssetsExcludingGoodwillColumn}
-		mutableColumns                            = postgres.ColumnList{SymbolColumn, StartDateColumn, EndDateColumn, CreatedAtColumn, RevenueColumn, CostOfRevenueColumn, GrossProfitColumn, OperatingIncomeColumn, TotalAssetsColumn, TotalCurrentAssetsColumn, PrepaidExpensesColumn, PropertyPlantAndEquipmentNetColumn, RetainedEarningsColumn, OtherAssetsNoncurrentColumn, TotalNonCurrentAssetsColumn, TotalLiabilitiesColumn, ShareholderEquityColumn, NetIncomeColumn, SharesOutstandingDilutedColumn, SharesOutstandingBasicColumn, EpsDilutedColumn, EpsBasicColumn, OperatingCashFlowColumn, InvestingCashFlowColumn, FinancingCashFlowColumn, NetCashFlowColumn, ResearchDevelopmentExpenseColumn, SellingGeneralAdministrativeExpenseColumn, OperatingExpensesColumn, NonOperatingIncomeColumn, PreTaxIncomeColumn, IncomeTaxColumn, DepreciationAmortizationColumn, StockBasedCompensationColumn, DividendsPaidColumn, CashOnHandColumn, CurrentNetReceivablesColumn, InventoryColumn, TotalCurrentLiabilitiesColumn, TotalNonCurrentLiabilitiesColumn, LongTermDebtColumn, TotalLongTermLiabilitiesColumn, GoodwillColumn, IntangibleAssetsExcludingGoodwillColumn}
+		AvailableDateColumn                       = postgres.DateColumn("available_date")
+		allColumns                                = postgres.ColumnList{AfIDColumn, SymbolColumn, StartDateColumn, EndDateColumn, CreatedAtColumn, RevenueColumn, CostOfRevenueColumn, GrossProfitColumn, OperatingIncomeColumn, TotalAssetsColumn, TotalCurrentAssetsColumn, PrepaidExpensesColumn, PropertyPlantAndEquipmentNetColumn, RetainedEarningsColumn, OtherAssetsNoncurrentColumn, TotalNonCurrentAssetsColumn, TotalLiabilitiesColumn, ShareholderEquityColumn, NetIncomeColumn, SharesOutstandingDilutedColumn, SharesOutstandingBasicColumn, EpsDilutedColumn, EpsBasicColumn, OperatingCashFlowColumn, InvestingCashFlowColumn, FinancingCashFlowColumn, NetCashFlowColumn, ResearchDevelopmentExpenseColumn, SellingGeneralAdministrativeExpenseColumn, OperatingExpensesColumn, NonOperatingIncomeColumn, PreTaxIncomeColumn, IncomeTaxColumn, DepreciationAmortizationColumn, StockBasedCompensationColumn, DividendsPaidColumn, CashOnHandColumn, CurrentNetReceivablesColumn, InventoryColumn, TotalCurrentLiabilitiesColumn, TotalNonCurrentLiabilitiesColumn, LongTermDebtColumn, TotalLongTermLiabilitiesColumn, GoodwillColumn, IntangibleAssetsExcludingGoodwillColumn, AvailableDateColumn}
+		mutableColumns                            = postgres.ColumnList{SymbolColumn, StartDateColumn, EndDateColumn, CreatedAtColumn, RevenueColumn, CostOfRevenueColumn, GrossProfitColumn, OperatingIncomeColumn, TotalAssetsColumn, TotalCurrentAssetsColumn, PrepaidExpensesColumn, PropertyPlantAndEquipmentNetColumn, RetainedEarningsColumn, OtherAssetsNoncurrentColumn, TotalNonCurrentAssetsColumn, TotalLiabilitiesColumn, ShareholderEquityColumn, NetIncomeColumn, SharesOutstandingDilutedColumn, SharesOutstandingBasicColumn, EpsDilutedColumn, EpsBasicColumn, OperatingCashFlowColumn, InvestingCashFlowColumn, FinancingCashFlowColumn, NetCashFlowColumn, ResearchDevelopmentExpenseColumn, SellingGeneralAdministrativeExpenseColumn, OperatingExpensesColumn, NonOperatingIncomeColumn, PreTaxIncomeColumn, IncomeTaxColumn, DepreciationAmortizationColumn, StockBasedCompensationColumn, DividendsPaidColumn, CashOnHandColumn, CurrentNetReceivablesColumn, InventoryColumn, TotalCurrentLiabilitiesColumn, TotalNonCurrentLiabilitiesColumn, LongTermDebtColumn, TotalLongTermLiabilitiesColumn, GoodwillColumn, IntangibleAssetsExcludingGoodwillColumn, AvailableDateColumn}
 	)
 
 	return assetFundamentalTable{
@@ -200,6 +202,7 @@ func newAssetFundamentalTableImpl(schemaName, tableName, alias string) assetFund
 		TotalLongTermLiabilities:            TotalLongTermLiabilitiesColumn,
 		Goodwill:                            GoodwillColumn,
 		IntangibleAssetsExcludingGoodwill:   IntangibleAssetsExcludingGoodwillColumn,
+		AvailableDate:                       AvailableDateColumn,
 
 		AllColumns:     allColumns,
 		MutableColumns: mutableColumns,
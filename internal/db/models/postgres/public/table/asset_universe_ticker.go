@@ -20,6 +20,8 @@ type assetUniverseTickerTable struct {
 	AssetUniverseTicker postgres.ColumnString
 	TickerID            postgres.ColumnString
 	AssetUniverseID     postgres.ColumnString
+	StartDate           postgres.ColumnDate
+	EndDate             postgres.ColumnDate
 
 	AllColumns     postgres.ColumnList
 	MutableColumns postgres.ColumnList
@@ -63,8 +65,10 @@ func newAssetUniverseTickerTableImpl(schemaName, tableName, alias string) assetU
 		AssetUniverseTickerColumn = postgres.StringColumn("asset_universe_ticker")
 		TickerIDColumn            = postgres.StringColumn("ticker_id")
 		AssetUniverseIDColumn     = postgres.StringColumn("asset_universe_id")
-		allColumns                = postgres.ColumnList{AssetUniverseTickerColumn, TickerIDColumn, AssetUniverseIDColumn}
-		mutableColumns            = postgres.ColumnList{TickerIDColumn, AssetUniverseIDColumn}
+		StartDateColumn           = postgres.DateColumn("start_date")
+		EndDateColumn             = postgres.DateColumn("end_date")
+		allColumns                = postgres.ColumnList{AssetUniverseTickerColumn, TickerIDColumn, AssetUniverseIDColumn, StartDateColumn, EndDateColumn}
+		mutableColumns            = postgres.ColumnList{TickerIDColumn, AssetUniverseIDColumn, StartDateColumn, EndDateColumn}
 	)
 
 	return assetUniverseTickerTable{
@@ -74,6 +78,8 @@ func newAssetUniverseTickerTableImpl(schemaName, tableName, alias string) assetU
 		AssetUniverseTicker: AssetUniverseTickerColumn,
 		TickerID:            TickerIDColumn,
 		AssetUniverseID:     AssetUniverseIDColumn,
+		StartDate:           StartDateColumn,
+		EndDate:             EndDateColumn,
 
 		AllColumns:     allColumns,
 		MutableColumns: mutableColumns,
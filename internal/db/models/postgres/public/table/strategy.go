@@ -17,18 +17,26 @@ type strategyTable struct {
 	postgres.Table
 
 	// Columns
-	StrategyID        postgres.ColumnString
-	StrategyName      postgres.ColumnString
-	FactorExpression  postgres.ColumnString
-	RebalanceInterval postgres.ColumnString
-	NumAssets         postgres.ColumnInteger
-	AssetUniverse     postgres.ColumnString
-	Saved             postgres.ColumnBool
-	UserAccountID     postgres.ColumnString
-	CreatedAt         postgres.ColumnTimestampz
-	ModifiedAt        postgres.ColumnTimestampz
-	Published         postgres.ColumnBool
-	Description       postgres.ColumnString
+	StrategyID          postgres.ColumnString
+	StrategyName        postgres.ColumnString
+	FactorExpression    postgres.ColumnString
+	RebalanceInterval   postgres.ColumnString
+	NumAssets           postgres.ColumnInteger
+	AssetUniverse       postgres.ColumnString
+	Saved               postgres.ColumnBool
+	UserAccountID       postgres.ColumnString
+	CreatedAt           postgres.ColumnTimestampz
+	ModifiedAt          postgres.ColumnTimestampz
+	Published           postgres.ColumnBool
+	Description         postgres.ColumnString
+	PositionConstraints postgres.ColumnString
+	WeightingScheme     postgres.ColumnString
+	WeightingIntensity  postgres.ColumnFloat
+	LongShort           postgres.ColumnString
+	ShortBorrowBps      postgres.ColumnFloat
+	UniverseFilter      postgres.ColumnString
+	DividendMode        postgres.ColumnString
+	ExecutionDelayDays  postgres.ColumnInteger
 
 	AllColumns     postgres.ColumnList
 	MutableColumns postgres.ColumnList
@@ -69,38 +77,54 @@ func newStrategyTable(schemaName, tableName, alias string) *StrategyTable {
 
 func newStrategyTableImpl(schemaName, tableName, alias string) strategyTable {
 	var (
-		StrategyIDColumn        = postgres.StringColumn("strategy_id")
-		StrategyNameColumn      = postgres.StringColumn("strategy_name")
-		FactorExpressionColumn  = postgres.StringColumn("factor_expression")
-		RebalanceIntervalColumn = postgres.StringColumn("rebalance_interval")
-		NumAssetsColumn         = postgres.IntegerColumn("num_assets")
-		AssetUniverseColumn     = postgres.StringColumn("asset_universe")
-		SavedColumn             = postgres.BoolColumn("saved")
-		UserAccountIDColumn     = postgres.StringColumn("user_account_id")
-		CreatedAtColumn         = postgres.TimestampzColumn("created_at")
-		ModifiedAtColumn        = postgres.TimestampzColumn("modified_at")
-		PublishedColumn         = postgres.BoolColumn("published")
-		DescriptionColumn       = postgres.StringColumn("description")
-		allColumns              = postgres.ColumnList{StrategyIDColumn, StrategyNameColumn, FactorExpressionColumn, RebalanceIntervalColumn, NumAssetsColumn, AssetUniverseColumn, SavedColumn, UserAccountIDColumn, CreatedAtColumn, ModifiedAtColumn, PublishedColumn, DescriptionColumn}
-		mutableColumns          = postgres.ColumnList{StrategyNameColumn, FactorExpressionColumn, RebalanceIntervalColumn, NumAssetsColumn, AssetUniverseColumn, SavedColumn, UserAccountIDColumn, CreatedAtColumn, ModifiedAtColumn, PublishedColumn, DescriptionColumn}
+		StrategyIDColumn          = postgres.StringColumn("strategy_id")
+		StrategyNameColumn        = postgres.StringColumn("strategy_name")
+		FactorExpressionColumn    = postgres.StringColumn("factor_expression")
+		RebalanceIntervalColumn   = postgres.StringColumn("rebalance_interval")
+		NumAssetsColumn           = postgres.IntegerColumn("num_assets")
+		AssetUniverseColumn       = postgres.StringColumn("asset_universe")
+		SavedColumn               = postgres.BoolColumn("saved")
+		UserAccountIDColumn       = postgres.StringColumn("user_account_id")
+		CreatedAtColumn           = postgres.TimestampzColumn("created_at")
+		ModifiedAtColumn          = postgres.TimestampzColumn("modified_at")
+		PublishedColumn           = postgres.BoolColumn("published")
+		DescriptionColumn         = postgres.StringColumn("description")
+		PositionConstraintsColumn = postgres.StringColumn("position_constraints")
+		WeightingSchemeColumn     = postgres.StringColumn("weighting_scheme")
+		WeightingIntensityColumn  = postgres.FloatColumn("weighting_intensity")
+		LongShortColumn           = postgres.StringColumn("long_short")
+		ShortBorrowBpsColumn      = postgres.FloatColumn("short_borrow_bps")
+		UniverseFilterColumn      = postgres.StringColumn("universe_filter")
+		DividendModeColumn        = postgres.StringColumn("dividend_mode")
+		ExecutionDelayDaysColumn  = postgres.IntegerColumn("execution_delay_days")
+		allColumns                = postgres.ColumnList{StrategyIDColumn, StrategyNameColumn, FactorExpressionColumn, RebalanceIntervalColumn, NumAssetsColumn, AssetUniverseColumn, SavedColumn, UserAccountIDColumn, CreatedAtColumn, ModifiedAtColumn, PublishedColumn, DescriptionColumn, PositionConstraintsColumn, WeightingSchemeColumn, WeightingIntensityColumn, LongShortColumn, ShortBorrowBpsColumn, UniverseFilterColumn, DividendModeColumn, ExecutionDelayDaysColumn}
+		mutableColumns            = postgres.ColumnList{StrategyNameColumn, FactorExpressionColumn, RebalanceIntervalColumn, NumAssetsColumn, AssetUniverseColumn, SavedColumn, UserAccountIDColumn, CreatedAtColumn, ModifiedAtColumn, PublishedColumn, DescriptionColumn, PositionConstraintsColumn, WeightingSchemeColumn, WeightingIntensityColumn, LongShortColumn, ShortBorrowBpsColumn, UniverseFilterColumn, DividendModeColumn, ExecutionDelayDaysColumn}
 	)
 
 	return strategyTable{
 		Table: postgres.NewTable(schemaName, tableName, alias, allColumns...),
 
 		//Columns
-		StrategyID:        StrategyIDColumn,
-		StrategyName:      StrategyNameColumn,
-		FactorExpression:  FactorExpressionColumn,
-		RebalanceInterval: RebalanceIntervalColumn,
-		NumAssets:         NumAssetsColumn,
-		AssetUniverse:     AssetUniverseColumn,
-		Saved:             SavedColumn,
-		UserAccountID:     UserAccountIDColumn,
-		CreatedAt:         CreatedAtColumn,
-		ModifiedAt:        ModifiedAtColumn,
-		Published:         PublishedColumn,
-		Description:       DescriptionColumn,
+		StrategyID:          StrategyIDColumn,
+		StrategyName:        StrategyNameColumn,
+		FactorExpression:    FactorExpressionColumn,
+		RebalanceInterval:   RebalanceIntervalColumn,
+		NumAssets:           NumAssetsColumn,
+		AssetUniverse:       AssetUniverseColumn,
+		Saved:               SavedColumn,
+		UserAccountID:       UserAccountIDColumn,
+		CreatedAt:           CreatedAtColumn,
+		ModifiedAt:          ModifiedAtColumn,
+		Published:           PublishedColumn,
+		Description:         DescriptionColumn,
+		PositionConstraints: PositionConstraintsColumn,
+		WeightingScheme:     WeightingSchemeColumn,
+		WeightingIntensity:  WeightingIntensityColumn,
+		LongShort:           LongShortColumn,
+		ShortBorrowBps:      ShortBorrowBpsColumn,
+		UniverseFilter:      UniverseFilterColumn,
+		DividendMode:        DividendModeColumn,
+		ExecutionDelayDays:  ExecutionDelayDaysColumn,
 
 		AllColumns:     allColumns,
 		MutableColumns: mutableColumns,
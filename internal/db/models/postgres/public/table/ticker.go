@@ -20,6 +20,7 @@ type tickerTable struct {
 	Symbol   postgres.ColumnString
 	Name     postgres.ColumnString
 	TickerID postgres.ColumnString
+	Sector   postgres.ColumnString
 
 	AllColumns     postgres.ColumnList
 	MutableColumns postgres.ColumnList
@@ -63,8 +64,9 @@ func newTickerTableImpl(schemaName, tableName, alias string) tickerTable {
 		SymbolColumn   = postgres.StringColumn("symbol")
 		NameColumn     = postgres.StringColumn("name")
 		TickerIDColumn = postgres.StringColumn("ticker_id")
-		allColumns     = postgres.ColumnList{SymbolColumn, NameColumn, TickerIDColumn}
-		mutableColumns = postgres.ColumnList{SymbolColumn, NameColumn}
+		SectorColumn   = postgres.StringColumn("sector")
+		allColumns     = postgres.ColumnList{SymbolColumn, NameColumn, TickerIDColumn, SectorColumn}
+		mutableColumns = postgres.ColumnList{SymbolColumn, NameColumn, SectorColumn}
 	)
 
 	return tickerTable{
@@ -74,6 +76,7 @@ func newTickerTableImpl(schemaName, tableName, alias string) tickerTable {
 		Symbol:   SymbolColumn,
 		Name:     NameColumn,
 		TickerID: TickerIDColumn,
+		Sector:   SectorColumn,
 
 		AllColumns:     allColumns,
 		MutableColumns: mutableColumns,
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/google/uuid"
@@ -58,6 +59,67 @@ func (p Portfolio) TotalValue(priceMap map[string]decimal.Decimal) (decimal.Deci
 	return totalValue, nil
 }
 
+// TradesToTarget returns the trades required to go from the current
+// portfolio to the target, priced using priceMap. it does not apply
+// any minimum order size rules
+func (p Portfolio) TradesToTarget(target Portfolio, priceMap map[string]decimal.Decimal) []*ProposedTrade {
+	trades := []*ProposedTrade{}
+	for symbol, position := range target.Positions {
+		diff := position.ExactQuantity
+		if prevPosition, ok := p.Positions[symbol]; ok {
+			diff = position.ExactQuantity.Sub(prevPosition.ExactQuantity)
+		}
+		if !diff.Equal(decimal.Zero) {
+			trades = append(trades, &ProposedTrade{
+				Symbol:        symbol,
+				TickerID:      position.TickerID,
+				ExactQuantity: diff,
+				ExpectedPrice: priceMap[symbol],
+			})
+		}
+	}
+	for symbol, position := range p.Positions {
+		if _, ok := target.Positions[symbol]; !ok {
+			trades = append(trades, &ProposedTrade{
+				Symbol:        symbol,
+				TickerID:      position.TickerID,
+				ExactQuantity: position.ExactQuantity.Neg(),
+				ExpectedPrice: priceMap[symbol],
+			})
+		}
+	}
+	return trades
+}
+
+// MaxWeightDrift returns the largest absolute difference between the
+// portfolio's current weights and the target weights
+func (p Portfolio) MaxWeightDrift(targetWeights map[string]float64, priceMap map[string]decimal.Decimal) (float64, error) {
+	totalValue, err := p.TotalValue(priceMap)
+	if err != nil {
+		return 0, err
+	}
+	if totalValue.IsZero() {
+		return 0, fmt.Errorf("cannot compute drift of portfolio with no value")
+	}
+
+	currentWeights := map[string]float64{}
+	for symbol, position := range p.Positions {
+		currentWeights[symbol] = position.ExactQuantity.Mul(priceMap[symbol]).Div(totalValue).InexactFloat64()
+	}
+
+	maxDrift := 0.0
+	for symbol, w := range targetWeights {
+		maxDrift = math.Max(maxDrift, math.Abs(w-currentWeights[symbol]))
+	}
+	for symbol, w := range currentWeights {
+		if _, ok := targetWeights[symbol]; !ok {
+			maxDrift = math.Max(maxDrift, math.Abs(w))
+		}
+	}
+
+	return maxDrift, nil
+}
+
 type Position struct {
 	Symbol   string
 	Quantity float64
@@ -8,6 +8,9 @@ import (
 
 type AssetPrice struct {
 	Symbol string
-	Price  decimal.Decimal
-	Date   time.Time
+	// adjusted for splits and dividends
+	Price decimal.Decimal
+	Date  time.Time
+	// unadjusted close. only set where we have it
+	RawPrice *decimal.Decimal
 }
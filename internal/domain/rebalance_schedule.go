@@ -0,0 +1,235 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type RebalanceScheduleType string
+
+const (
+	// fixed number of days from the previous rebalance. this is
+	// what "daily", "weekly", "monthly" and "yearly" have always meant
+	RebalanceScheduleType_Interval RebalanceScheduleType = "INTERVAL"
+	// last trading day of each month
+	RebalanceScheduleType_MonthEnd RebalanceScheduleType = "MONTH_END"
+	// last trading day of March, June, September and December
+	RebalanceScheduleType_QuarterEnd RebalanceScheduleType = "QUARTER_END"
+	// first trading day on or after the first <Weekday> of each month
+	RebalanceScheduleType_FirstWeekday RebalanceScheduleType = "FIRST_WEEKDAY"
+	// checked every trading day, but only trades when some
+	// weight drifts from target by more than DriftThreshold
+	RebalanceScheduleType_Drift RebalanceScheduleType = "DRIFT"
+)
+
+// RebalanceSchedule decides when a strategy trades. the same schedule
+// drives backtests and live investments
+type RebalanceSchedule struct {
+	Type     RebalanceScheduleType
+	Interval time.Duration
+	Weekday  time.Weekday
+	// absolute weight difference, e.g. 0.05 for 5%
+	DriftThreshold float64
+}
+
+// ParseRebalanceSchedule reads schedules from the strings stored on
+// strategies. legacy values are "daily", "weekly", "monthly" and
+// "yearly", and "" is treated as daily. calendar anchors are
+// "month_end", "quarter_end" and "first_<weekday>", e.g. "first_monday".
+// drift bands are "drift:<percent>", e.g. "drift:5"
+func ParseRebalanceSchedule(s string) (*RebalanceSchedule, error) {
+	normalized := strings.ToLower(strings.TrimSpace(s))
+
+	day := time.Hour * 24
+	switch normalized {
+	case "", "daily":
+		return &RebalanceSchedule{Type: RebalanceScheduleType_Interval, Interval: day}, nil
+	case "weekly":
+		return &RebalanceSchedule{Type: RebalanceScheduleType_Interval, Interval: 7 * day}, nil
+	case "monthly":
+		return &RebalanceSchedule{Type: RebalanceScheduleType_Interval, Interval: 30 * day}, nil
+	case "yearly":
+		return &RebalanceSchedule{Type: RebalanceScheduleType_Interval, Interval: 365 * day}, nil
+	case "month_end":
+		return &RebalanceSchedule{Type: RebalanceScheduleType_MonthEnd}, nil
+	case "quarter_end":
+		return &RebalanceSchedule{Type: RebalanceScheduleType_QuarterEnd}, nil
+	}
+
+	if weekdayStr, ok := strings.CutPrefix(normalized, "first_"); ok {
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			if strings.ToLower(d.String()) == weekdayStr {
+				return &RebalanceSchedule{Type: RebalanceScheduleType_FirstWeekday, Weekday: d}, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid weekday in rebalance schedule %s", s)
+	}
+
+	if pctStr, ok := strings.CutPrefix(normalized, "drift:"); ok {
+		pct, err := strconv.ParseFloat(pctStr, 64)
+		if err != nil || pct <= 0 || pct >= 100 {
+			return nil, fmt.Errorf("invalid drift threshold in rebalance schedule %s", s)
+		}
+		return &RebalanceSchedule{Type: RebalanceScheduleType_Drift, DriftThreshold: pct / 100}, nil
+	}
+
+	return nil, fmt.Errorf("invalid rebalance schedule %s", s)
+}
+
+// RebalanceDays picks the days to rebalance on out of tradingDays, which
+// must be sorted. the first trading day is always included so the
+// starting cash gets invested. drift schedules return every day, since
+// whether they trade depends on the portfolio
+func (s RebalanceSchedule) RebalanceDays(tradingDays []time.Time) []time.Time {
+	if len(tradingDays) == 0 {
+		return []time.Time{}
+	}
+
+	switch s.Type {
+	case RebalanceScheduleType_Interval:
+		return intervalDays(tradingDays, s.Interval)
+	case RebalanceScheduleType_Drift:
+		return tradingDays
+	}
+
+	out := []time.Time{tradingDays[0]}
+	for i := 1; i < len(tradingDays); i++ {
+		var next *time.Time
+		if i+1 < len(tradingDays) {
+			next = &tradingDays[i+1]
+		}
+		if s.isAnchor(tradingDays[i], tradingDays[i-1], next) {
+			out = append(out, tradingDays[i])
+		}
+	}
+	return out
+}
+
+// isAnchor checks whether t is an anchor day, given the trading days
+// around it. next is nil when t is the last day we have data for, in
+// which case weekdays stand in for trading days
+func (s RebalanceSchedule) isAnchor(t, prev time.Time, next *time.Time) bool {
+	switch s.Type {
+	case RebalanceScheduleType_MonthEnd, RebalanceScheduleType_QuarterEnd:
+		if s.Type == RebalanceScheduleType_QuarterEnd && t.Month()%3 != 0 {
+			return false
+		}
+		if next != nil {
+			return next.Month() != t.Month()
+		}
+		return sameDay(t, lastWeekdayOfMonth(t))
+	case RebalanceScheduleType_FirstWeekday:
+		// first trading day on or after the anchor, so holidays
+		// push the rebalance back a day
+		anchor := firstWeekdayOfMonth(t, s.Weekday)
+		return !t.Before(anchor) && prev.Before(anchor)
+	}
+	return false
+}
+
+// IsDue decides whether a live investment should rebalance on date, given
+// when it last rebalanced. we don't have a holiday calendar here, so anchors
+// that fall on a holiday are picked up on the next run. drift schedules are
+// always due, and the caller decides whether drift is big enough to trade
+func (s RebalanceSchedule) IsDue(date time.Time, lastRebalance *time.Time) bool {
+	if lastRebalance == nil {
+		return true
+	}
+	date = truncateToDay(date)
+	last := truncateToDay(*lastRebalance)
+
+	switch s.Type {
+	case RebalanceScheduleType_Interval:
+		daysSince := date.Sub(last).Hours() / 24
+		return daysSince >= math.Round(s.Interval.Hours()/24)
+	case RebalanceScheduleType_Drift:
+		return true
+	}
+
+	anchor := s.mostRecentAnchor(date)
+	return anchor.After(last)
+}
+
+// mostRecentAnchor finds the latest anchor on or before date, treating
+// every weekday as a trading day
+func (s RebalanceSchedule) mostRecentAnchor(date time.Time) time.Time {
+	for t := date; ; t = t.AddDate(0, 0, -1) {
+		if !isWeekday(t) {
+			continue
+		}
+		next := nextWeekday(t)
+		if s.isAnchor(t, prevWeekday(t), &next) {
+			return t
+		}
+	}
+}
+
+// intervalDays steps through the trading days in fixed increments,
+// moving to the next trading day whenever we land on a day without data
+func intervalDays(allTradingDays []time.Time, interval time.Duration) []time.Time {
+	allTradingDaysSet := map[time.Time]bool{}
+	for _, t := range allTradingDays {
+		allTradingDaysSet[t] = true
+	}
+	end := allTradingDays[len(allTradingDays)-1]
+
+	tradingDays := []time.Time{}
+	currentTime := allTradingDays[0]
+	for currentTime.Unix() <= end.Unix() {
+		if _, ok := allTradingDaysSet[currentTime]; ok {
+			tradingDays = append(tradingDays, currentTime)
+			currentTime = currentTime.Add(interval)
+		} else {
+			currentTime = currentTime.Add(time.Hour * 24)
+		}
+	}
+
+	return tradingDays
+}
+
+func lastWeekdayOfMonth(t time.Time) time.Time {
+	d := time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location()).AddDate(0, 0, -1)
+	for !isWeekday(d) {
+		d = d.AddDate(0, 0, -1)
+	}
+	return d
+}
+
+func firstWeekdayOfMonth(t time.Time, weekday time.Weekday) time.Time {
+	d := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	for d.Weekday() != weekday {
+		d = d.AddDate(0, 0, 1)
+	}
+	return d
+}
+
+func nextWeekday(t time.Time) time.Time {
+	d := t.AddDate(0, 0, 1)
+	for !isWeekday(d) {
+		d = d.AddDate(0, 0, 1)
+	}
+	return d
+}
+
+func prevWeekday(t time.Time) time.Time {
+	d := t.AddDate(0, 0, -1)
+	for !isWeekday(d) {
+		d = d.AddDate(0, 0, -1)
+	}
+	return d
+}
+
+func isWeekday(t time.Time) bool {
+	return t.Weekday() != time.Saturday && t.Weekday() != time.Sunday
+}
+
+func truncateToDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.YearDay() == b.YearDay()
+}
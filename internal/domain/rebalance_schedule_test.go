@@ -0,0 +1,102 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestRebalanceSchedule_RebalanceDays(t *testing.T) {
+	// every weekday from mid-march to mid-april 2024, minus good friday
+	tradingDays := []time.Time{}
+	for d := date(2024, 3, 25); d.Before(date(2024, 4, 12)); d = d.AddDate(0, 0, 1) {
+		if isWeekday(d) && !d.Equal(date(2024, 3, 29)) {
+			tradingDays = append(tradingDays, d)
+		}
+	}
+
+	t.Run("month end skips the holiday", func(t *testing.T) {
+		schedule, err := ParseRebalanceSchedule("month_end")
+		require.NoError(t, err)
+		require.Equal(t, "", cmp.Diff(
+			[]time.Time{date(2024, 3, 25), date(2024, 3, 28)},
+			schedule.RebalanceDays(tradingDays),
+		))
+	})
+
+	t.Run("first monday", func(t *testing.T) {
+		schedule, err := ParseRebalanceSchedule("FIRST_MONDAY")
+		require.NoError(t, err)
+		require.Equal(t, "", cmp.Diff(
+			[]time.Time{date(2024, 3, 25), date(2024, 4, 1)},
+			schedule.RebalanceDays(tradingDays),
+		))
+	})
+
+	t.Run("legacy weekly", func(t *testing.T) {
+		schedule, err := ParseRebalanceSchedule("weekly")
+		require.NoError(t, err)
+		require.Equal(t, "", cmp.Diff(
+			[]time.Time{date(2024, 3, 25), date(2024, 4, 1), date(2024, 4, 8)},
+			schedule.RebalanceDays(tradingDays),
+		))
+	})
+}
+
+func TestParseRebalanceSchedule(t *testing.T) {
+	schedule, err := ParseRebalanceSchedule(" MONTHLY")
+	require.NoError(t, err)
+	require.Equal(t, RebalanceSchedule{Type: RebalanceScheduleType_Interval, Interval: 30 * 24 * time.Hour}, *schedule)
+	schedule, err = ParseRebalanceSchedule("")
+	require.NoError(t, err)
+	require.Equal(t, RebalanceSchedule{Type: RebalanceScheduleType_Interval, Interval: 24 * time.Hour}, *schedule)
+
+	_, err = ParseRebalanceSchedule("month-end")
+	require.Error(t, err)
+	_, err = ParseRebalanceSchedule("quarterly")
+	require.Error(t, err)
+	_, err = ParseRebalanceSchedule("first_funday")
+	require.Error(t, err)
+	_, err = ParseRebalanceSchedule("drift:abc")
+	require.Error(t, err)
+}
+
+func TestRebalanceSchedule_IsDue(t *testing.T) {
+	monthEnd := RebalanceSchedule{Type: RebalanceScheduleType_MonthEnd}
+	last := date(2024, 2, 29)
+
+	require.True(t, monthEnd.IsDue(date(2024, 3, 5), nil))
+	require.False(t, monthEnd.IsDue(date(2024, 3, 28), &last))
+	require.True(t, monthEnd.IsDue(date(2024, 3, 29), &last))
+	// missed the month end, so catch up
+	require.True(t, monthEnd.IsDue(date(2024, 4, 1), &last))
+
+	monthly := RebalanceSchedule{Type: RebalanceScheduleType_Interval, Interval: 30 * 24 * time.Hour}
+	require.False(t, monthly.IsDue(date(2024, 3, 29), &last))
+	require.True(t, monthly.IsDue(date(2024, 3, 30), &last))
+}
+
+func TestPortfolio_MaxWeightDrift(t *testing.T) {
+	p := NewPortfolio()
+	p.Positions["AAPL"] = &Position{Symbol: "AAPL", ExactQuantity: decimal.NewFromInt(6)}
+	p.Positions["MSFT"] = &Position{Symbol: "MSFT", ExactQuantity: decimal.NewFromInt(4)}
+	pm := map[string]decimal.Decimal{
+		"AAPL": decimal.NewFromInt(10),
+		"MSFT": decimal.NewFromInt(10),
+	}
+
+	drift, err := p.MaxWeightDrift(map[string]float64{"AAPL": 0.5, "MSFT": 0.5}, pm)
+	require.NoError(t, err)
+	require.InDelta(t, 0.1, drift, 0.0001)
+
+	drift, err = p.MaxWeightDrift(map[string]float64{"AAPL": 0.6, "GOOG": 0.4}, pm)
+	require.NoError(t, err)
+	require.InDelta(t, 0.4, drift, 0.0001)
+}
@@ -124,6 +124,17 @@ func mapQuarter(year, quarter int) (time.Time, time.Time) {
 	return x.start, x.end
 }
 
+// filingAvailableDate is when a quarter's filing is assumed to be public,
+// since the data source doesn't say. these are the sec's 10-q and 10-k
+// deadlines for smaller filers, so most filings are out sooner. keep in
+// sync with the backfill in the available_date migration
+func filingAvailableDate(quarter int, end time.Time) time.Time {
+	if quarter == 4 {
+		return end.AddDate(0, 0, 90)
+	}
+	return end.AddDate(0, 0, 45)
+}
+
 func invertDjResponse(asset string, in datajockey.Fields) ([]model.AssetFundamental, error) {
 	mappedValues := map[string]*model.AssetFundamental{}
 
@@ -463,6 +474,7 @@ func invertDjResponse(asset string, in datajockey.Fields) ([]model.AssetFundamen
 			Symbol:                              asset,
 			StartDate:                           start,
 			EndDate:                             end,
+			AvailableDate:                       filingAvailableDate(quarter, end),
 			CreatedAt:                           &now,
 			Revenue:                             v.Revenue,
 			CostOfRevenue:                       v.CostOfRevenue,
@@ -84,6 +84,7 @@ func (h adjustedPriceRepositoryHandler) Add(tx *sql.Tx, adjPrices []model.Adjust
 		).DO_UPDATE(
 		postgres.SET(
 			table.AdjustedPrice.Price.SET(table.AdjustedPrice.EXCLUDED.Price),
+			table.AdjustedPrice.RawPrice.SET(table.AdjustedPrice.EXCLUDED.RawPrice),
 		),
 	)
 
@@ -209,9 +210,10 @@ func (h adjustedPriceRepositoryHandler) List(symbols []string, start, end time.T
 	out := []domain.AssetPrice{}
 	for _, p := range result {
 		out = append(out, domain.AssetPrice{
-			Symbol: p.Symbol,
-			Date:   p.Date,
-			Price:  p.Price,
+			Symbol:   p.Symbol,
+			Date:     p.Date,
+			Price:    p.Price,
+			RawPrice: p.RawPrice,
 		})
 		h.AddToPriceCache(p.Symbol, p.Date, p.Price)
 	}
@@ -443,9 +445,10 @@ func (h adjustedPriceRepositoryHandler) GetMany(inputs []GetManyInput) ([]domain
 		}
 		for _, m := range result.models {
 			out = append(out, domain.AssetPrice{
-				Symbol: m.Symbol,
-				Price:  m.Price,
-				Date:   m.Date,
+				Symbol:   m.Symbol,
+				Price:    m.Price,
+				Date:     m.Date,
+				RawPrice: m.RawPrice,
 			})
 		}
 	}
@@ -12,7 +12,9 @@ import (
 
 type AssetFundamentalsRepository interface {
 	Add(qrm.Executable, []model.AssetFundamental) error
-	Get(tx qrm.Queryable, symbol string, date time.Time) (*model.AssetFundamental, error)
+	// ListAvailable returns up to limit of the latest filings that were
+	// public on date, most recent quarter first
+	ListAvailable(tx qrm.Queryable, symbol string, date time.Time, limit int) ([]model.AssetFundamental, error)
 }
 
 type AssetFundamentalsRepositoryHandler struct{}
@@ -36,22 +38,22 @@ func (h AssetFundamentalsRepositoryHandler) Add(tx qrm.Executable, af []model.As
 	return nil
 }
 
-func (h AssetFundamentalsRepositoryHandler) Get(tx qrm.Queryable, symbol string, date time.Time) (*model.AssetFundamental, error) {
-	d := DateT(date)
+func (h AssetFundamentalsRepositoryHandler) ListAvailable(tx qrm.Queryable, symbol string, date time.Time, limit int) ([]model.AssetFundamental, error) {
 	query := AssetFundamental.
 		SELECT(AssetFundamental.AllColumns).
 		WHERE(
 			AND(
 				AssetFundamental.Symbol.EQ(String(symbol)),
-				AssetFundamental.StartDate.LT_EQ(d),
-				AssetFundamental.EndDate.GT_EQ(d),
+				AssetFundamental.AvailableDate.LT_EQ(DateT(date)),
 			),
-		)
+		).
+		ORDER_BY(AssetFundamental.EndDate.DESC()).
+		LIMIT(int64(limit))
 
-	out := &model.AssetFundamental{}
-	err := query.Query(tx, out)
+	out := []model.AssetFundamental{}
+	err := query.Query(tx, &out)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get asset fundamental with symbol %s: %w", symbol, err)
+		return nil, fmt.Errorf("failed to list asset fundamentals for %s: %w", symbol, err)
 	}
 
 	return out, nil
@@ -7,18 +7,84 @@ import (
 	"factorbacktest/internal/db/models/postgres/public/table"
 	"factorbacktest/internal/db/models/postgres/public/view"
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/go-jet/jet/v2/postgres"
 	"github.com/go-jet/jet/v2/qrm"
+	"github.com/google/uuid"
 )
 
 type AssetUniverseRepository interface {
 	GetAssetUniverses() ([]model.AssetUniverseSize, error)
-	GetAssets(assetUniverseName string) ([]model.Ticker, error)
+	// GetAssets returns the universe's members as of the given date
+	GetAssets(assetUniverseName string, asOf time.Time) ([]model.Ticker, error)
+	// GetMembership returns every ticker that was in the universe at
+	// any point between start and end, and when
+	GetMembership(assetUniverseName string, start, end time.Time) (UniverseMembership, error)
 	AddAssets(tx *sql.Tx, universe model.AssetUniverse, tickers []model.Ticker) error
+	ApplyMembershipChanges(tx *sql.Tx, universe model.AssetUniverse, changes []UniverseMembershipChange) error
 	GetOrCreate(tx *sql.Tx, name string) (*model.AssetUniverse, error)
 }
 
+type UniverseMember struct {
+	Ticker model.Ticker
+	// nil means it was a member before we have history for the universe
+	StartDate *time.Time
+	// first day it's no longer a member. nil means it still is
+	EndDate *time.Time
+}
+
+func (m UniverseMember) isMemberOn(date time.Time) bool {
+	return (m.StartDate == nil || !date.Before(*m.StartDate)) &&
+		(m.EndDate == nil || date.Before(*m.EndDate))
+}
+
+// UniverseMembership is a universe's membership history. a ticker appears
+// once per stint in the universe
+type UniverseMembership []UniverseMember
+
+// Tickers returns every ticker that was ever a member, sorted by symbol
+func (m UniverseMembership) Tickers() []model.Ticker {
+	seen := map[uuid.UUID]bool{}
+	out := []model.Ticker{}
+	for _, member := range m {
+		if !seen[member.Ticker.TickerID] {
+			seen[member.Ticker.TickerID] = true
+			out = append(out, member.Ticker)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Symbol < out[j].Symbol
+	})
+	return out
+}
+
+// MembersOn returns the tickers that were in the universe on the date,
+// sorted by symbol
+func (m UniverseMembership) MembersOn(date time.Time) []model.Ticker {
+	seen := map[uuid.UUID]bool{}
+	out := []model.Ticker{}
+	for _, member := range m {
+		if member.isMemberOn(date) && !seen[member.Ticker.TickerID] {
+			seen[member.Ticker.TickerID] = true
+			out = append(out, member.Ticker)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Symbol < out[j].Symbol
+	})
+	return out
+}
+
+// UniverseMembershipChange is a ticker joining or leaving a universe,
+// effective on Date
+type UniverseMembershipChange struct {
+	Date   time.Time
+	Ticker model.Ticker
+	Added  bool
+}
+
 type assetUniverseRepositoryHandler struct {
 	Db qrm.Queryable
 }
@@ -39,33 +105,60 @@ func (h assetUniverseRepositoryHandler) GetAssetUniverses() ([]model.AssetUniver
 	return out, nil
 }
 
-func (h assetUniverseRepositoryHandler) GetAssets(assetUniverseName string) ([]model.Ticker, error) {
-	query := postgres.SELECT(table.Ticker.AllColumns).FROM(
+func (h assetUniverseRepositoryHandler) GetAssets(assetUniverseName string, asOf time.Time) ([]model.Ticker, error) {
+	membership, err := h.GetMembership(assetUniverseName, asOf, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	return membership.MembersOn(asOf), nil
+}
+
+func (h assetUniverseRepositoryHandler) GetMembership(assetUniverseName string, start, end time.Time) (UniverseMembership, error) {
+	t := table.AssetUniverseTicker
+	// selecting the membership's primary key keeps jet from merging a ticker's
+	// separate stints into one row
+	query := postgres.SELECT(table.Ticker.AllColumns, t.AllColumns).FROM(
 		table.Ticker.
 			INNER_JOIN(
-				table.AssetUniverseTicker,
-				table.AssetUniverseTicker.TickerID.EQ(table.Ticker.TickerID),
+				t,
+				t.TickerID.EQ(table.Ticker.TickerID),
 			).
 			INNER_JOIN(
 				table.AssetUniverse,
-				table.AssetUniverse.AssetUniverseID.EQ(table.AssetUniverseTicker.AssetUniverseID),
+				table.AssetUniverse.AssetUniverseID.EQ(t.AssetUniverseID),
 			),
 	)
 
+	// any stint that overlaps the range
+	conditions := []postgres.BoolExpression{
+		postgres.OR(t.StartDate.IS_NULL(), t.StartDate.LT_EQ(postgres.DateT(end))),
+		postgres.OR(t.EndDate.IS_NULL(), t.EndDate.GT(postgres.DateT(start))),
+	}
 	if assetUniverseName != "ALL" {
-		query = query.WHERE(table.AssetUniverse.AssetUniverseName.EQ(postgres.String(assetUniverseName)))
+		conditions = append(conditions, table.AssetUniverse.AssetUniverseName.EQ(postgres.String(assetUniverseName)))
 	}
+	query = query.WHERE(postgres.AND(conditions...))
 
-	// i don't understand where duplicates are
-	// being filtered
-
-	tickers := []model.Ticker{}
-	err := query.Query(h.Db, &tickers)
+	result := []struct {
+		model.Ticker
+		model.AssetUniverseTicker
+	}{}
+	err := query.Query(h.Db, &result)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query assets from %s: %w", assetUniverseName, err)
+		return nil, fmt.Errorf("failed to query membership of %s: %w", assetUniverseName, err)
 	}
 
-	return tickers, nil
+	out := UniverseMembership{}
+	for _, r := range result {
+		out = append(out, UniverseMember{
+			Ticker:    r.Ticker,
+			StartDate: r.AssetUniverseTicker.StartDate,
+			EndDate:   r.AssetUniverseTicker.EndDate,
+		})
+	}
+
+	return out, nil
 }
 
 func (h assetUniverseRepositoryHandler) AddAssets(tx *sql.Tx, universe model.AssetUniverse, tickers []model.Ticker) error {
@@ -82,7 +175,7 @@ func (h assetUniverseRepositoryHandler) AddAssets(tx *sql.Tx, universe model.Ass
 		ON_CONFLICT(
 			table.AssetUniverseTicker.TickerID,
 			table.AssetUniverseTicker.AssetUniverseID,
-		).DO_NOTHING()
+		).WHERE(table.AssetUniverseTicker.EndDate.IS_NULL()).DO_NOTHING()
 
 	_, err := query.Exec(tx)
 	if err != nil {
@@ -92,6 +185,71 @@ func (h assetUniverseRepositoryHandler) AddAssets(tx *sql.Tx, universe model.Ass
 	return nil
 }
 
+// ApplyMembershipChanges replays a history of universe changes, in date
+// order. adding a ticker that's already an open-ended member with no
+// start date backfills its start date. removing a ticker we have no open
+// membership for records it as a member up until the removal
+func (h assetUniverseRepositoryHandler) ApplyMembershipChanges(tx *sql.Tx, universe model.AssetUniverse, changes []UniverseMembershipChange) error {
+	sorted := append([]UniverseMembershipChange{}, changes...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Date.Before(sorted[j].Date)
+	})
+
+	t := table.AssetUniverseTicker
+	for _, change := range sorted {
+		date := change.Date
+		if change.Added {
+			query := t.INSERT(t.MutableColumns).
+				MODEL(model.AssetUniverseTicker{
+					TickerID:        change.Ticker.TickerID,
+					AssetUniverseID: universe.AssetUniverseID,
+					StartDate:       &date,
+				}).
+				ON_CONFLICT(t.TickerID, t.AssetUniverseID).
+				WHERE(t.EndDate.IS_NULL()).
+				DO_UPDATE(
+					postgres.SET(t.StartDate.SET(t.EXCLUDED.StartDate)).
+						WHERE(t.StartDate.IS_NULL()),
+				)
+			if _, err := query.Exec(tx); err != nil {
+				return fmt.Errorf("failed to add %s to universe %s on %s: %w", change.Ticker.Symbol, universe.AssetUniverseName, date.Format(time.DateOnly), err)
+			}
+			continue
+		}
+
+		query := t.UPDATE(t.EndDate).
+			SET(postgres.DateT(date)).
+			WHERE(postgres.AND(
+				t.TickerID.EQ(postgres.UUID(change.Ticker.TickerID)),
+				t.AssetUniverseID.EQ(postgres.UUID(universe.AssetUniverseID)),
+				t.EndDate.IS_NULL(),
+			))
+		result, err := query.Exec(tx)
+		if err != nil {
+			return fmt.Errorf("failed to remove %s from universe %s on %s: %w", change.Ticker.Symbol, universe.AssetUniverseName, date.Format(time.DateOnly), err)
+		}
+		numRows, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if numRows > 0 {
+			continue
+		}
+
+		insertQuery := t.INSERT(t.MutableColumns).
+			MODEL(model.AssetUniverseTicker{
+				TickerID:        change.Ticker.TickerID,
+				AssetUniverseID: universe.AssetUniverseID,
+				EndDate:         &date,
+			})
+		if _, err := insertQuery.Exec(tx); err != nil {
+			return fmt.Errorf("failed to remove %s from universe %s on %s: %w", change.Ticker.Symbol, universe.AssetUniverseName, date.Format(time.DateOnly), err)
+		}
+	}
+
+	return nil
+}
+
 func (h assetUniverseRepositoryHandler) GetOrCreate(tx *sql.Tx, name string) (*model.AssetUniverse, error) {
 	query := table.AssetUniverse.SELECT(table.AssetUniverse.AllColumns).WHERE(table.AssetUniverse.AssetUniverseName.EQ(postgres.String(name)))
 	out := model.AssetUniverse{}
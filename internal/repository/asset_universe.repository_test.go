@@ -4,10 +4,13 @@ import (
 	"database/sql"
 	"factorbacktest/internal/db/models/postgres/public/model"
 	"factorbacktest/internal/db/models/postgres/public/table"
+	"factorbacktest/internal/util"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/go-jet/jet/v2/postgres"
+	"github.com/google/uuid"
 	_ "github.com/lib/pq"
 	"github.com/stretchr/testify/require"
 )
@@ -147,7 +150,7 @@ func Test_assetUniverseRepositoryHandler_GetAssets(t *testing.T) {
 
 		handler := assetUniverseRepositoryHandler{tx}
 
-		tickers, err := handler.GetAssets("SPY_TOP_80")
+		tickers, err := handler.GetAssets("SPY_TOP_80", time.Now().UTC())
 		require.NoError(t, err)
 		require.Equal(t, 3, len(tickers))
 	})
@@ -166,7 +169,7 @@ func Test_assetUniverseRepositoryHandler_GetAssets(t *testing.T) {
 
 		tickerSet := map[string]struct{}{}
 
-		tickers, err := handler.GetAssets("ALL")
+		tickers, err := handler.GetAssets("ALL", time.Now().UTC())
 		require.NoError(t, err)
 
 		for _, ticker := range tickers {
@@ -179,3 +182,37 @@ func Test_assetUniverseRepositoryHandler_GetAssets(t *testing.T) {
 		require.Equal(t, 3, len(tickers))
 	})
 }
+
+func TestUniverseMembership(t *testing.T) {
+	date := func(y, m, d int) *time.Time {
+		out := util.NewDate(y, m, d)
+		return &out
+	}
+	aapl := model.Ticker{TickerID: uuid.New(), Symbol: "AAPL"}
+	enron := model.Ticker{TickerID: uuid.New(), Symbol: "ENRN"}
+	meta := model.Ticker{TickerID: uuid.New(), Symbol: "META"}
+
+	membership := UniverseMembership{
+		{Ticker: aapl},
+		{Ticker: enron, EndDate: date(2001, 12, 3)},
+		{Ticker: meta, StartDate: date(2013, 12, 23), EndDate: date(2015, 1, 1)},
+		// rejoined
+		{Ticker: meta, StartDate: date(2016, 1, 1)},
+	}
+
+	symbols := func(tickers []model.Ticker) []string {
+		out := []string{}
+		for _, t := range tickers {
+			out = append(out, t.Symbol)
+		}
+		return out
+	}
+
+	require.Equal(t, []string{"AAPL", "ENRN", "META"}, symbols(membership.Tickers()))
+	require.Equal(t, []string{"AAPL", "ENRN"}, symbols(membership.MembersOn(util.NewDate(2001, 12, 2))))
+	// end date is exclusive
+	require.Equal(t, []string{"AAPL"}, symbols(membership.MembersOn(util.NewDate(2001, 12, 3))))
+	require.Equal(t, []string{"AAPL", "META"}, symbols(membership.MembersOn(util.NewDate(2013, 12, 23))))
+	require.Equal(t, []string{"AAPL"}, symbols(membership.MembersOn(util.NewDate(2015, 6, 1))))
+	require.Equal(t, []string{"AAPL", "META"}, symbols(membership.MembersOn(util.NewDate(2020, 1, 1))))
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"factorbacktest/internal/db/models/postgres/public/model"
+	"factorbacktest/internal/db/models/postgres/public/table"
+
+	"github.com/go-jet/jet/v2/postgres"
+	"github.com/go-jet/jet/v2/qrm"
+)
+
+// CorporateActionRepository stores dividends and splits. adjusted_price
+// already bakes these into price, so they're only needed when working
+// with raw prices
+type CorporateActionRepository interface {
+	Add(tx *sql.Tx, actions []model.CorporateAction) error
+	List(symbols []string, start, end time.Time) ([]model.CorporateAction, error)
+}
+
+type corporateActionRepositoryHandler struct {
+	Db *sql.DB
+}
+
+func NewCorporateActionRepository(db *sql.DB) CorporateActionRepository {
+	return corporateActionRepositoryHandler{Db: db}
+}
+
+func (h corporateActionRepositoryHandler) Add(tx *sql.Tx, actions []model.CorporateAction) error {
+	if len(actions) == 0 {
+		return nil
+	}
+	createdAt := time.Now().UTC()
+	for i := range actions {
+		actions[i].CreatedAt = createdAt
+	}
+
+	t := table.CorporateAction
+	query := t.
+		INSERT(t.MutableColumns).
+		MODELS(actions).
+		ON_CONFLICT(
+			t.Symbol, t.ExDate, t.CorporateActionType,
+		).DO_UPDATE(
+		postgres.SET(
+			t.Amount.SET(t.EXCLUDED.Amount),
+		),
+	)
+
+	var db qrm.Executable = h.Db
+	if tx != nil {
+		db = tx
+	}
+
+	_, err := query.Exec(db)
+	if err != nil {
+		return fmt.Errorf("failed to add corporate actions to db: %w", err)
+	}
+
+	return nil
+}
+
+// List returns every corporate action for the given symbols with an
+// ex-date in [start, end], ordered by ex-date
+func (h corporateActionRepositoryHandler) List(symbols []string, start, end time.Time) ([]model.CorporateAction, error) {
+	if len(symbols) == 0 {
+		return []model.CorporateAction{}, nil
+	}
+	symbolsFilter := []postgres.Expression{}
+	for _, s := range symbols {
+		symbolsFilter = append(symbolsFilter, postgres.String(s))
+	}
+
+	t := table.CorporateAction
+	query := t.
+		SELECT(t.AllColumns).
+		WHERE(
+			postgres.AND(
+				t.Symbol.IN(symbolsFilter...),
+				t.ExDate.BETWEEN(postgres.DateT(start), postgres.DateT(end)),
+			),
+		).
+		ORDER_BY(t.ExDate.ASC())
+
+	result := []model.CorporateAction{}
+	err := query.Query(h.Db, &result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list corporate actions: %w", err)
+	}
+
+	return result, nil
+}
@@ -59,6 +59,27 @@ note for all these functions, negative numbers are not allowed, as that would lo
 - peRatio(strDate date) - price-to-book ratio of the asset on the given day
 - marketCap(strDate date) - market cap of the asset on the given day. if the user wants smaller cap assets, use the reciprocal of this
 - eps(strDate date) - earnings per share of the asset on the given day
+- fundamental(string field, strDate date) - a field from the latest quarterly filing that was public on the given day, e.g. fundamental("NetIncome", currentDate). fields include Revenue, GrossProfit, OperatingIncome, NetIncome, TotalAssets, TotalLiabilities, ShareholderEquity, LongTermDebt, CashOnHand, OperatingCashFlow, ResearchDevelopmentExpense and DividendsPaid
+- ttm(string field, strDate date) - the field summed over the last four quarterly filings public on the given day, e.g. ttm("Revenue", currentDate)
+- yoyGrowth(string field, strDate date) - growth of the field in the latest public quarter vs the same quarter a year earlier, as a fraction, e.g. yoyGrowth("NetIncome", currentDate)
+
+technical indicators. each reads the asset's prices over a window from start to end, and end cannot be after currentDate:
+- sma(strDate start, strDate end) - simple moving average of the price
+- ema(strDate start, strDate end) - exponential moving average of the price
+- rsi(strDate start, strDate end) - relative strength index, from 0 to 100. above 70 is usually read as overbought, below 30 as oversold
+- macd(strDate start, strDate end) - 12 day ema minus 26 day ema at the end of the window. the window needs at least 26 trading days, e.g. nMonthsAgo(3) to currentDate
+- maxDrawdown(strDate start, strDate end) - the largest fall from a peak in the window, as a positive fraction
+- distanceFromHigh(strDate start, strDate end) - how far the price is below the window's high, as a fraction <= 0. the 52-week high is distanceFromHigh(nYearsAgo(1), currentDate)
+- skew(strDate start, strDate end) - skewness of daily returns
+- beta(string benchmark, strDate start, strDate end) - beta of daily returns against the benchmark symbol, e.g. beta("SPY", nYearsAgo(1), currentDate)
+- correlation(string benchmark, strDate start, strDate end) - correlation of daily returns with the benchmark symbol
+
+cross-sectional functions compare the asset to every other asset in the universe on the same day. use them to put factors on the same scale before combining them, e.g. zscore(pricePercentChange(nYearsAgo(1), currentDate)) + zscore(-stdev(nYearsAgo(1), currentDate))
+- zscore(expression) - how many standard deviations the asset's value is from the universe mean
+- rank(expression) - rank of the asset's value in the universe, where 1 is the lowest
+- percentile(expression) - rank scaled from 0 (lowest) to 1 (highest)
+- demean(expression) - the asset's value minus the universe mean
+- winsorize(expression, limit) - clips the value to the universe's limit and 1-limit quantiles. limit is optional and defaults to 0.05
 
 Do not include any explanations, only provide a  RFC8259 compliant JSON response following this format without deviation:
 {
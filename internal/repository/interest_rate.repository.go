@@ -16,6 +16,8 @@ type InterestRateRepository interface {
 	GetRatesOnDate(date time.Time, tx *sql.Tx) (*domain.InterestRateMap, error)
 	GetRatesOnDates(dates []time.Time, tx *sql.Tx) (map[string]domain.InterestRateMap, error)
 	Add(m domain.InterestRateMap, date time.Time, tx *sql.Tx) error
+	// List only reads what's already stored, keyed by date
+	List(start, end time.Time) (map[string]domain.InterestRateMap, error)
 }
 
 type interestRateRepository struct {
@@ -127,6 +129,32 @@ func (r interestRateRepository) GetRatesOnDates(dates []time.Time, tx *sql.Tx) (
 	return out, nil
 }
 
+func (r interestRateRepository) List(start, end time.Time) (map[string]domain.InterestRateMap, error) {
+	query := table.InterestRate.SELECT(table.InterestRate.AllColumns).
+		WHERE(
+			table.InterestRate.Date.BETWEEN(postgres.DateT(start), postgres.DateT(end)),
+		)
+
+	rows := []model.InterestRate{}
+	err := query.Query(r.DB, &rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rates: %w", err)
+	}
+
+	out := map[string]domain.InterestRateMap{}
+	for _, row := range rows {
+		dateStr := row.Date.Format(time.DateOnly)
+		if _, ok := out[dateStr]; !ok {
+			out[dateStr] = domain.InterestRateMap{
+				Rates: map[int]float64{},
+			}
+		}
+		out[dateStr].Rates[int(row.DurationMonths)] = row.InterestRate
+	}
+
+	return out, nil
+}
+
 func (r interestRateRepository) Add(m domain.InterestRateMap, date time.Time, tx *sql.Tx) error {
 	models := []model.InterestRate{}
 	for duration, rate := range m.Rates {
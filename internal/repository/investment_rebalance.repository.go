@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
@@ -17,6 +18,9 @@ type InvestmentRebalanceRepository interface {
 	Add(tx *sql.Tx, ir model.InvestmentRebalance) (*model.InvestmentRebalance, error)
 	Get(tx *sql.Tx, id uuid.UUID) (*model.InvestmentRebalance, error)
 	List(tx *sql.Tx) ([]model.InvestmentRebalance, error)
+	// LatestStartDates returns when each investment last started a
+	// rebalance that didn't error
+	LatestStartDates(tx *sql.Tx) (map[uuid.UUID]time.Time, error)
 }
 
 type investmentRebalanceRepositoryHandler struct {
@@ -86,3 +90,41 @@ func (h investmentRebalanceRepositoryHandler) List(tx *sql.Tx) ([]model.Investme
 
 	return result, nil
 }
+
+func (h investmentRebalanceRepositoryHandler) LatestStartDates(tx *sql.Tx) (map[uuid.UUID]time.Time, error) {
+	t := table.InvestmentRebalance
+	query := t.
+		SELECT(
+			t.InvestmentID,
+			postgres.MAX(t.CreatedAt).AS("latest_created_at"),
+		).
+		WHERE(t.State.NOT_EQ(postgres.NewEnumValue(model.RebalancerRunState_Error.String()))).
+		GROUP_BY(t.InvestmentID)
+
+	var db qrm.Queryable = h.Db
+	if tx != nil {
+		db = tx
+	}
+
+	q, args := query.Sql()
+	rows, err := db.QueryContext(context.Background(), q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest investment rebalances: %w", err)
+	}
+	defer rows.Close()
+
+	out := map[uuid.UUID]time.Time{}
+	for rows.Next() {
+		var investmentID uuid.UUID
+		var createdAt time.Time
+		if err := rows.Scan(&investmentID, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan latest investment rebalance: %w", err)
+		}
+		out[investmentID] = createdAt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read latest investment rebalances: %w", err)
+	}
+
+	return out, nil
+}
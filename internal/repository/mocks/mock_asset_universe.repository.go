@@ -12,7 +12,9 @@ package mock_repository
 import (
 	sql "database/sql"
 	model "factorbacktest/internal/db/models/postgres/public/model"
+	repository "factorbacktest/internal/repository"
 	reflect "reflect"
+	time "time"
 
 	gomock "go.uber.org/mock/gomock"
 )
@@ -54,6 +56,20 @@ func (mr *MockAssetUniverseRepositoryMockRecorder) AddAssets(tx, universe, ticke
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAssets", reflect.TypeOf((*MockAssetUniverseRepository)(nil).AddAssets), tx, universe, tickers)
 }
 
+// ApplyMembershipChanges mocks base method.
+func (m *MockAssetUniverseRepository) ApplyMembershipChanges(tx *sql.Tx, universe model.AssetUniverse, changes []repository.UniverseMembershipChange) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyMembershipChanges", tx, universe, changes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ApplyMembershipChanges indicates an expected call of ApplyMembershipChanges.
+func (mr *MockAssetUniverseRepositoryMockRecorder) ApplyMembershipChanges(tx, universe, changes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyMembershipChanges", reflect.TypeOf((*MockAssetUniverseRepository)(nil).ApplyMembershipChanges), tx, universe, changes)
+}
+
 // GetAssetUniverses mocks base method.
 func (m *MockAssetUniverseRepository) GetAssetUniverses() ([]model.AssetUniverseSize, error) {
 	m.ctrl.T.Helper()
@@ -70,18 +86,33 @@ func (mr *MockAssetUniverseRepositoryMockRecorder) GetAssetUniverses() *gomock.C
 }
 
 // GetAssets mocks base method.
-func (m *MockAssetUniverseRepository) GetAssets(assetUniverseName string) ([]model.Ticker, error) {
+func (m *MockAssetUniverseRepository) GetAssets(assetUniverseName string, asOf time.Time) ([]model.Ticker, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAssets", assetUniverseName)
+	ret := m.ctrl.Call(m, "GetAssets", assetUniverseName, asOf)
 	ret0, _ := ret[0].([]model.Ticker)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAssets indicates an expected call of GetAssets.
-func (mr *MockAssetUniverseRepositoryMockRecorder) GetAssets(assetUniverseName any) *gomock.Call {
+func (mr *MockAssetUniverseRepositoryMockRecorder) GetAssets(assetUniverseName, asOf any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAssets", reflect.TypeOf((*MockAssetUniverseRepository)(nil).GetAssets), assetUniverseName, asOf)
+}
+
+// GetMembership mocks base method.
+func (m *MockAssetUniverseRepository) GetMembership(assetUniverseName string, start, end time.Time) (repository.UniverseMembership, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMembership", assetUniverseName, start, end)
+	ret0, _ := ret[0].(repository.UniverseMembership)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMembership indicates an expected call of GetMembership.
+func (mr *MockAssetUniverseRepositoryMockRecorder) GetMembership(assetUniverseName, start, end any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAssets", reflect.TypeOf((*MockAssetUniverseRepository)(nil).GetAssets), assetUniverseName)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMembership", reflect.TypeOf((*MockAssetUniverseRepository)(nil).GetMembership), assetUniverseName, start, end)
 }
 
 // GetOrCreate mocks base method.
@@ -0,0 +1,71 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/corporate_action.repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=internal/repository/corporate_action.repository.go -destination=internal/repository/mocks/mock_corporate_action.repository.go
+//
+
+// Package mock_repository is a generated GoMock package.
+package mock_repository
+
+import (
+	sql "database/sql"
+	model "factorbacktest/internal/db/models/postgres/public/model"
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockCorporateActionRepository is a mock of CorporateActionRepository interface.
+type MockCorporateActionRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockCorporateActionRepositoryMockRecorder
+}
+
+// MockCorporateActionRepositoryMockRecorder is the mock recorder for MockCorporateActionRepository.
+type MockCorporateActionRepositoryMockRecorder struct {
+	mock *MockCorporateActionRepository
+}
+
+// NewMockCorporateActionRepository creates a new mock instance.
+func NewMockCorporateActionRepository(ctrl *gomock.Controller) *MockCorporateActionRepository {
+	mock := &MockCorporateActionRepository{ctrl: ctrl}
+	mock.recorder = &MockCorporateActionRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCorporateActionRepository) EXPECT() *MockCorporateActionRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Add mocks base method.
+func (m *MockCorporateActionRepository) Add(tx *sql.Tx, actions []model.CorporateAction) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Add", tx, actions)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Add indicates an expected call of Add.
+func (mr *MockCorporateActionRepositoryMockRecorder) Add(tx, actions any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockCorporateActionRepository)(nil).Add), tx, actions)
+}
+
+// List mocks base method.
+func (m *MockCorporateActionRepository) List(symbols []string, start, end time.Time) ([]model.CorporateAction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", symbols, start, end)
+	ret0, _ := ret[0].([]model.CorporateAction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockCorporateActionRepositoryMockRecorder) List(symbols, start, end any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockCorporateActionRepository)(nil).List), symbols, start, end)
+}
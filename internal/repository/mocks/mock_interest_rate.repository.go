@@ -84,3 +84,18 @@ func (mr *MockInterestRateRepositoryMockRecorder) GetRatesOnDates(dates, tx any)
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRatesOnDates", reflect.TypeOf((*MockInterestRateRepository)(nil).GetRatesOnDates), dates, tx)
 }
+
+// List mocks base method.
+func (m *MockInterestRateRepository) List(start, end time.Time) (map[string]domain.InterestRateMap, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", start, end)
+	ret0, _ := ret[0].(map[string]domain.InterestRateMap)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockInterestRateRepositoryMockRecorder) List(start, end any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockInterestRateRepository)(nil).List), start, end)
+}
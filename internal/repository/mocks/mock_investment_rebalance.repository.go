@@ -13,6 +13,7 @@ import (
 	sql "database/sql"
 	model "factorbacktest/internal/db/models/postgres/public/model"
 	reflect "reflect"
+	time "time"
 
 	uuid "github.com/google/uuid"
 	gomock "go.uber.org/mock/gomock"
@@ -71,6 +72,21 @@ func (mr *MockInvestmentRebalanceRepositoryMockRecorder) Get(tx, id any) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockInvestmentRebalanceRepository)(nil).Get), tx, id)
 }
 
+// LatestStartDates mocks base method.
+func (m *MockInvestmentRebalanceRepository) LatestStartDates(tx *sql.Tx) (map[uuid.UUID]time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LatestStartDates", tx)
+	ret0, _ := ret[0].(map[uuid.UUID]time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LatestStartDates indicates an expected call of LatestStartDates.
+func (mr *MockInvestmentRebalanceRepositoryMockRecorder) LatestStartDates(tx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LatestStartDates", reflect.TypeOf((*MockInvestmentRebalanceRepository)(nil).LatestStartDates), tx)
+}
+
 // List mocks base method.
 func (m *MockInvestmentRebalanceRepository) List(tx *sql.Tx) ([]model.InvestmentRebalance, error) {
 	m.ctrl.T.Helper()
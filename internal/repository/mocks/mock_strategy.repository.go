@@ -13,6 +13,7 @@ import (
 	model "factorbacktest/internal/db/models/postgres/public/model"
 	repository "factorbacktest/internal/repository"
 	reflect "reflect"
+	time "time"
 
 	postgres "github.com/go-jet/jet/v2/postgres"
 	uuid "github.com/google/uuid"
@@ -132,6 +133,21 @@ func (mr *MockStrategyRepositoryMockRecorder) List(arg0 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockStrategyRepository)(nil).List), arg0)
 }
 
+// ListUserRuns mocks base method.
+func (m *MockStrategyRepository) ListUserRuns(userAccountID uuid.UUID, assetUniverse string, start, end time.Time) ([]model.StrategyRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserRuns", userAccountID, assetUniverse, start, end)
+	ret0, _ := ret[0].([]model.StrategyRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserRuns indicates an expected call of ListUserRuns.
+func (mr *MockStrategyRepositoryMockRecorder) ListUserRuns(userAccountID, assetUniverse, start, end any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserRuns", reflect.TypeOf((*MockStrategyRepository)(nil).ListUserRuns), userAccountID, assetUniverse, start, end)
+}
+
 // Update mocks base method.
 func (m *MockStrategyRepository) Update(arg0 model.Strategy, arg1 postgres.ColumnList) (*model.Strategy, error) {
 	m.ctrl.T.Helper()
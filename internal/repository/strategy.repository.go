@@ -22,6 +22,9 @@ type StrategyRepository interface {
 
 	AddRun(model.StrategyRun) (*model.StrategyRun, error)
 	GetLatestPublishedRun(strategyID uuid.UUID) (*model.StrategyRun, error)
+	// ListUserRuns returns runs of every strategy the user backtested on
+	// the asset universe over exactly start through end
+	ListUserRuns(userAccountID uuid.UUID, assetUniverse string, start, end time.Time) ([]model.StrategyRun, error)
 }
 
 type strategyRepositoryHandler struct {
@@ -210,3 +213,28 @@ func (h strategyRepositoryHandler) GetLatestPublishedRun(strategyID uuid.UUID) (
 
 	return &out, nil
 }
+
+func (h strategyRepositoryHandler) ListUserRuns(userAccountID uuid.UUID, assetUniverse string, start, end time.Time) ([]model.StrategyRun, error) {
+	t := table.StrategyRun
+	query := postgres.SELECT(t.AllColumns).FROM(
+		t.INNER_JOIN(
+			table.Strategy,
+			table.Strategy.StrategyID.EQ(t.StrategyID),
+		),
+	).WHERE(
+		postgres.AND(
+			table.Strategy.UserAccountID.EQ(postgres.UUID(userAccountID)),
+			table.Strategy.AssetUniverse.EQ(postgres.String(assetUniverse)),
+			t.StartDate.EQ(postgres.DateT(start)),
+			t.EndDate.EQ(postgres.DateT(end)),
+		),
+	)
+
+	out := []model.StrategyRun{}
+	err := query.Query(h.Db, &out)
+	if err != nil && !errors.Is(err, qrm.ErrNoRows) {
+		return nil, fmt.Errorf("failed to list user strategy runs: %w", err)
+	}
+
+	return out, nil
+}
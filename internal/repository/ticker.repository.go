@@ -75,6 +75,9 @@ func (h tickerRepositoryHandler) GetOrCreate(tx *sql.Tx, t model.Ticker) (*model
 		ON_CONFLICT(table.Ticker.Symbol).DO_UPDATE(
 		postgres.SET(
 			table.Ticker.Symbol.SET(table.Ticker.EXCLUDED.Symbol),
+			// fill in the sector if we learned it, but don't
+			// forget one we already knew
+			table.Ticker.Sector.SET(postgres.StringExp(postgres.COALESCE(table.Ticker.EXCLUDED.Sector, table.Ticker.Sector))),
 		),
 	).RETURNING(table.Ticker.AllColumns)
 
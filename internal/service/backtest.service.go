@@ -3,13 +3,16 @@ package service
 import (
 	"context"
 	"database/sql"
+	"factorbacktest/internal"
 	"factorbacktest/internal/calculator"
 	"factorbacktest/internal/data"
 	"factorbacktest/internal/db/models/postgres/public/model"
 	"factorbacktest/internal/domain"
+	"factorbacktest/internal/logger"
 	"factorbacktest/internal/progress"
 	"factorbacktest/internal/repository"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/shopspring/decimal"
@@ -22,15 +25,19 @@ type BacktestHandler struct {
 	PriceRepository         repository.AdjustedPriceRepository
 	AssetUniverseRepository repository.AssetUniverseRepository
 
-	Db                      *sql.DB
-	PriceService            data.PriceService
-	FactorExpressionService calculator.FactorExpressionService
+	Db                        *sql.DB
+	PriceService              data.PriceService
+	FactorExpressionService   calculator.FactorExpressionService
+	CorporateActionRepository repository.CorporateActionRepository
 }
 
 type BacktestResult struct {
-	Date       time.Time
-	Portfolio  domain.Portfolio
-	TotalValue float64
+	// the day the portfolio was actually traded into. this is
+	// ScoringDate unless the backtest has an execution delay
+	Date        time.Time
+	ScoringDate time.Time
+	Portfolio   domain.Portfolio
+	TotalValue  float64
 	// might be less memory to join these in one map, but
 	// it's also cleaner to have these seperated so i don't
 	// need to define another struct for this, and because
@@ -39,6 +46,18 @@ type BacktestResult struct {
 	AssetWeights                 map[string]float64
 	FactorScores                 map[string]float64
 	PriceChangeTilNextResampling map[string]float64
+	// what we paid to rebalance into Portfolio. Portfolio is
+	// what's left after paying them, but TotalValue is from
+	// before, so they show up in the next result's TotalValue
+	TransactionCosts float64
+	// dividends received since the previous rebalance. always 0
+	// when trading on adjusted prices
+	Income float64
+	// what we paid to hold shorts since the previous rebalance
+	BorrowCosts float64
+	// trades from the portfolio as it drifted since the previous
+	// rebalance into Portfolio, sorted by symbol
+	Trades []domain.ProposedTrade
 }
 
 type BacktestSnapshot struct {
@@ -46,7 +65,15 @@ type BacktestSnapshot struct {
 	ValuePercentChange float64                         `json:"valuePercentChange"`
 	Value              float64                         `json:"value"`
 	Date               string                          `json:"date"`
+	ScoringDate        string                          `json:"scoringDate"`
 	AssetMetrics       map[string]SnapshotAssetMetrics `json:"assetMetrics"`
+	TransactionCosts   float64                         `json:"transactionCosts"`
+	BorrowCosts        float64                         `json:"borrowCosts"`
+	// ValuePercentChange split into price and income, both cumulative
+	// and relative to the starting value. on adjusted prices dividends
+	// are baked into the price, so it's all price return
+	PriceReturnPercentChange  float64 `json:"priceReturnPercentChange"`
+	IncomeReturnPercentChange float64 `json:"incomeReturnPercentChange"`
 }
 
 type SnapshotAssetMetrics struct {
@@ -59,18 +86,124 @@ type BacktestInput struct {
 	FactorExpression  string
 	BacktestStart     time.Time
 	BacktestEnd       time.Time
-	RebalanceInterval time.Duration
+	RebalanceSchedule domain.RebalanceSchedule
 	StartingCash      float64
 	NumTickers        int
 	AssetUniverse     string
+	// optional - nil means trading is free
+	TransactionCosts *calculator.TransactionCostModel
+	// number of trading days between computing factor scores
+	// and filling the resulting trades. 0 trades at the close
+	// of the scoring day, which peeks at that day's prices
+	ExecutionDelay int
+	DividendMode   DividendMode
+	// optional - nil is long-only and fully invested
+	LongShort *internal.LongShortOptions
+	// annual cost of borrowing shares to short, in basis
+	// points of short market value
+	ShortBorrowBps float64
+	// optional - splits the results into in-sample and
+	// out-of-sample windows
+	WalkForward *WalkForwardOptions
+	// optional - narrows the universe on each rebalance
+	// date before scoring
+	UniverseFilter *UniverseFilter
+	// optional - nil is unconstrained
+	Constraints *internal.PositionConstraints
+	// zero value is the z-score tilt
+	Weighting internal.WeightingOptions
+}
+
+// DividendMode controls how the backtest accounts for dividends
+type DividendMode string
+
+const (
+	// trade on adjusted prices, which already assume every
+	// dividend is reinvested on the ex-date
+	DividendModeAdjusted DividendMode = ""
+	// trade on raw prices and hold dividends as cash until
+	// the next rebalance
+	DividendModeCash DividendMode = "cash"
+	// trade on raw prices and buy more of the paying stock
+	// with each dividend on the ex-date
+	DividendModeReinvest DividendMode = "reinvest"
+)
+
+func (m DividendMode) UsesRawPrices() bool {
+	return m == DividendModeCash || m == DividendModeReinvest
+}
+
+// strategyBacktestInput is everything a saved strategy was backtested
+// with, so reruns hold the same portfolio. the window and starting cash
+// are left to the caller
+func strategyBacktestInput(ctx context.Context, strategy model.Strategy) (*BacktestInput, error) {
+	schedule, err := domain.ParseRebalanceSchedule(strategy.RebalanceInterval)
+	if err != nil {
+		// strategies saved before schedules were validated can have
+		// anything here, which always meant daily
+		logger.FromContext(ctx).Warnf("strategy %s has unknown rebalance schedule, rebalancing daily: %s", strategy.StrategyID.String(), err.Error())
+		schedule = &domain.RebalanceSchedule{Type: domain.RebalanceScheduleType_Interval, Interval: time.Hour * 24}
+	}
+	longShort, err := internal.ParseLongShortOptions(strategy.LongShort)
+	if err != nil {
+		return nil, err
+	}
+	universeFilter, err := ParseUniverseFilter(strategy.UniverseFilter)
+	if err != nil {
+		return nil, err
+	}
+	constraints, err := internal.ParsePositionConstraints(strategy.PositionConstraints)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &BacktestInput{
+		FactorExpression:  strategy.FactorExpression,
+		RebalanceSchedule: *schedule,
+		NumTickers:        int(strategy.NumAssets),
+		AssetUniverse:     strategy.AssetUniverse,
+		ExecutionDelay:    int(strategy.ExecutionDelayDays),
+		LongShort:         longShort,
+		UniverseFilter:    universeFilter,
+		Constraints:       constraints,
+		Weighting:         internal.StrategyWeighting(strategy),
+	}
+	if strategy.DividendMode != nil {
+		out.DividendMode = DividendMode(*strategy.DividendMode)
+	}
+	if strategy.ShortBorrowBps != nil {
+		out.ShortBorrowBps = *strategy.ShortBorrowBps
+	}
+	return out, nil
 }
 
 type BacktestResponse struct {
-	Results        []BacktestResult
-	Snapshots      map[string]BacktestSnapshot
-	LatestHoldings LatestHoldings
+	Results               []BacktestResult
+	Snapshots             map[string]BacktestSnapshot
+	LatestHoldings        LatestHoldings
+	TotalTransactionCosts float64
+	TotalIncome           float64
+	TotalBorrowCosts      float64
+	// portfolio value at the close of every trading day,
+	// from the first rebalance through the end of the backtest
+	EquityCurve []EquityPoint
+	Turnover    TurnoverReport
+	// only set when the input asked for walk forward windows
+	WalkForward *WalkForwardResult
+	// symbols left in the universe on each rebalance date,
+	// only set when the input has a universe filter
+	UniverseMembership map[time.Time][]string
+}
+
+type EquityPoint struct {
+	Date  time.Time
+	Value float64
 }
 
+// number of trading days used to estimate volatility
+// for the slippage component of transaction costs
+const slippageVolatilityWindow = 21
+
 func (h BacktestHandler) Backtest(ctx context.Context, in BacktestInput) (*BacktestResponse, error) {
 	profile, endProfile := domain.GetProfile(ctx) // used for profiling API performance
 	defer endProfile()
@@ -82,10 +215,15 @@ func (h BacktestHandler) Backtest(ctx context.Context, in BacktestInput) (*Backt
 	// behaves exactly as before.
 	endSetupStep := progress.Step(ctx, "setup", "Loading asset universe & trading days")
 	_, endSpan := profile.StartNewSpan("setting up backtest")
-	tickers, err := h.AssetUniverseRepository.GetAssets(in.AssetUniverse)
+	// everyone who was in the universe at some point during the
+	// backtest, so names that were later removed or delisted still
+	// get picked on the days they were members
+	membership, err := h.AssetUniverseRepository.GetMembership(in.AssetUniverse, in.BacktestStart, in.BacktestEnd)
 	if err != nil {
 		return nil, err
-	} else if len(tickers) == 0 {
+	}
+	tickers := membership.Tickers()
+	if len(tickers) == 0 {
 		return nil, fmt.Errorf("no tickers found")
 	}
 	universeSymbols := []string{}
@@ -96,32 +234,175 @@ func (h BacktestHandler) Backtest(ctx context.Context, in BacktestInput) (*Backt
 	// all trading days within the selected window that we need to run a calculation on
 	// this will only contain days that we actually have data for, so if data is old, it
 	// will not include recent days
-	tradingDays, err := h.calculateRelevantTradingDays(in.BacktestStart, in.BacktestEnd, in.RebalanceInterval)
+	tradingDays, err := h.calculateRelevantTradingDays(in.BacktestStart, in.BacktestEnd, in.RebalanceSchedule)
 	if err != nil {
 		return nil, err
 	}
 	if len(tradingDays) == 0 {
 		return nil, fmt.Errorf("failed to backtest: no calculated trading days in given range")
 	}
+	fillDates, err := h.calculateFillDates(tradingDays, in.BacktestEnd, in.ExecutionDelay)
+	if err != nil {
+		return nil, err
+	}
+	if len(fillDates) == 0 {
+		return nil, fmt.Errorf("failed to backtest: execution delay of %d days leaves no trades in given range", in.ExecutionDelay)
+	}
 
 	endSpan()
 	endSetupStep()
 
+	members := membersByDay(membership, tradingDays)
+	if in.UniverseFilter != nil {
+		endFilterStep := progress.Step(ctx, "universe_filter", "Filtering asset universe")
+		span, endSpan := profile.StartNewSpan("filtering universe")
+		members, err = h.applyUniverseFilter(domain.NewCtxWithSubProfile(ctx, span), tradingDays, members, *in.UniverseFilter)
+		if err != nil {
+			return nil, err
+		}
+		endSpan()
+		endFilterStep()
+	}
+
 	endFactorScoresStep := progress.Step(ctx, "factor_scores", "Calculating factor scores")
 	span, endSpan := profile.StartNewSpan("calculating factor scores")
-	factorScoresByDay, priceCache, err := h.FactorExpressionService.CalculateFactorScoresWithCache(domain.NewCtxWithSubProfile(ctx, span), tradingDays, tickers, in.FactorExpression)
+	factorScoresByDay, priceCache, err := h.FactorExpressionService.CalculateFactorScoresForMembers(domain.NewCtxWithSubProfile(ctx, span), tradingDays, members, in.FactorExpression)
 	if err != nil {
 		return nil, fmt.Errorf("failed to calculate factor scores: %w", err)
 	}
 	endSpan()
 	endFactorScoresStep()
 
+	weightingDataByDay, err := h.weightingData(ctx, tradingDays, members, in.Weighting)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := h.simulate(ctx, profile, in, backtestScores{
+		tickers:            tickers,
+		universeSymbols:    universeSymbols,
+		scoresByDay:        factorScoresByDay,
+		priceCache:         priceCache,
+		weightingDataByDay: weightingDataByDay,
+	}, tradingDays, fillDates)
+	if err != nil {
+		return nil, err
+	}
+
+	endLatestHoldingsStep := progress.Step(ctx, "latest_holdings", "Resolving latest holdings")
+	latestHoldings, err := getLatestHoldings(ctx, h, in)
+	if err != nil {
+		return nil, err
+	}
+	endLatestHoldingsStep()
+	resp.LatestHoldings = *latestHoldings
+
+	if in.UniverseFilter != nil {
+		resp.UniverseMembership = map[time.Time][]string{}
+		for day, tickers := range members {
+			symbols := []string{}
+			for _, t := range tickers {
+				symbols = append(symbols, t.Symbol)
+			}
+			resp.UniverseMembership[day] = symbols
+		}
+	}
+
+	return resp, nil
+}
+
+// membersByDay lists who was in the universe on each day
+func membersByDay(membership repository.UniverseMembership, days []time.Time) map[time.Time][]model.Ticker {
+	out := map[time.Time][]model.Ticker{}
+	for _, d := range days {
+		out[d] = membership.MembersOn(d)
+	}
+	return out
+}
+
+// backtestScores is everything a simulation needs that doesn't depend on
+// how the portfolio is built, so it can be shared across simulations
+type backtestScores struct {
+	tickers         []model.Ticker
+	universeSymbols []string
+	scoresByDay     map[time.Time]*calculator.ScoresResultsOnDay
+	priceCache      *data.PriceCache
+	// nil unless the weighting scheme needs data
+	weightingDataByDay map[time.Time]*calculator.ScoresResultsOnDay
+}
+
+// weightingData scores whatever the weighting scheme weights by, over
+// the same members as the factor scores. nil when it doesn't need
+// anything beyond factor scores
+func (h BacktestHandler) weightingData(
+	ctx context.Context,
+	tradingDays []time.Time,
+	tickersByDay map[time.Time][]model.Ticker,
+	weighting internal.WeightingOptions,
+) (map[time.Time]*calculator.ScoresResultsOnDay, error) {
+	expression := weighting.Scheme.DataExpression()
+	if expression == "" {
+		return nil, nil
+	}
+
+	profile, endProfile := domain.GetProfile(ctx)
+	defer endProfile()
+	span, endSpan := profile.StartNewSpan(fmt.Sprintf("calculating %s weighting data", weighting.Scheme))
+	defer endSpan()
+
+	out, _, err := h.FactorExpressionService.CalculateFactorScoresForMembers(domain.NewCtxWithSubProfile(ctx, span), tradingDays, tickersByDay, expression)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate %s weighting data: %w", weighting.Scheme, err)
+	}
+	return out, nil
+}
+
+// simulate runs the portfolio through tradingDays, which must all have
+// scores. it doesn't resolve latest holdings
+func (h BacktestHandler) simulate(
+	ctx context.Context,
+	profile *domain.Profile,
+	in BacktestInput,
+	scores backtestScores,
+	tradingDays []time.Time,
+	fillDates map[time.Time]time.Time,
+) (*BacktestResponse, error) {
+	universeSymbols := scores.universeSymbols
+	factorScoresByDay := scores.scoresByDay
+	priceCache := scores.priceCache
+	sectorBySymbol := internal.SectorBySymbol(scores.tickers)
+
+	// every daily price in the backtest, for marking to market. volatility
+	// based slippage also needs history up front, to estimate volatility
+	// on the first rebalance
+	usesVolatility := in.TransactionCosts != nil && in.TransactionCosts.UsesVolatility()
+	historyStart := tradingDays[0]
+	if usesVolatility {
+		historyStart = historyStart.AddDate(0, 0, -2*slippageVolatilityWindow)
+	}
+	priceHistory, err := h.loadPriceHistory(universeSymbols, historyStart, in.BacktestEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load price history: %w", err)
+	}
+	sim, err := h.newDailySimulation(universeSymbols, priceHistory, tradingDays[0], in.BacktestEnd, in)
+	if err != nil {
+		return nil, err
+	}
+
 	startValue := decimal.NewFromFloat(in.StartingCash)
 
 	currentPortfolio := domain.NewPortfolio()
 	currentPortfolio.SetCash(startValue)
 
 	out := []BacktestResult{}
+	totalTransactionCosts := decimal.Zero
+	totalIncome := decimal.Zero
+	totalBorrowCosts := decimal.Zero
+	equityCurve := []EquityPoint{}
+	// income and borrow costs since the last result, which
+	// carry over days that don't end up rebalancing
+	periodIncome := decimal.Zero
+	periodBorrowCosts := decimal.Zero
 
 	const errThreshold = 0.1
 	backtestErrors := []error{}
@@ -130,6 +411,11 @@ func (h BacktestHandler) Backtest(ctx context.Context, in BacktestInput) (*Backt
 
 	endSimulateStep := progress.Step(ctx, "simulate", "Running portfolio simulation")
 	for _, t := range tradingDays {
+		fillDate, ok := fillDates[t]
+		if !ok {
+			// trades would fill after the backtest ends
+			continue
+		}
 		iterSpan, endIter := profile.StartNewSpan(fmt.Sprintf("daily iter %s", t.Format("2006-01-02")))
 		iterProfile, endIterProfile := iterSpan.NewSubProfile()
 
@@ -145,21 +431,39 @@ func (h BacktestHandler) Backtest(ctx context.Context, in BacktestInput) (*Backt
 		// Reuse the price cache built upstream by CalculateFactorScoresWithCache
 		// instead of hitting the db once per rebalance day. Falls back to the
 		// repository for any symbol the cache doesn't have.
-		pm, err := priceCache.GetManyOnDay(ctx, universeSymbols, t)
+		pm, err := priceCache.GetManyOnDay(ctx, universeSymbols, fillDate)
 		endPriceSpan()
 		if err != nil {
 			endIterProfile()
 			endIter()
-			return nil, fmt.Errorf("failed to get prices on day %v: %w", t, err)
+			return nil, fmt.Errorf("failed to get prices on day %v: %w", fillDate, err)
+		}
+		priceMap[fillDate.Format(time.DateOnly)] = pm
+
+		// catch the portfolio up on every day since the last rebalance.
+		// on raw prices, we trade on raw prices too, but snapshots keep
+		// using adjusted prices for price changes
+		period, err := sim.advance(currentPortfolio, fillDate)
+		if err != nil {
+			endIterProfile()
+			endIter()
+			return nil, err
+		}
+		equityCurve = append(equityCurve, period.Equity...)
+		periodIncome = periodIncome.Add(period.Income)
+		periodBorrowCosts = periodBorrowCosts.Add(period.BorrowCosts)
+		totalIncome = totalIncome.Add(period.Income)
+		totalBorrowCosts = totalBorrowCosts.Add(period.BorrowCosts)
+		if sim.useRawPrices {
+			pm = sim.pricesOnDay(universeSymbols, fillDate)
 		}
-		priceMap[t.Format(time.DateOnly)] = pm
 
 		currentPortfolioValue, err := currentPortfolio.TotalValue(pm)
 		endTotalVal()
 		if err != nil {
 			endIterProfile()
 			endIter()
-			return nil, fmt.Errorf("failed to calculate portfolio value on %v: %w", t, err)
+			return nil, fmt.Errorf("failed to calculate portfolio value on %v: %w", fillDate, err)
 		}
 
 		valuesFromDay, ok := factorScoresByDay[t]
@@ -169,13 +473,57 @@ func (h BacktestHandler) Backtest(ctx context.Context, in BacktestInput) (*Backt
 			return nil, fmt.Errorf("failed to retrieve factor score data from %s", t.Format(time.DateOnly))
 		}
 
-		computeTargetPortfolioResponse, err := calculator.ComputeTargetPortfolio(calculator.ComputeTargetPortfolioInput{
-			Date:             t,
-			TargetNumTickers: in.NumTickers,
-			FactorScores:     valuesFromDay.SymbolScores,
-			PortfolioValue:   currentPortfolioValue,
-			PriceMap:         pm,
-		})
+		var weightingData map[string]*float64
+		if d, ok := scores.weightingDataByDay[t]; ok {
+			weightingData = d.SymbolScores
+		}
+		computeTarget := func(portfolioValue decimal.Decimal) (*calculator.ComputeTargetPortfolioResponse, error) {
+			return calculator.ComputeTargetPortfolio(calculator.ComputeTargetPortfolioInput{
+				Date:             fillDate,
+				TargetNumTickers: in.NumTickers,
+				FactorScores:     valuesFromDay.SymbolScores,
+				PortfolioValue:   portfolioValue,
+				PriceMap:         pm,
+				LongShort:        in.LongShort,
+				Constraints:      in.Constraints,
+				SectorBySymbol:   sectorBySymbol,
+				Weighting:        in.Weighting,
+				WeightingData:    weightingData,
+			})
+		}
+		// drift schedules score every day, but only trade once
+		// the portfolio has wandered far enough from target
+		if in.RebalanceSchedule.Type == domain.RebalanceScheduleType_Drift && len(out) > 0 {
+			withinBand, err := withinDriftBand(*currentPortfolio, currentPortfolioValue, pm, in.RebalanceSchedule.DriftThreshold, computeTarget)
+			if err != nil || withinBand {
+				if err != nil {
+					backtestErrors = append(backtestErrors, err)
+				}
+				endCompute()
+				endIterProfile()
+				endIter()
+				continue
+			}
+		}
+
+		var computeTargetPortfolioResponse *calculator.ComputeTargetPortfolioResponse
+		transactionCosts := decimal.Zero
+		if in.TransactionCosts != nil {
+			var volatility map[string]float64
+			if usesVolatility {
+				volatility = volatilityOnDay(priceHistory, fillDate)
+			}
+			computeTargetPortfolioResponse, transactionCosts, err = rebalanceWithTransactionCosts(
+				*in.TransactionCosts,
+				*currentPortfolio,
+				currentPortfolioValue,
+				pm,
+				volatility,
+				computeTarget,
+			)
+		} else {
+			computeTargetPortfolioResponse, err = computeTarget(currentPortfolioValue)
+		}
 		endCompute()
 		if err != nil {
 			backtestErrors = append(backtestErrors, err)
@@ -184,17 +532,41 @@ func (h BacktestHandler) Backtest(ctx context.Context, in BacktestInput) (*Backt
 			continue
 		}
 
+		trades := []domain.ProposedTrade{}
+		for _, trade := range currentPortfolio.TradesToTarget(*computeTargetPortfolioResponse.TargetPortfolio, pm) {
+			trades = append(trades, *trade)
+		}
+		sort.Slice(trades, func(i, j int) bool {
+			return trades[i].Symbol < trades[j].Symbol
+		})
+
 		out = append(out, BacktestResult{
-			Date:         t,
-			Portfolio:    *computeTargetPortfolioResponse.TargetPortfolio,
-			TotalValue:   currentPortfolioValue.InexactFloat64(),
-			AssetWeights: computeTargetPortfolioResponse.AssetWeights,
-			FactorScores: computeTargetPortfolioResponse.FactorScores,
+			Date:             fillDate,
+			ScoringDate:      t,
+			Portfolio:        *computeTargetPortfolioResponse.TargetPortfolio,
+			TotalValue:       currentPortfolioValue.InexactFloat64(),
+			AssetWeights:     computeTargetPortfolioResponse.AssetWeights,
+			FactorScores:     computeTargetPortfolioResponse.FactorScores,
+			TransactionCosts: transactionCosts.InexactFloat64(),
+			Income:           periodIncome.InexactFloat64(),
+			BorrowCosts:      periodBorrowCosts.InexactFloat64(),
+			Trades:           trades,
 		})
+		periodIncome = decimal.Zero
+		periodBorrowCosts = decimal.Zero
+		totalTransactionCosts = totalTransactionCosts.Add(transactionCosts)
 		currentPortfolio = computeTargetPortfolioResponse.TargetPortfolio.DeepCopy()
 		endIterProfile()
 		endIter()
 	}
+
+	period, err := sim.finish(currentPortfolio, in.BacktestEnd)
+	if err != nil {
+		return nil, err
+	}
+	equityCurve = append(equityCurve, period.Equity...)
+	totalIncome = totalIncome.Add(period.Income)
+	totalBorrowCosts = totalBorrowCosts.Add(period.BorrowCosts)
 	endSimulateStep()
 
 	if float64(len(backtestErrors))/float64(len(tradingDays)) >= errThreshold {
@@ -209,7 +581,7 @@ func (h BacktestHandler) Backtest(ctx context.Context, in BacktestInput) (*Backt
 	// need to be moved out
 
 	endSnapshotsStep := progress.Step(ctx, "snapshots", "Generating snapshots")
-	_, endSpan = profile.StartNewSpan("creating snapshots")
+	_, endSpan := profile.StartNewSpan("creating snapshots")
 	snapshots, err := toSnapshots(out, priceMap)
 	if err != nil {
 		return nil, fmt.Errorf("failed to compute snapshots: %w", err)
@@ -217,31 +589,368 @@ func (h BacktestHandler) Backtest(ctx context.Context, in BacktestInput) (*Backt
 	endSpan()
 	endSnapshotsStep()
 
-	endLatestHoldingsStep := progress.Step(ctx, "latest_holdings", "Resolving latest holdings")
-	latestHoldings, err := getLatestHoldings(ctx, h, universeSymbols, tickers, in)
+	var walkForward *WalkForwardResult
+	if in.WalkForward != nil {
+		walkForward, err = splitWalkForward(equityCurve, in.BacktestStart, in.BacktestEnd, *in.WalkForward)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &BacktestResponse{
+		Results:               out,
+		Snapshots:             snapshots,
+		TotalTransactionCosts: totalTransactionCosts.InexactFloat64(),
+		TotalIncome:           totalIncome.InexactFloat64(),
+		TotalBorrowCosts:      totalBorrowCosts.InexactFloat64(),
+		EquityCurve:           equityCurve,
+		Turnover:              calculateTurnover(out, in.BacktestEnd),
+		WalkForward:           walkForward,
+	}, nil
+}
+
+// rebalanceWithTransactionCosts computes the target portfolio, then charges
+// the cost of trading into it. the target is re-sized so the portfolio pays
+// for its own trades; since the smaller target usually trades slightly less
+// than estimated, the difference is left in cash
+func rebalanceWithTransactionCosts(
+	costModel calculator.TransactionCostModel,
+	currentPortfolio domain.Portfolio,
+	portfolioValue decimal.Decimal,
+	pm map[string]decimal.Decimal,
+	volatilityBySymbol map[string]float64,
+	computeTarget func(portfolioValue decimal.Decimal) (*calculator.ComputeTargetPortfolioResponse, error),
+) (*calculator.ComputeTargetPortfolioResponse, decimal.Decimal, error) {
+	target, err := computeTarget(portfolioValue)
+	if err != nil {
+		return nil, decimal.Zero, err
+	}
+	estimatedCost := costModel.TotalCost(currentPortfolio.TradesToTarget(*target.TargetPortfolio, pm), volatilityBySymbol)
+	if estimatedCost.IsZero() {
+		return target, decimal.Zero, nil
+	}
+
+	target, err = computeTarget(portfolioValue.Sub(estimatedCost))
+	if err != nil {
+		return nil, decimal.Zero, err
+	}
+	cost := costModel.TotalCost(currentPortfolio.TradesToTarget(*target.TargetPortfolio, pm), volatilityBySymbol)
+	target.TargetPortfolio.SetCash(target.TargetPortfolio.Cash.Add(estimatedCost.Sub(cost)))
+
+	return target, cost, nil
+}
+
+// withinDriftBand checks whether every current weight is within
+// threshold of the target the portfolio would rebalance into
+func withinDriftBand(
+	currentPortfolio domain.Portfolio,
+	portfolioValue decimal.Decimal,
+	pm map[string]decimal.Decimal,
+	threshold float64,
+	computeTarget func(portfolioValue decimal.Decimal) (*calculator.ComputeTargetPortfolioResponse, error),
+) (bool, error) {
+	target, err := computeTarget(portfolioValue)
+	if err != nil {
+		return false, err
+	}
+	drift, err := currentPortfolio.MaxWeightDrift(target.AssetWeights, pm)
+	if err != nil {
+		return false, err
+	}
+	return drift <= threshold, nil
+}
+
+// shortBorrowCost charges the annual borrow rate on the market value
+// of every short position, pro-rated over the holding period
+func shortBorrowCost(p domain.Portfolio, pm map[string]decimal.Decimal, borrowBps float64, held time.Duration) decimal.Decimal {
+	shortValue := decimal.Zero
+	for symbol, position := range p.Positions {
+		if position.ExactQuantity.IsNegative() {
+			shortValue = shortValue.Add(position.ExactQuantity.Abs().Mul(pm[symbol]))
+		}
+	}
+	yearsHeld := held.Hours() / (365 * 24)
+	return shortValue.Mul(decimal.NewFromFloat(borrowBps / 10000 * yearsHeld))
+}
+
+// loadPriceHistory gets every daily price for the given symbols, grouped
+// by symbol and sorted by date
+func (h BacktestHandler) loadPriceHistory(symbols []string, start, end time.Time) (map[string][]domain.AssetPrice, error) {
+	prices, err := h.PriceRepository.List(symbols, start, end)
 	if err != nil {
 		return nil, err
 	}
-	endLatestHoldingsStep()
+	out := map[string][]domain.AssetPrice{}
+	for _, p := range prices {
+		out[p.Symbol] = append(out[p.Symbol], p)
+	}
+	for symbol := range out {
+		sort.Slice(out[symbol], func(i, j int) bool {
+			return out[symbol][i].Date.Before(out[symbol][j].Date)
+		})
+	}
+	return out, nil
+}
 
-	return &BacktestResponse{
-		Results:        out,
-		Snapshots:      snapshots,
-		LatestHoldings: *latestHoldings,
+// dailySimulation steps a portfolio through every trading day between
+// rebalances, marking it to market and charging anything that accrues
+// daily. on raw prices, it also applies dividends and splits as they go ex
+type dailySimulation struct {
+	useRawPrices bool
+	dividendMode DividendMode
+	borrowBps    float64
+	// every trading day in the backtest, ascending
+	tradingDays []time.Time
+	// sorted by date. on raw prices, only includes prices
+	// that have a raw price
+	prices       map[string][]domain.AssetPrice
+	actionsByDay map[string][]model.CorporateAction
+
+	lastDay *time.Time
+	// rebalance days are marked after trading, so the
+	// curve reflects what we actually held at the close
+	pendingMark *time.Time
+}
+
+type simulatedPeriod struct {
+	Income      decimal.Decimal
+	BorrowCosts decimal.Decimal
+	Equity      []EquityPoint
+}
+
+func (h BacktestHandler) newDailySimulation(
+	symbols []string,
+	priceHistory map[string][]domain.AssetPrice,
+	start, end time.Time,
+	in BacktestInput,
+) (*dailySimulation, error) {
+	tradingDays, err := h.PriceRepository.ListTradingDays(start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trading days: %w", err)
+	}
+	sort.Slice(tradingDays, func(i, j int) bool {
+		return tradingDays[i].Before(tradingDays[j])
+	})
+
+	sim := &dailySimulation{
+		useRawPrices: in.DividendMode.UsesRawPrices(),
+		dividendMode: in.DividendMode,
+		borrowBps:    in.ShortBorrowBps,
+		tradingDays:  tradingDays,
+		prices:       priceHistory,
+		actionsByDay: map[string][]model.CorporateAction{},
+	}
+	if !sim.useRawPrices {
+		return sim, nil
+	}
+
+	if h.CorporateActionRepository == nil {
+		return nil, fmt.Errorf("dividend mode %s requires corporate action data, which isn't configured", in.DividendMode)
+	}
+
+	rawPrices := map[string][]domain.AssetPrice{}
+	for symbol, history := range priceHistory {
+		for _, p := range history {
+			if p.RawPrice != nil {
+				rawPrices[symbol] = append(rawPrices[symbol], p)
+			}
+		}
+	}
+	if len(rawPrices) == 0 {
+		return nil, fmt.Errorf("no raw prices found for universe - prices need to be re-ingested to use dividend mode %s", in.DividendMode)
+	}
+	sim.prices = rawPrices
+
+	actions, err := h.CorporateActionRepository.List(symbols, start, end)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range actions {
+		key := a.ExDate.Format(time.DateOnly)
+		sim.actionsByDay[key] = append(sim.actionsByDay[key], a)
+	}
+
+	return sim, nil
+}
+
+// pricesOnDay returns the most recent price on or before
+// date for each symbol. symbols without one are left out
+func (s dailySimulation) pricesOnDay(symbols []string, date time.Time) map[string]decimal.Decimal {
+	out := map[string]decimal.Decimal{}
+	for _, symbol := range symbols {
+		history := s.prices[symbol]
+		i := sort.Search(len(history), func(i int) bool {
+			return history[i].Date.After(date)
+		})
+		if i > 0 {
+			if s.useRawPrices {
+				out[symbol] = *history[i-1].RawPrice
+			} else {
+				out[symbol] = history[i-1].Price
+			}
+		}
+	}
+	return out
+}
+
+// advance walks every trading day after the last one it saw, through the
+// rebalance day, applying whatever accrues to p and marking it to market.
+// the rebalance day itself is marked on the next call, after trading
+func (s *dailySimulation) advance(p *domain.Portfolio, rebalanceDay time.Time) (*simulatedPeriod, error) {
+	period, err := s.walk(p, rebalanceDay)
+	if err != nil {
+		return nil, err
+	}
+	if s.lastDay == nil || rebalanceDay.After(*s.lastDay) {
+		income, borrowCosts := s.step(p, rebalanceDay)
+		period.Income = period.Income.Add(income)
+		period.BorrowCosts = period.BorrowCosts.Add(borrowCosts)
+	}
+	s.pendingMark = &rebalanceDay
+
+	return period, nil
+}
+
+// finish walks through the end of the backtest, so the curve
+// covers the time after the last rebalance
+func (s *dailySimulation) finish(p *domain.Portfolio, end time.Time) (*simulatedPeriod, error) {
+	return s.walk(p, end.AddDate(0, 0, 1))
+}
+
+// walk steps and marks every trading day after the last one
+// it saw, up to but not including until
+func (s *dailySimulation) walk(p *domain.Portfolio, until time.Time) (*simulatedPeriod, error) {
+	period := &simulatedPeriod{
+		Income:      decimal.Zero,
+		BorrowCosts: decimal.Zero,
+		Equity:      []EquityPoint{},
+	}
+	if s.pendingMark != nil {
+		point, err := s.mark(p, *s.pendingMark)
+		if err != nil {
+			return nil, err
+		}
+		period.Equity = append(period.Equity, *point)
+		s.pendingMark = nil
+	}
+	if s.lastDay == nil {
+		return period, nil
+	}
+
+	for _, t := range s.tradingDays {
+		if !t.Before(until) {
+			break
+		}
+		if !t.After(*s.lastDay) {
+			continue
+		}
+		income, borrowCosts := s.step(p, t)
+		period.Income = period.Income.Add(income)
+		period.BorrowCosts = period.BorrowCosts.Add(borrowCosts)
+
+		point, err := s.mark(p, t)
+		if err != nil {
+			return nil, err
+		}
+		period.Equity = append(period.Equity, *point)
+	}
+
+	return period, nil
+}
+
+// step applies a single day's corporate actions and short borrow
+// costs to p, and returns the income and borrow costs
+func (s *dailySimulation) step(p *domain.Portfolio, t time.Time) (decimal.Decimal, decimal.Decimal) {
+	pm := s.pricesOnDay(p.HeldSymbols(), t)
+
+	borrowCosts := decimal.Zero
+	if s.lastDay != nil && s.borrowBps > 0 {
+		borrowCosts = shortBorrowCost(*p, pm, s.borrowBps, t.Sub(*s.lastDay))
+		p.SetCash(p.Cash.Sub(borrowCosts))
+	}
+
+	income := decimal.Zero
+	if s.useRawPrices {
+		income = applyCorporateActions(p, s.actionsByDay[t.Format(time.DateOnly)], s.dividendMode, pm)
+	}
+	s.lastDay = &t
+
+	return income, borrowCosts
+}
+
+func (s dailySimulation) mark(p *domain.Portfolio, t time.Time) (*EquityPoint, error) {
+	value, err := p.TotalValue(s.pricesOnDay(p.HeldSymbols(), t))
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark portfolio to market on %s: %w", t.Format(time.DateOnly), err)
+	}
+	return &EquityPoint{
+		Date:  t,
+		Value: value.InexactFloat64(),
 	}, nil
 }
 
+// applyCorporateActions adjusts the portfolio for dividends and splits
+// going ex on a single day, and returns the dividend income. reinvested
+// dividends are bought at pm, and fall back to cash if there's no price
+func applyCorporateActions(p *domain.Portfolio, actions []model.CorporateAction, mode DividendMode, pm map[string]decimal.Decimal) decimal.Decimal {
+	income := decimal.Zero
+	for _, action := range actions {
+		position, ok := p.Positions[action.Symbol]
+		if !ok || position.ExactQuantity.IsZero() {
+			continue
+		}
+		switch action.CorporateActionType {
+		case model.CorporateActionType_Split:
+			position.ExactQuantity = position.ExactQuantity.Mul(action.Amount)
+		case model.CorporateActionType_Dividend:
+			amount := position.ExactQuantity.Mul(action.Amount)
+			income = income.Add(amount)
+			price, ok := pm[action.Symbol]
+			if mode == DividendModeReinvest && ok && price.IsPositive() {
+				position.ExactQuantity = position.ExactQuantity.Add(amount.Div(price))
+			} else {
+				p.SetCash(p.Cash.Add(amount))
+			}
+		}
+	}
+	return income
+}
+
+func volatilityOnDay(priceHistory map[string][]domain.AssetPrice, date time.Time) map[string]float64 {
+	out := map[string]float64{}
+	for symbol, prices := range priceHistory {
+		out[symbol] = calculator.TrailingDailyVolatility(prices, date, slippageVolatilityWindow)
+	}
+	return out
+}
+
 type LatestHoldings struct {
 	Date   time.Time
 	Assets map[string]SnapshotAssetMetrics
 }
 
-func getLatestHoldings(ctx context.Context, h BacktestHandler, universeSymbols []string, tickers []model.Ticker, in BacktestInput) (*LatestHoldings, error) {
+func getLatestHoldings(ctx context.Context, h BacktestHandler, in BacktestInput) (*LatestHoldings, error) {
 	latestTradingDay, err := h.PriceRepository.LatestTradingDay()
 	if err != nil {
 		return nil, err
 	}
 
+	tickers, err := h.AssetUniverseRepository.GetAssets(in.AssetUniverse, *latestTradingDay)
+	if err != nil {
+		return nil, err
+	}
+	if in.UniverseFilter != nil {
+		members, err := h.applyUniverseFilter(ctx, []time.Time{*latestTradingDay}, map[time.Time][]model.Ticker{*latestTradingDay: tickers}, *in.UniverseFilter)
+		if err != nil {
+			return nil, err
+		}
+		tickers = members[*latestTradingDay]
+	}
+	universeSymbols := []string{}
+	for _, t := range tickers {
+		universeSymbols = append(universeSymbols, t.Symbol)
+	}
+
 	pm, err := h.PriceRepository.GetManyOnDay(universeSymbols, *latestTradingDay)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get prices on day %v: %w", latestTradingDay, err)
@@ -251,12 +960,25 @@ func getLatestHoldings(ctx context.Context, h BacktestHandler, universeSymbols [
 		return nil, fmt.Errorf("failed to calculate factor scores: %w", err)
 	}
 	scoreResults := factorScoresOnLatestDay[*latestTradingDay]
+	var weightingData map[string]*float64
+	weightingDataByDay, err := h.weightingData(ctx, []time.Time{*latestTradingDay}, map[time.Time][]model.Ticker{*latestTradingDay: tickers}, in.Weighting)
+	if err != nil {
+		return nil, err
+	}
+	if d, ok := weightingDataByDay[*latestTradingDay]; ok {
+		weightingData = d.SymbolScores
+	}
 	computeTargetPortfolioResponse, err := calculator.ComputeTargetPortfolio(calculator.ComputeTargetPortfolioInput{
 		Date:             *latestTradingDay,
 		TargetNumTickers: in.NumTickers,
 		FactorScores:     scoreResults.SymbolScores,
 		PortfolioValue:   decimal.NewFromInt(1000),
 		PriceMap:         pm,
+		LongShort:        in.LongShort,
+		Constraints:      in.Constraints,
+		SectorBySymbol:   internal.SectorBySymbol(tickers),
+		Weighting:        in.Weighting,
+		WeightingData:    weightingData,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to calculate target portfolio")
@@ -280,10 +1002,14 @@ func getLatestHoldings(ctx context.Context, h BacktestHandler, universeSymbols [
 func toSnapshots(result []BacktestResult, priceMap map[string]map[string]decimal.Decimal) (map[string]BacktestSnapshot, error) {
 	snapshots := map[string]BacktestSnapshot{}
 
+	cumulativeIncome := 0.0
 	for i, r := range result {
 		pc := 0.0
+		incomePc := 0.0
 		if i != 0 {
 			pc = 100 * (r.TotalValue - result[0].TotalValue) / result[0].TotalValue
+			cumulativeIncome += r.Income
+			incomePc = 100 * cumulativeIncome / result[0].TotalValue
 		}
 		priceChangeTilNextResampling := map[string]float64{}
 
@@ -306,7 +1032,13 @@ func toSnapshots(result []BacktestResult, priceMap map[string]map[string]decimal
 			ValuePercentChange: pc,
 			Value:              r.TotalValue,
 			Date:               r.Date.Format(time.DateOnly),
+			ScoringDate:        r.ScoringDate.Format(time.DateOnly),
 			AssetMetrics:       joinAssetMetrics(r.AssetWeights, r.FactorScores, priceChangeTilNextResampling),
+			TransactionCosts:   r.TransactionCosts,
+			BorrowCosts:        r.BorrowCosts,
+
+			PriceReturnPercentChange:  pc - incomePc,
+			IncomeReturnPercentChange: incomePc,
 		}
 	}
 
@@ -347,33 +1079,58 @@ func joinAssetMetrics(
 	return out
 }
 
-func (h BacktestHandler) calculateRelevantTradingDays(
-	start, end time.Time,
-	interval time.Duration,
-) ([]time.Time, error) {
-	allTradingDays, err := h.PriceRepository.ListTradingDays(start, end)
-	if err != nil {
-		return nil, fmt.Errorf("failed to calculate trading days: %w", err)
+// calculateFillDates maps each scoring date to the trading day its trades
+// fill on, `delay` trading days later. scoring dates that would fill after
+// end are left out
+func (h BacktestHandler) calculateFillDates(scoringDays []time.Time, end time.Time, delay int) (map[time.Time]time.Time, error) {
+	if delay < 0 {
+		return nil, fmt.Errorf("execution delay cannot be negative, got %d", delay)
 	}
-	if len(allTradingDays) == 0 {
-		return []time.Time{}, nil
+	out := map[time.Time]time.Time{}
+	if delay == 0 {
+		for _, t := range scoringDays {
+			out[t] = t
+		}
+		return out, nil
 	}
 
-	allTradingDaysSet := map[time.Time]bool{}
-	for _, t := range allTradingDays {
-		allTradingDaysSet[t] = true
+	allTradingDays, err := h.PriceRepository.ListTradingDays(scoringDays[0], end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate fill dates: %w", err)
 	}
 
-	tradingDays := []time.Time{}
-	currentTime := allTradingDays[0]
-	for currentTime.Unix() <= end.Unix() {
-		if _, ok := allTradingDaysSet[currentTime]; ok {
-			tradingDays = append(tradingDays, currentTime)
-			currentTime = currentTime.Add(interval)
-		} else {
-			currentTime = currentTime.Add(time.Hour * 24)
+	return fillDatesFromTradingDays(scoringDays, allTradingDays, delay), nil
+}
+
+func fillDatesFromTradingDays(scoringDays, allTradingDays []time.Time, delay int) map[time.Time]time.Time {
+	indexByDay := map[time.Time]int{}
+	for i, t := range allTradingDays {
+		indexByDay[t] = i
+	}
+
+	out := map[time.Time]time.Time{}
+	for _, t := range scoringDays {
+		i, ok := indexByDay[t]
+		if !ok || i+delay >= len(allTradingDays) {
+			continue
 		}
+		out[t] = allTradingDays[i+delay]
 	}
 
-	return tradingDays, nil
+	return out
+}
+
+func (h BacktestHandler) calculateRelevantTradingDays(
+	start, end time.Time,
+	schedule domain.RebalanceSchedule,
+) ([]time.Time, error) {
+	allTradingDays, err := h.PriceRepository.ListTradingDays(start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate trading days: %w", err)
+	}
+	sort.Slice(allTradingDays, func(i, j int) bool {
+		return allTradingDays[i].Before(allTradingDays[j])
+	})
+
+	return schedule.RebalanceDays(allTradingDays), nil
 }
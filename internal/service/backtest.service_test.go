@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"factorbacktest/internal"
+	"factorbacktest/internal/db/models/postgres/public/model"
+	"factorbacktest/internal/domain"
+	"factorbacktest/internal/util"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_fillDatesFromTradingDays(t *testing.T) {
+	allTradingDays := []time.Time{
+		util.NewDate(2020, 1, 2),
+		util.NewDate(2020, 1, 3),
+		util.NewDate(2020, 1, 6),
+		util.NewDate(2020, 1, 7),
+	}
+
+	t.Run("next bar skips the weekend", func(t *testing.T) {
+		out := fillDatesFromTradingDays(
+			[]time.Time{util.NewDate(2020, 1, 2), util.NewDate(2020, 1, 3)},
+			allTradingDays,
+			1,
+		)
+		require.Equal(t, "", cmp.Diff(map[time.Time]time.Time{
+			util.NewDate(2020, 1, 2): util.NewDate(2020, 1, 3),
+			util.NewDate(2020, 1, 3): util.NewDate(2020, 1, 6),
+		}, out))
+	})
+
+	t.Run("drops scoring dates that fill past the end", func(t *testing.T) {
+		out := fillDatesFromTradingDays(
+			[]time.Time{util.NewDate(2020, 1, 3), util.NewDate(2020, 1, 6)},
+			allTradingDays,
+			2,
+		)
+		require.Equal(t, "", cmp.Diff(map[time.Time]time.Time{
+			util.NewDate(2020, 1, 3): util.NewDate(2020, 1, 7),
+		}, out))
+	})
+}
+
+func Test_applyCorporateActions(t *testing.T) {
+	newPortfolio := func() *domain.Portfolio {
+		p := domain.NewPortfolio()
+		p.Positions["AAPL"] = &domain.Position{Symbol: "AAPL", ExactQuantity: decimal.NewFromInt(10)}
+		return p
+	}
+	actions := []model.CorporateAction{
+		{Symbol: "AAPL", CorporateActionType: model.CorporateActionType_Split, Amount: decimal.NewFromInt(4)},
+		{Symbol: "AAPL", CorporateActionType: model.CorporateActionType_Dividend, Amount: decimal.NewFromFloat(0.5)},
+		{Symbol: "MSFT", CorporateActionType: model.CorporateActionType_Dividend, Amount: decimal.NewFromInt(1)},
+	}
+	pm := map[string]decimal.Decimal{"AAPL": decimal.NewFromInt(40)}
+
+	t.Run("cash", func(t *testing.T) {
+		p := newPortfolio()
+		income := applyCorporateActions(p, actions, DividendModeCash, pm)
+
+		require.Equal(t, "20", income.String())
+		require.Equal(t, "20", p.Cash.String())
+		require.Equal(t, "40", p.Positions["AAPL"].ExactQuantity.String())
+	})
+
+	t.Run("reinvest", func(t *testing.T) {
+		p := newPortfolio()
+		income := applyCorporateActions(p, actions, DividendModeReinvest, pm)
+
+		require.Equal(t, "20", income.String())
+		require.True(t, p.Cash.IsZero())
+		require.Equal(t, "40.5", p.Positions["AAPL"].ExactQuantity.String())
+	})
+}
+
+func Test_dailySimulation(t *testing.T) {
+	days := []time.Time{
+		util.NewDate(2020, 1, 2),
+		util.NewDate(2020, 1, 3),
+		util.NewDate(2020, 1, 6),
+	}
+	prices := map[string][]domain.AssetPrice{
+		"AAPL": {
+			{Symbol: "AAPL", Date: days[0], Price: decimal.NewFromInt(10)},
+			{Symbol: "AAPL", Date: days[1], Price: decimal.NewFromInt(11)},
+			{Symbol: "AAPL", Date: days[2], Price: decimal.NewFromInt(12)},
+		},
+	}
+	sim := &dailySimulation{
+		tradingDays:  days,
+		prices:       prices,
+		actionsByDay: map[string][]model.CorporateAction{},
+	}
+
+	p := domain.NewPortfolio()
+	p.SetCash(decimal.NewFromInt(100))
+	_, err := sim.advance(p, days[0])
+	require.NoError(t, err)
+
+	// trade into 10 shares on the first day
+	p.SetCash(decimal.Zero)
+	p.Positions["AAPL"] = &domain.Position{Symbol: "AAPL", ExactQuantity: decimal.NewFromInt(10)}
+
+	period, err := sim.finish(p, days[2])
+	require.NoError(t, err)
+	require.Equal(t, "", cmp.Diff([]EquityPoint{
+		{Date: days[0], Value: 100},
+		{Date: days[1], Value: 110},
+		{Date: days[2], Value: 120},
+	}, period.Equity))
+}
+
+func Test_strategyBacktestInput(t *testing.T) {
+	borrowBps := 50.0
+	in, err := strategyBacktestInput(context.Background(), model.Strategy{
+		FactorExpression:   "price(currentDate)",
+		RebalanceInterval:  "monthly",
+		NumAssets:          10,
+		AssetUniverse:      "SPY_TOP_80",
+		LongShort:          util.StringPointer(`{"numShortTickers":5,"grossExposure":2,"netExposure":0}`),
+		ShortBorrowBps:     &borrowBps,
+		UniverseFilter:     util.StringPointer(`{"condition":"price(currentDate) > 5"}`),
+		DividendMode:       util.StringPointer("reinvest"),
+		ExecutionDelayDays: 1,
+	})
+	require.NoError(t, err)
+	require.Equal(t, &internal.LongShortOptions{NumShortTickers: 5, GrossExposure: 2}, in.LongShort)
+	require.Equal(t, 50.0, in.ShortBorrowBps)
+	require.Equal(t, &UniverseFilter{Condition: "price(currentDate) > 5"}, in.UniverseFilter)
+	require.Equal(t, DividendModeReinvest, in.DividendMode)
+	require.Equal(t, 1, in.ExecutionDelay)
+	require.Nil(t, in.Constraints)
+
+	in, err = strategyBacktestInput(context.Background(), model.Strategy{RebalanceInterval: "monthly"})
+	require.NoError(t, err)
+	require.Nil(t, in.LongShort)
+	require.Nil(t, in.UniverseFilter)
+	require.Equal(t, DividendModeAdjusted, in.DividendMode)
+
+	in, err = strategyBacktestInput(context.Background(), model.Strategy{RebalanceInterval: "hourly"})
+	require.NoError(t, err)
+	require.Equal(t, domain.RebalanceSchedule{Type: domain.RebalanceScheduleType_Interval, Interval: 24 * time.Hour}, in.RebalanceSchedule)
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"factorbacktest/internal/calculator"
+	"factorbacktest/internal/data"
+	"factorbacktest/internal/domain"
+	"factorbacktest/internal/repository"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// FactorAnalysisService answers research questions about a factor
+// expression on its own, without building a portfolio out of it
+type FactorAnalysisService interface {
+	QuantileReturns(ctx context.Context, in QuantileAnalysisInput) (*calculator.QuantileReturnsResult, error)
+	InformationCoefficients(ctx context.Context, in ICAnalysisInput) (*calculator.InformationCoefficientResult, error)
+}
+
+type QuantileAnalysisInput struct {
+	FactorExpression  string
+	AssetUniverse     string
+	Start             time.Time
+	End               time.Time
+	RebalanceSchedule domain.RebalanceSchedule
+	NumQuantiles      int
+}
+
+type ICAnalysisInput struct {
+	FactorExpression  string
+	AssetUniverse     string
+	Start             time.Time
+	End               time.Time
+	RebalanceSchedule domain.RebalanceSchedule
+	// in trading days
+	Horizons []int
+}
+
+func NewFactorAnalysisService(
+	priceRepository repository.AdjustedPriceRepository,
+	universeRepository repository.AssetUniverseRepository,
+	factorExpressionService calculator.FactorExpressionService,
+) FactorAnalysisService {
+	return factorAnalysisServiceHandler{
+		PriceRepository:         priceRepository,
+		UniverseRepository:      universeRepository,
+		FactorExpressionService: factorExpressionService,
+	}
+}
+
+type factorAnalysisServiceHandler struct {
+	PriceRepository         repository.AdjustedPriceRepository
+	UniverseRepository      repository.AssetUniverseRepository
+	FactorExpressionService calculator.FactorExpressionService
+}
+
+// scoredUniverse is everything both analyses need: the factor scores on
+// each scoring day, and a way to look up prices afterwards
+type scoredUniverse struct {
+	symbols        []string
+	allTradingDays []time.Time
+	scoringDays    []time.Time
+	scoresByDay    map[time.Time]*calculator.ScoresResultsOnDay
+	priceCache     *data.PriceCache
+}
+
+func (h factorAnalysisServiceHandler) scoreUniverse(ctx context.Context, expression, universe string, start, end time.Time, schedule domain.RebalanceSchedule) (*scoredUniverse, error) {
+	// only names in the universe on each scoring day get scored, so
+	// later removals still count against the factor
+	membership, err := h.UniverseRepository.GetMembership(universe, start, end)
+	if err != nil {
+		return nil, err
+	}
+	tickers := membership.Tickers()
+	if len(tickers) == 0 {
+		return nil, fmt.Errorf("no tickers found")
+	}
+	symbols := []string{}
+	for _, t := range tickers {
+		symbols = append(symbols, t.Symbol)
+	}
+
+	allTradingDays, err := h.PriceRepository.ListTradingDays(start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trading days: %w", err)
+	}
+	sort.Slice(allTradingDays, func(i, j int) bool {
+		return allTradingDays[i].Before(allTradingDays[j])
+	})
+	scoringDays := schedule.RebalanceDays(allTradingDays)
+	if len(scoringDays) == 0 {
+		return nil, fmt.Errorf("no trading days in given range")
+	}
+
+	scoresByDay, priceCache, err := h.FactorExpressionService.CalculateFactorScoresForMembers(ctx, scoringDays, membersByDay(membership, scoringDays), expression)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate factor scores: %w", err)
+	}
+
+	return &scoredUniverse{
+		symbols:        symbols,
+		allTradingDays: allTradingDays,
+		scoringDays:    scoringDays,
+		scoresByDay:    scoresByDay,
+		priceCache:     priceCache,
+	}, nil
+}
+
+func (u scoredUniverse) pricesOnDays(ctx context.Context, days []time.Time) (map[time.Time]map[string]decimal.Decimal, error) {
+	out := map[time.Time]map[string]decimal.Decimal{}
+	for _, d := range days {
+		if _, ok := out[d]; ok {
+			continue
+		}
+		pm, err := u.priceCache.GetManyOnDay(ctx, u.symbols, d)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get prices on %s: %w", d.Format(time.DateOnly), err)
+		}
+		out[d] = pm
+	}
+	return out, nil
+}
+
+func (h factorAnalysisServiceHandler) QuantileReturns(ctx context.Context, in QuantileAnalysisInput) (*calculator.QuantileReturnsResult, error) {
+	u, err := h.scoreUniverse(ctx, in.FactorExpression, in.AssetUniverse, in.Start, in.End, in.RebalanceSchedule)
+	if err != nil {
+		return nil, err
+	}
+
+	// the last bucket is held through the end of the range
+	dates := append([]time.Time{}, u.scoringDays...)
+	if last := u.allTradingDays[len(u.allTradingDays)-1]; last.After(dates[len(dates)-1]) {
+		dates = append(dates, last)
+	}
+
+	pricesByDay, err := u.pricesOnDays(ctx, dates)
+	if err != nil {
+		return nil, err
+	}
+
+	return calculator.CalculateQuantileReturns(calculator.QuantileReturnsInput{
+		NumQuantiles: in.NumQuantiles,
+		Dates:        dates,
+		ScoresByDay:  u.scoresByDay,
+		PricesByDay:  pricesByDay,
+	})
+}
+
+func (h factorAnalysisServiceHandler) InformationCoefficients(ctx context.Context, in ICAnalysisInput) (*calculator.InformationCoefficientResult, error) {
+	u, err := h.scoreUniverse(ctx, in.FactorExpression, in.AssetUniverse, in.Start, in.End, in.RebalanceSchedule)
+	if err != nil {
+		return nil, err
+	}
+
+	// prices on each scoring day and at the end of every horizon
+	dayIndex := map[time.Time]int{}
+	for i, t := range u.allTradingDays {
+		dayIndex[t] = i
+	}
+	days := []time.Time{}
+	for _, t := range u.scoringDays {
+		days = append(days, t)
+		for _, horizon := range in.Horizons {
+			if i := dayIndex[t] + horizon; horizon > 0 && i < len(u.allTradingDays) {
+				days = append(days, u.allTradingDays[i])
+			}
+		}
+	}
+
+	pricesByDay, err := u.pricesOnDays(ctx, days)
+	if err != nil {
+		return nil, err
+	}
+
+	return calculator.CalculateInformationCoefficients(calculator.InformationCoefficientInput{
+		TradingDays: u.allTradingDays,
+		ScoringDays: u.scoringDays,
+		Horizons:    in.Horizons,
+		ScoresByDay: u.scoresByDay,
+		PricesByDay: pricesByDay,
+	})
+}
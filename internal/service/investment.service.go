@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"factorbacktest/internal"
 	"factorbacktest/internal/calculator"
 	"factorbacktest/internal/data"
 	"factorbacktest/internal/db/models/postgres/public/model"
@@ -101,6 +102,14 @@ func NewInvestmentService(
 }
 
 func (h investmentServiceHandler) Add(ctx context.Context, userAccountID uuid.UUID, strategyID uuid.UUID, amount int) error {
+	strategy, err := h.StrategyRepository.Get(strategyID)
+	if err != nil {
+		return fmt.Errorf("failed to get strategy with id %s: %w", strategyID.String(), err)
+	}
+	if strategy.LongShort != nil {
+		return fmt.Errorf("cannot invest in a long/short strategy, investments can only hold long positions")
+	}
+
 	tx, err := h.Db.Begin()
 	if err != nil {
 		return err
@@ -274,23 +283,45 @@ func (h investmentServiceHandler) GetStats(ctx context.Context, investmentID uui
 }
 
 // listForRebalance retrieves all investments that should be
-// rebalanced right now
-// todo - fix so that it looks at rebalance interval
-func (h investmentServiceHandler) listForRebalance(ctx context.Context) ([]model.Investment, error) {
+// rebalanced right now, along with the schedule of each one's
+// strategy. investments without a schedule always rebalance
+func (h investmentServiceHandler) listForRebalance(ctx context.Context, date time.Time) ([]model.Investment, map[uuid.UUID]*domain.RebalanceSchedule, error) {
 	log := logger.FromContext(ctx)
 	investments, err := h.InvestmentRepository.List(repository.StrategyInvestmentListFilter{})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	lastRebalances, err := h.lastRebalanceDates()
+	if err != nil {
+		return nil, nil, err
 	}
 
 	investmentsToRebalance := []model.Investment{}
+	schedules := map[uuid.UUID]*domain.RebalanceSchedule{}
 	for _, investment := range investments {
+		// liquidations go out as soon as they're requested
+		if investment.LiquidationRequestedAt == nil {
+			strategy, err := h.StrategyRepository.Get(investment.StrategyID)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to get strategy with id %s: %w", investment.StrategyID.String(), err)
+			}
+			schedule, err := domain.ParseRebalanceSchedule(strategy.RebalanceInterval)
+			if err != nil {
+				log.Warnf("investment %s has unknown rebalance schedule, rebalancing anyway: %s", investment.InvestmentID.String(), err.Error())
+			} else if !schedule.IsDue(date, lastRebalances[investment.InvestmentID]) {
+				log.Infof("skipping rebalancing investment id %s: not due on %s schedule", investment.InvestmentID, strategy.RebalanceInterval)
+				continue
+			} else {
+				schedules[investment.InvestmentID] = schedule
+			}
+		}
+
 		log.Infof("rebalancing %s", investment.InvestmentID.String())
 		tradeOrders, err := h.InvestmentTradeRepository.List(nil, repository.InvestmentTradeListFilter{
 			InvestmentID: &investment.InvestmentID,
 		})
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		pendingInvestmentTradeID := uuid.Nil
 		for _, t := range tradeOrders {
@@ -306,7 +337,21 @@ func (h investmentServiceHandler) listForRebalance(ctx context.Context) ([]model
 		}
 	}
 
-	return investmentsToRebalance, nil
+	return investmentsToRebalance, schedules, nil
+}
+
+// lastRebalanceDates finds when each investment last started
+// a rebalance that didn't error
+func (h investmentServiceHandler) lastRebalanceDates() (map[uuid.UUID]*time.Time, error) {
+	latest, err := h.InvestmentRebalanceRepository.LatestStartDates(nil)
+	if err != nil {
+		return nil, err
+	}
+	out := map[uuid.UUID]*time.Time{}
+	for investmentID, createdAt := range latest {
+		out[investmentID] = &createdAt
+	}
+	return out, nil
 }
 
 func (h investmentServiceHandler) getTargetPortfolio(
@@ -333,14 +378,42 @@ func (h investmentServiceHandler) getTargetPortfolio(
 	if err != nil {
 		return nil, fmt.Errorf("failed to get strategy with id %s: %w", investment.StrategyID.String(), err)
 	}
-	universe, err := h.UniverseRepository.GetAssets(strategy.AssetUniverse)
+	if strategy.LongShort != nil {
+		return nil, fmt.Errorf("strategy %s is long/short, but investments can only hold long positions", strategy.StrategyID.String())
+	}
+	universe, err := h.UniverseRepository.GetAssets(strategy.AssetUniverse, time.Now().UTC())
 	if err != nil {
 		return nil, err
 	}
+	universeFilter, err := ParseUniverseFilter(strategy.UniverseFilter)
+	if err != nil {
+		return nil, err
+	}
+	if universeFilter != nil {
+		universe, err = filterLatestUniverse(ctx, h.FactorExpressionService, universe, *universeFilter)
+		if err != nil {
+			return nil, err
+		}
+	}
 	factorScoresOnLatestDay, err := h.FactorExpressionService.CalculateLatestFactorScores(ctx, universe, strategy.FactorExpression)
 	if err != nil {
 		return nil, fmt.Errorf("failed to calculate factor scores: %w", err)
 	}
+	constraints, err := internal.ParsePositionConstraints(strategy.PositionConstraints)
+	if err != nil {
+		return nil, err
+	}
+	// same weighting as the backtest, so the investment holds what
+	// the strategy did
+	weighting := internal.StrategyWeighting(*strategy)
+	var weightingData map[string]*float64
+	if expression := weighting.Scheme.DataExpression(); expression != "" {
+		results, err := h.FactorExpressionService.CalculateLatestFactorScores(ctx, universe, expression)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate %s weighting data: %w", weighting.Scheme, err)
+		}
+		weightingData = results.SymbolScores
+	}
 	computeTargetPortfolioResponse, err := calculator.ComputeTargetPortfolio(calculator.ComputeTargetPortfolioInput{
 		Date:             date,
 		TargetNumTickers: int(strategy.NumAssets),
@@ -348,6 +421,10 @@ func (h investmentServiceHandler) getTargetPortfolio(
 		PortfolioValue:   portfolioValue,
 		PriceMap:         pm,
 		TickerIDMap:      tickerIDMap,
+		Constraints:      constraints,
+		SectorBySymbol:   internal.SectorBySymbol(universe),
+		Weighting:        weighting,
+		WeightingData:    weightingData,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to compute target portfolio: %w", err)
@@ -370,6 +447,7 @@ func (h investmentServiceHandler) rebalanceInvestment(
 	rebalancerRun model.RebalancerRun,
 	pm map[string]decimal.Decimal,
 	tickerIDMap map[string]uuid.UUID,
+	schedule *domain.RebalanceSchedule,
 ) (*rebalanceInvestmentResponse, error) {
 	log := logger.FromContext(ctx).With(
 		"investmentID", investment.InvestmentID.String(),
@@ -415,6 +493,20 @@ func (h investmentServiceHandler) rebalanceInvestment(
 		return nil, fmt.Errorf("failed to get target portfolio: %w", err)
 	}
 
+	// drift schedules are checked every run, but only trade once holdings
+	// wander far enough from target. brand new investments are all cash,
+	// so they always trade
+	if schedule != nil && schedule.Type == domain.RebalanceScheduleType_Drift && len(initialPortfolio.Positions) > 0 {
+		drift, err := initialPortfolio.MaxWeightDrift(computeTargetPortfolioResponse.AssetWeights, pm)
+		if err != nil {
+			return nil, err
+		}
+		if drift <= schedule.DriftThreshold {
+			log.Infof("skipping rebalance: max drift %f within %f band", drift, schedule.DriftThreshold)
+			return &rebalanceInvestmentResponse{}, nil
+		}
+	}
+
 	proposedTrades, err := transitionToTarget(ctx, *initialPortfolio, *computeTargetPortfolioResponse.TargetPortfolio, pm)
 	if err != nil {
 		return nil, err
@@ -493,35 +585,7 @@ func transitionToTarget(
 	priceMap map[string]decimal.Decimal,
 ) ([]*domain.ProposedTrade, error) {
 	log := logger.FromContext(ctx)
-	trades := []*domain.ProposedTrade{}
-	prevPositions := currentPortfolio.Positions
-	targetPositions := targetPortfolio.Positions
-
-	for symbol, position := range targetPositions {
-		diff := position.ExactQuantity
-		prevPosition, ok := prevPositions[symbol]
-		if ok {
-			diff = position.ExactQuantity.Sub(prevPosition.ExactQuantity)
-		}
-		if !diff.Equal(decimal.Zero) {
-			trades = append(trades, &domain.ProposedTrade{
-				Symbol:        symbol,
-				TickerID:      position.TickerID,
-				ExactQuantity: diff,
-				ExpectedPrice: priceMap[symbol],
-			})
-		}
-	}
-	for symbol, position := range prevPositions {
-		if _, ok := targetPositions[symbol]; !ok {
-			trades = append(trades, &domain.ProposedTrade{
-				Symbol:        symbol,
-				TickerID:      position.TickerID,
-				ExactQuantity: position.ExactQuantity.Neg(),
-				ExpectedPrice: priceMap[symbol],
-			})
-		}
-	}
+	trades := currentPortfolio.TradesToTarget(targetPortfolio, priceMap)
 
 	// we know that any buys should trigger sells (even
 	// if we're selling cash), and vice-versa
@@ -625,8 +689,7 @@ func (h investmentServiceHandler) Rebalance(ctx context.Context) error {
 		return fmt.Errorf("market is not open")
 	}
 
-	// note - assumes everything is due for rebalance when run, i.e. rebalances everything
-	investmentsToRebalance, err := h.listForRebalance(ctx)
+	investmentsToRebalance, schedules, err := h.listForRebalance(ctx, date)
 	if err != nil {
 		return err
 	}
@@ -695,6 +758,7 @@ func (h investmentServiceHandler) Rebalance(ctx context.Context) error {
 			*rebalancerRun,
 			pm,
 			tickerIDMap,
+			schedules[investment.InvestmentID],
 		)
 		if err != nil {
 			log.Errorf("failed to generate results for investment %s: %s", investment.InvestmentID.String(), err.Error())
@@ -906,27 +970,24 @@ func (h investmentServiceHandler) reconcileInvestment(ctx context.Context, inves
 
 	targetWeights := map[string]float64{}
 
-	interval := time.Hour * 24
-	// if strings.EqualFold(strategy.RebalanceInterval, "weekly") {
-	// 	interval *= 7
-	// } else if strings.EqualFold(strategy.RebalanceInterval, "monthly") {
-	// 	interval *= 30
-	// } else if strings.EqualFold(strategy.RebalanceInterval, "yearly") {
-	// 	interval *= 365
-	// }
+	// recon compares against daily rebalances, regardless of
+	// the strategy's schedule
+	schedule := domain.RebalanceSchedule{
+		Type:     domain.RebalanceScheduleType_Interval,
+		Interval: time.Hour * 24,
+	}
 
 	// todo - figure out how to call the backtest
-	backtestInput := BacktestInput{
-		FactorExpression:  strategy.FactorExpression,
-		BacktestStart:     investment.StartDate,
-		BacktestEnd:       time.Now().UTC(),
-		RebalanceInterval: interval,
-		StartingCash:      float64(investment.AmountDollars),
-		NumTickers:        int(strategy.NumAssets),
-		AssetUniverse:     strategy.AssetUniverse,
+	backtestInput, err := strategyBacktestInput(ctx, *strategy)
+	if err != nil {
+		return nil, err
 	}
+	backtestInput.BacktestStart = investment.StartDate
+	backtestInput.BacktestEnd = time.Now().UTC()
+	backtestInput.RebalanceSchedule = schedule
+	backtestInput.StartingCash = float64(investment.AmountDollars)
 
-	backtestResponse, err := h.BacktestHandler.Backtest(ctx, backtestInput)
+	backtestResponse, err := h.BacktestHandler.Backtest(ctx, *backtestInput)
 	if err != nil && strings.Contains(err.Error(), "no calculated trading days in given range") {
 		backtestResponse = &BacktestResponse{
 			Snapshots: map[string]BacktestSnapshot{},
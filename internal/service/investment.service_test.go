@@ -143,7 +143,7 @@ func Test_investmentServiceHandler_rebalanceInvestment(t *testing.T) {
 				}, nil)
 
 			universeRepository.EXPECT().
-				GetAssets("universe").
+				GetAssets("universe", gomock.Any()).
 				Return([]model.Ticker{}, nil)
 
 			feService.EXPECT().
@@ -194,7 +194,7 @@ func Test_investmentServiceHandler_rebalanceInvestment(t *testing.T) {
 			// 	Return(expectedTradesStatus, nil)
 		}
 
-		response, err := handler.rebalanceInvestment(context.Background(), tx, investment, rebalancerRun, priceMap, tickerIDMap)
+		response, err := handler.rebalanceInvestment(context.Background(), tx, investment, rebalancerRun, priceMap, tickerIDMap, nil)
 		require.NoError(t, err)
 
 		require.NotEmpty(t, response)
@@ -290,6 +290,28 @@ func TestGetTargetPortfolioForLiquidation(t *testing.T) {
 	require.Equal(t, decimal.NewFromInt(-1), trades[0].ExactQuantity)
 }
 
+func TestGetTargetPortfolioRejectsLongShort(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	strategyRepository := mock_repository.NewMockStrategyRepository(ctrl)
+	handler := investmentServiceHandler{StrategyRepository: strategyRepository}
+	strategyID := uuid.New()
+
+	strategyRepository.EXPECT().Get(strategyID).Return(&model.Strategy{
+		StrategyID: strategyID,
+		LongShort:  util.StringPointer(`{"numShortTickers":3,"grossExposure":2}`),
+	}, nil)
+
+	_, err := handler.getTargetPortfolio(
+		context.Background(),
+		model.Investment{StrategyID: strategyID},
+		time.Now(),
+		decimal.NewFromInt(100),
+		map[string]decimal.Decimal{},
+		map[string]uuid.UUID{},
+	)
+	require.ErrorContains(t, err, "long positions")
+}
+
 func Test_transitionToTarget(t *testing.T) {
 	t.Run("idk", func(t *testing.T) {
 		startingPortfolio := domain.Portfolio{
@@ -5,19 +5,24 @@ import (
 	"factorbacktest/internal/calculator"
 	"factorbacktest/internal/db/models/postgres/public/model"
 	"factorbacktest/internal/db/models/postgres/public/table"
+	"factorbacktest/internal/logger"
 	"factorbacktest/internal/repository"
 	"fmt"
 	"time"
 
 	"github.com/go-jet/jet/v2/postgres"
 	"github.com/google/uuid"
-	"github.com/shopspring/decimal"
 )
 
 type StrategyService interface {
 	// Add()
 	// AddRun()
-	CalculateMetrics(ctx context.Context, strategyID uuid.UUID, backtestResults []BacktestResult) (*calculator.CalculateMetricsResult, error)
+	CalculateMetrics(ctx context.Context, equityCurve []EquityPoint) (*calculator.CalculateMetricsResult, error)
+	CalculateBenchmarkStats(ctx context.Context, equityCurve []EquityPoint, benchmarkSymbol string) (*calculator.BenchmarkStats, error)
+	CalculateOverfitting(ctx context.Context, equityCurves [][]EquityPoint) (*calculator.OverfittingResult, error)
+	CalculateStrategyOverfitting(ctx context.Context, strategyID uuid.UUID, start, end time.Time, equityCurve []EquityPoint) (*calculator.OverfittingResult, error)
+	Bootstrap(ctx context.Context, equityCurve []EquityPoint, options calculator.BootstrapOptions) (*calculator.BootstrapResult, error)
+	RunStressScenarios(ctx context.Context, strategyID uuid.UUID, scenarios []StressScenario) ([]StressScenarioResult, error)
 	Save(uuid.UUID) error
 	// Publish()
 	// Unsave()
@@ -29,20 +34,29 @@ func NewStrategyService(
 	universeRepository repository.AssetUniverseRepository,
 	priceRepository repository.AdjustedPriceRepository,
 	backtestHandler BacktestHandler,
+	interestRateRepository repository.InterestRateRepository,
 ) StrategyService {
 	return strategyServiceHandler{
-		StrategyRepository: strategyRepository,
-		UniverseRepository: universeRepository,
-		PriceRepository:    priceRepository,
-		BacktestHandler:    backtestHandler,
+		StrategyRepository:     strategyRepository,
+		UniverseRepository:     universeRepository,
+		PriceRepository:        priceRepository,
+		BacktestHandler:        backtestHandler,
+		InterestRateRepository: interestRateRepository,
 	}
 }
 
 type strategyServiceHandler struct {
-	StrategyRepository repository.StrategyRepository
-	UniverseRepository repository.AssetUniverseRepository
-	PriceRepository    repository.AdjustedPriceRepository
-	BacktestHandler    BacktestHandler
+	StrategyRepository     repository.StrategyRepository
+	UniverseRepository     repository.AssetUniverseRepository
+	PriceRepository        repository.AdjustedPriceRepository
+	BacktestHandler        backtester
+	InterestRateRepository repository.InterestRateRepository
+}
+
+// backtester is the part of BacktestHandler strategies use, so stress
+// scenarios can be tested without a db
+type backtester interface {
+	Backtest(ctx context.Context, in BacktestInput) (*BacktestResponse, error)
 }
 
 func (h strategyServiceHandler) Save(strategyID uuid.UUID) error {
@@ -58,72 +72,212 @@ func (h strategyServiceHandler) Save(strategyID uuid.UUID) error {
 	return nil
 }
 
-func (h strategyServiceHandler) CalculateMetrics(ctx context.Context, strategyID uuid.UUID, backtestResults []BacktestResult) (*calculator.CalculateMetricsResult, error) {
-	strategy, err := h.StrategyRepository.Get(strategyID)
+// CalculateMetrics computes performance stats from the backtest's
+// daily equity curve
+func (h strategyServiceHandler) CalculateMetrics(ctx context.Context, equityCurve []EquityPoint) (*calculator.CalculateMetricsResult, error) {
+	dates := []time.Time{}
+	values := []float64{}
+	for _, p := range equityCurve {
+		dates = append(dates, p.Date)
+		values = append(values, p.Value)
+	}
+
+	riskFreeRates := h.riskFreeRatesOrZero(ctx, dates)
+
+	metrics, err := calculator.CalculateMetrics(dates, values, riskFreeRates)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get strategy: %w", err)
+		return nil, fmt.Errorf("failed to calculate metrics: %w", err)
 	}
 
-	// let's use three year windows for stats
-	start := backtestResults[0].Date
-	end := backtestResults[len(backtestResults)-1].Date
+	return metrics, nil
+}
+
+// CalculateBenchmarkStats compares the backtest's daily equity curve to
+// the benchmark's adjusted prices over the same days
+func (h strategyServiceHandler) CalculateBenchmarkStats(ctx context.Context, equityCurve []EquityPoint, benchmarkSymbol string) (*calculator.BenchmarkStats, error) {
+	if len(equityCurve) < 2 {
+		return nil, fmt.Errorf("cannot compare to benchmark with < 2 equity curve points")
+	}
+	dates := []time.Time{}
+	values := []float64{}
+	for _, p := range equityCurve {
+		dates = append(dates, p.Date)
+		values = append(values, p.Value)
+	}
 
-	assets, err := h.UniverseRepository.GetAssets(strategy.AssetUniverse)
+	prices, err := h.PriceRepository.List([]string{benchmarkSymbol}, dates[0], dates[len(dates)-1])
 	if err != nil {
-		return nil, fmt.Errorf("failed to get assets from universie name")
+		return nil, fmt.Errorf("failed to get %s prices: %w", benchmarkSymbol, err)
 	}
-	getPricesInput := []repository.GetManyInput{}
-	for _, a := range assets {
-		getPricesInput = append(getPricesInput, repository.GetManyInput{
-			Symbol:  a.Symbol,
-			MinDate: start,
-			MaxDate: end,
-		})
+	if len(prices) == 0 {
+		return nil, fmt.Errorf("no prices found for benchmark %s", benchmarkSymbol)
 	}
-	prices, err := h.PriceRepository.GetMany(getPricesInput)
+	benchmarkPrices := map[time.Time]float64{}
+	for _, p := range prices {
+		benchmarkPrices[p.Date] = p.Price.InexactFloat64()
+	}
+
+	riskFreeRates := h.riskFreeRatesOrZero(ctx, dates)
+
+	result, err := calculator.CalculateBenchmarkStats(dates, values, benchmarkPrices, riskFreeRates)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get prices: %w", err)
+		return nil, fmt.Errorf("failed to calculate benchmark stats: %w", err)
 	}
 
-	mappedPrices := map[time.Time]map[string]decimal.Decimal{}
-	for _, p := range prices {
-		if _, ok := mappedPrices[p.Date]; !ok {
-			mappedPrices[p.Date] = map[string]decimal.Decimal{}
+	return result, nil
+}
+
+// CalculateOverfitting computes the deflated sharpe ratio and probability
+// of backtest overfitting across every backtest tried for a strategy. only
+// days that appear in every equity curve are used
+func (h strategyServiceHandler) CalculateOverfitting(ctx context.Context, equityCurves [][]EquityPoint) (*calculator.OverfittingResult, error) {
+	if len(equityCurves) == 0 {
+		return nil, fmt.Errorf("cannot calculate overfitting with no backtests")
+	}
+
+	numCurvesOnDate := map[time.Time]int{}
+	for _, curve := range equityCurves {
+		for _, p := range curve {
+			numCurvesOnDate[p.Date]++
+		}
+	}
+	dates := []time.Time{}
+	for _, p := range equityCurves[0] {
+		if numCurvesOnDate[p.Date] == len(equityCurves) {
+			dates = append(dates, p.Date)
+		}
+	}
+
+	curves := [][]float64{}
+	for _, curve := range equityCurves {
+		valuesByDate := map[time.Time]float64{}
+		for _, p := range curve {
+			valuesByDate[p.Date] = p.Value
 		}
-		mappedPrices[p.Date][p.Symbol] = p.Price
+		values := []float64{}
+		for _, d := range dates {
+			values = append(values, valuesByDate[d])
+		}
+		curves = append(curves, values)
 	}
 
-	relevantTradingDays, err := h.PriceRepository.ListTradingDays(
-		start,
-		end,
-	)
+	riskFreeRates := h.riskFreeRatesOrZero(ctx, dates)
+
+	result, err := calculator.CalculateOverfitting(dates, curves, riskFreeRates)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list trading days: %w", err)
+		return nil, fmt.Errorf("failed to calculate overfitting: %w", err)
 	}
 
-	metrics, err := calculator.CalculateMetrics(
-		h.convertToCalculatorBacktestResults(backtestResults),
-		relevantTradingDays,
-		mappedPrices,
-	)
+	return result, nil
+}
+
+// CalculateStrategyOverfitting deflates the sharpe of a strategy's
+// backtest by every other strategy its user ran on the same universe
+// over the same window, i.e. the variants they tried before settling on
+// this one. anonymous backtests are treated as the only trial
+func (h strategyServiceHandler) CalculateStrategyOverfitting(ctx context.Context, strategyID uuid.UUID, start, end time.Time, equityCurve []EquityPoint) (*calculator.OverfittingResult, error) {
+	strategy, err := h.StrategyRepository.Get(strategyID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to calculate metrics: %w", err)
+		return nil, fmt.Errorf("failed to get strategy: %w", err)
 	}
 
-	return metrics, nil
+	otherSharpes := []float64{}
+	if strategy.UserAccountID != nil {
+		runs, err := h.StrategyRepository.ListUserRuns(*strategy.UserAccountID, strategy.AssetUniverse, start, end)
+		if err != nil {
+			return nil, err
+		}
+		for _, run := range runs {
+			if run.StrategyID != strategyID && run.SharpeRatio != nil {
+				otherSharpes = append(otherSharpes, *run.SharpeRatio)
+			}
+		}
+	}
+
+	dates := []time.Time{}
+	values := []float64{}
+	for _, p := range equityCurve {
+		dates = append(dates, p.Date)
+		values = append(values, p.Value)
+	}
+
+	riskFreeRates := h.riskFreeRatesOrZero(ctx, dates)
+
+	result, err := calculator.CalculateDeflatedSharpeRatio(dates, values, riskFreeRates, otherSharpes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate deflated sharpe ratio: %w", err)
+	}
+
+	return result, nil
 }
 
-func (h strategyServiceHandler) convertToCalculatorBacktestResults(backtestResults []BacktestResult) []calculator.BacktestResult {
-	calculatorBacktestResults := make([]calculator.BacktestResult, len(backtestResults))
-	for i, br := range backtestResults {
-		calculatorBacktestResults[i] = calculator.BacktestResult{
-			Date:                         br.Date,
-			Portfolio:                    br.Portfolio,
-			TotalValue:                   br.TotalValue,
-			AssetWeights:                 br.AssetWeights,
-			FactorScores:                 br.FactorScores,
-			PriceChangeTilNextResampling: br.PriceChangeTilNextResampling,
+// Bootstrap resamples the equity curve's returns to put confidence
+// intervals on its metrics
+func (h strategyServiceHandler) Bootstrap(ctx context.Context, equityCurve []EquityPoint, options calculator.BootstrapOptions) (*calculator.BootstrapResult, error) {
+	dates := []time.Time{}
+	values := []float64{}
+	for _, p := range equityCurve {
+		dates = append(dates, p.Date)
+		values = append(values, p.Value)
+	}
+
+	riskFreeRates := h.riskFreeRatesOrZero(ctx, dates)
+
+	result, err := calculator.Bootstrap(dates, values, riskFreeRates, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to bootstrap: %w", err)
+	}
+
+	return result, nil
+}
+
+// riskFreeRateDurationMonths picks the treasury yield we treat as
+// the risk free rate, i.e. 3 month bills
+const riskFreeRateDurationMonths = 3
+
+// riskFreeRatesOrZero is riskFreeRates for metrics that can still be
+// reported without them. on failure it logs and returns nil, which the
+// calculator treats as a 0 rate
+func (h strategyServiceHandler) riskFreeRatesOrZero(ctx context.Context, dates []time.Time) []float64 {
+	riskFreeRates, err := h.riskFreeRates(dates)
+	if err != nil {
+		logger.FromContext(ctx).Warnf("failed to load risk free rates, using 0: %v", err)
+		return nil
+	}
+	return riskFreeRates
+}
+
+// riskFreeRates returns the annualized risk free rate on each date. the
+// interest_rate table only has business days the treasury published on,
+// so gaps are filled with the most recent rate before them
+func (h strategyServiceHandler) riskFreeRates(dates []time.Time) ([]float64, error) {
+	if h.InterestRateRepository == nil || len(dates) == 0 {
+		return nil, nil
+	}
+
+	// look back a bit so the first date has something to carry forward
+	ratesByDate, err := h.InterestRateRepository.List(dates[0].AddDate(0, 0, -14), dates[len(dates)-1])
+	if err != nil {
+		return nil, err
+	}
+	if len(ratesByDate) == 0 {
+		return nil, fmt.Errorf("no interest rates stored between %s and %s", dates[0].Format(time.DateOnly), dates[len(dates)-1].Format(time.DateOnly))
+	}
+
+	out := make([]float64, len(dates))
+	current := 0.0
+	i := 0
+	for d := dates[0].AddDate(0, 0, -14); i < len(dates); d = d.AddDate(0, 0, 1) {
+		if m, ok := ratesByDate[d.Format(time.DateOnly)]; ok {
+			if rate, err := m.GetRate(riskFreeRateDurationMonths); err == nil {
+				current = rate
+			}
+		}
+		for i < len(dates) && !dates[i].After(d) {
+			out[i] = current
+			i++
 		}
 	}
-	return calculatorBacktestResults
+
+	return out, nil
 }
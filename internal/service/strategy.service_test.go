@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"factorbacktest/internal/db/models/postgres/public/model"
+	mock_repository "factorbacktest/internal/repository/mocks"
+	"factorbacktest/internal/util"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCalculateStrategyOverfitting(t *testing.T) {
+	start := util.NewDate(2020, 1, 1)
+	end := util.NewDate(2020, 3, 1)
+	equityCurve := []EquityPoint{}
+	for i, v := range []float64{100, 101, 100.5, 102, 103, 102.5, 104} {
+		equityCurve = append(equityCurve, EquityPoint{Date: start.AddDate(0, 0, i), Value: v})
+	}
+
+	t.Run("counts the user's other runs as trials", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		strategyRepository := mock_repository.NewMockStrategyRepository(ctrl)
+		handler := strategyServiceHandler{StrategyRepository: strategyRepository}
+		strategyID := uuid.New()
+		userAccountID := uuid.New()
+
+		strategyRepository.EXPECT().Get(strategyID).Return(&model.Strategy{
+			StrategyID:    strategyID,
+			AssetUniverse: "SPY_TOP_80",
+			UserAccountID: &userAccountID,
+		}, nil)
+		sharpe := func(s float64) *float64 { return &s }
+		strategyRepository.EXPECT().ListUserRuns(userAccountID, "SPY_TOP_80", start, end).Return([]model.StrategyRun{
+			{StrategyID: strategyID, SharpeRatio: sharpe(2)},
+			{StrategyID: uuid.New(), SharpeRatio: sharpe(0.5)},
+			{StrategyID: uuid.New(), SharpeRatio: sharpe(-0.3)},
+			// failed to calculate metrics
+			{StrategyID: uuid.New()},
+		}, nil)
+
+		result, err := handler.CalculateStrategyOverfitting(context.Background(), strategyID, start, end, equityCurve)
+		require.NoError(t, err)
+		require.Equal(t, 3, result.NumTrials)
+		require.Len(t, result.DeflatedSharpeRatios, 1)
+		require.Greater(t, result.ExpectedMaxSharpe, 0.0)
+	})
+
+	t.Run("anonymous backtests are the only trial", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		strategyRepository := mock_repository.NewMockStrategyRepository(ctrl)
+		handler := strategyServiceHandler{StrategyRepository: strategyRepository}
+		strategyID := uuid.New()
+
+		strategyRepository.EXPECT().Get(strategyID).Return(&model.Strategy{StrategyID: strategyID}, nil)
+
+		result, err := handler.CalculateStrategyOverfitting(context.Background(), strategyID, start, end, equityCurve)
+		require.NoError(t, err)
+		require.Equal(t, 1, result.NumTrials)
+		require.Equal(t, 0.0, result.ExpectedMaxSharpe)
+	})
+}
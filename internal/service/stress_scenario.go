@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"factorbacktest/internal/calculator"
+	"factorbacktest/internal/domain"
+	"factorbacktest/internal/util"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StressScenario is a historical window a strategy gets run through on
+// its own, to see how it would have held up
+type StressScenario struct {
+	Name  string
+	Start time.Time
+	End   time.Time
+	// optional - keeps the backtest going after End, only to see
+	// when the drawdown recovers. every other stat stops at End
+	RecoveryEnd time.Time
+	Benchmark   string
+}
+
+// DefaultStressScenarios run from each market's peak to its trough, and
+// watch for a recovery until the s&p 500 was back at its old high
+var DefaultStressScenarios = []StressScenario{
+	{
+		Name:        "Dot-com bust",
+		Start:       util.NewDate(2000, 3, 24),
+		End:         util.NewDate(2002, 10, 9),
+		RecoveryEnd: util.NewDate(2007, 5, 30),
+		Benchmark:   "SPY",
+	},
+	{
+		Name:        "GFC 2008",
+		Start:       util.NewDate(2007, 10, 9),
+		End:         util.NewDate(2009, 3, 9),
+		RecoveryEnd: util.NewDate(2013, 3, 28),
+		Benchmark:   "SPY",
+	},
+	{
+		Name:        "COVID crash",
+		Start:       util.NewDate(2020, 2, 19),
+		End:         util.NewDate(2020, 3, 23),
+		RecoveryEnd: util.NewDate(2020, 8, 18),
+		Benchmark:   "SPY",
+	},
+	{
+		Name:        "2022 rate shock",
+		Start:       util.NewDate(2022, 1, 3),
+		End:         util.NewDate(2022, 10, 12),
+		RecoveryEnd: util.NewDate(2024, 1, 19),
+		Benchmark:   "SPY",
+	},
+}
+
+// starting cash doesn't change any of the reported stats
+const stressScenarioStartingCash = 10_000
+
+// most scenarios backtested at once. each is a full backtest
+const maxConcurrentStressScenarios = 4
+
+type StressScenarioResult struct {
+	Scenario StressScenario
+	// through RecoveryEnd, when the scenario has one
+	EquityCurve []EquityPoint
+	TotalReturn float64
+	MaxDrawdown calculator.Drawdown
+	// calendar days from the drawdown's trough back to its peak. nil
+	// when it didn't recover by RecoveryEnd, or End without one
+	RecoveryDays *int
+	// nil when the benchmark's prices couldn't be loaded
+	BenchmarkTotalReturn *float64
+	BenchmarkMaxDrawdown *float64
+	// strategy total return minus the benchmark's
+	ExcessReturn *float64
+	// why the benchmark stats are missing. the strategy's own
+	// stats are still reported
+	BenchmarkErr error
+	// set when the scenario couldn't be run, e.g. because the strategy's
+	// universe has no prices that far back
+	Err error
+}
+
+// RunStressScenarios backtests the saved strategy over each scenario's
+// window, a few at a time. a scenario failing doesn't fail the others
+func (h strategyServiceHandler) RunStressScenarios(ctx context.Context, strategyID uuid.UUID, scenarios []StressScenario) ([]StressScenarioResult, error) {
+	profile, endProfile := domain.GetProfile(ctx)
+	defer endProfile()
+
+	strategy, err := h.StrategyRepository.Get(strategyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get strategy %s: %w", strategyID.String(), err)
+	}
+	base, err := strategyBacktestInput(ctx, *strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]StressScenarioResult, len(scenarios))
+	sem := make(chan struct{}, maxConcurrentStressScenarios)
+	var wg sync.WaitGroup
+	for i, scenario := range scenarios {
+		// profiles aren't thread safe, so every scenario gets its own
+		span, endSpan := domain.NewSpan(fmt.Sprintf("stress scenario %s", scenario.Name))
+		profile.AddSpan(span)
+		scenarioCtx := domain.NewCtxWithSubProfile(ctx, span)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer endSpan()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			in := *base
+			in.BacktestStart = scenario.Start
+			in.BacktestEnd = scenario.End
+			if scenario.RecoveryEnd.After(scenario.End) {
+				in.BacktestEnd = scenario.RecoveryEnd
+			}
+			in.StartingCash = stressScenarioStartingCash
+			result, err := h.runStressScenario(scenarioCtx, in, scenario)
+			if err != nil {
+				results[i] = StressScenarioResult{Scenario: scenario, Err: err}
+				return
+			}
+			results[i] = *result
+		}()
+	}
+	wg.Wait()
+
+	return results, nil
+}
+
+func (h strategyServiceHandler) runStressScenario(ctx context.Context, in BacktestInput, scenario StressScenario) (*StressScenarioResult, error) {
+	backtest, err := h.BacktestHandler.Backtest(ctx, in)
+	if err != nil {
+		return nil, fmt.Errorf("failed to backtest %s: %w", scenario.Name, err)
+	}
+	curve := backtest.EquityCurve
+
+	dates := []time.Time{}
+	values := []float64{}
+	for _, p := range curve {
+		if p.Date.After(scenario.End) {
+			break
+		}
+		dates = append(dates, p.Date)
+		values = append(values, p.Value)
+	}
+	if len(values) < 2 {
+		return nil, fmt.Errorf("%s has too few trading days to evaluate", scenario.Name)
+	}
+	// only drawdown and return are reported, neither of which
+	// depend on the risk free rate
+	metrics, err := calculator.CalculateMetrics(dates, values, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &StressScenarioResult{
+		Scenario:    scenario,
+		EquityCurve: curve,
+		TotalReturn: values[len(values)-1]/values[0] - 1,
+		MaxDrawdown: metrics.MaxDrawdown,
+	}
+	if recovery := drawdownRecovery(curve, metrics.MaxDrawdown); recovery != nil {
+		out.MaxDrawdown.Recovery = recovery
+		days := int(recovery.Sub(metrics.MaxDrawdown.Trough).Hours() / 24)
+		out.RecoveryDays = &days
+	}
+
+	benchmarkReturn, benchmarkDrawdown, err := h.benchmarkOverWindow(scenario.Benchmark, dates)
+	if err != nil {
+		out.BenchmarkErr = err
+	} else {
+		excess := out.TotalReturn - benchmarkReturn
+		out.BenchmarkTotalReturn = &benchmarkReturn
+		out.BenchmarkMaxDrawdown = &benchmarkDrawdown
+		out.ExcessReturn = &excess
+	}
+
+	return out, nil
+}
+
+// drawdownRecovery finds the first day after the drawdown's trough that
+// the curve got back to its peak, including days after the scenario
+func drawdownRecovery(curve []EquityPoint, drawdown calculator.Drawdown) *time.Time {
+	if drawdown.Depth == 0 {
+		return nil
+	}
+	peakValue := 0.0
+	for _, p := range curve {
+		if p.Date.Equal(drawdown.Peak) {
+			peakValue = p.Value
+		}
+		if p.Date.After(drawdown.Trough) && p.Value >= peakValue {
+			return &p.Date
+		}
+	}
+	return nil
+}
+
+// benchmarkOverWindow returns the benchmark's total return and max
+// drawdown over the days the strategy has values for
+func (h strategyServiceHandler) benchmarkOverWindow(symbol string, dates []time.Time) (float64, float64, error) {
+	prices, err := h.PriceRepository.List([]string{symbol}, dates[0], dates[len(dates)-1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get %s prices: %w", symbol, err)
+	}
+	priceByDate := map[time.Time]float64{}
+	for _, p := range prices {
+		priceByDate[p.Date] = p.Price.InexactFloat64()
+	}
+
+	benchmarkDates := []time.Time{}
+	benchmarkValues := []float64{}
+	for _, d := range dates {
+		if price, ok := priceByDate[d]; ok {
+			benchmarkDates = append(benchmarkDates, d)
+			benchmarkValues = append(benchmarkValues, price)
+		}
+	}
+	if len(benchmarkValues) < 2 {
+		return 0, 0, fmt.Errorf("not enough %s prices between %s and %s", symbol, dates[0].Format(time.DateOnly), dates[len(dates)-1].Format(time.DateOnly))
+	}
+
+	metrics, err := calculator.CalculateMetrics(benchmarkDates, benchmarkValues, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return benchmarkValues[len(benchmarkValues)-1]/benchmarkValues[0] - 1, metrics.MaxDrawdown.Depth, nil
+}
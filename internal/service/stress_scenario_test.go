@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"factorbacktest/internal/db/models/postgres/public/model"
+	"factorbacktest/internal/domain"
+	mock_repository "factorbacktest/internal/repository/mocks"
+	"factorbacktest/internal/util"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// stubBacktester returns a canned curve, or error, for each backtest
+// start date
+type stubBacktester struct {
+	curves map[time.Time][]EquityPoint
+	errs   map[time.Time]error
+}
+
+func (b stubBacktester) Backtest(ctx context.Context, in BacktestInput) (*BacktestResponse, error) {
+	if err, ok := b.errs[in.BacktestStart]; ok {
+		return nil, err
+	}
+	return &BacktestResponse{EquityCurve: b.curves[in.BacktestStart]}, nil
+}
+
+func dailyCurve(start time.Time, values ...float64) []EquityPoint {
+	out := []EquityPoint{}
+	for i, v := range values {
+		out = append(out, EquityPoint{Date: start.AddDate(0, 0, i), Value: v})
+	}
+	return out
+}
+
+func dailyPrices(symbol string, start time.Time, values ...float64) []domain.AssetPrice {
+	out := []domain.AssetPrice{}
+	for i, v := range values {
+		out = append(out, domain.AssetPrice{
+			Symbol: symbol,
+			Date:   start.AddDate(0, 0, i),
+			Price:  decimal.NewFromFloat(v),
+		})
+	}
+	return out
+}
+
+func TestRunStressScenarios(t *testing.T) {
+	t.Run("a failing scenario doesn't fail the others", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		strategyRepository := mock_repository.NewMockStrategyRepository(ctrl)
+		priceRepository := mock_repository.NewMockAdjustedPriceRepository(ctrl)
+
+		ok := StressScenario{
+			Name:      "ok",
+			Start:     util.NewDate(2020, 2, 19),
+			End:       util.NewDate(2020, 2, 22),
+			Benchmark: "SPY",
+		}
+		failing := StressScenario{
+			Name:      "failing",
+			Start:     util.NewDate(2000, 3, 24),
+			End:       util.NewDate(2000, 3, 27),
+			Benchmark: "SPY",
+		}
+		handler := strategyServiceHandler{
+			StrategyRepository: strategyRepository,
+			PriceRepository:    priceRepository,
+			BacktestHandler: stubBacktester{
+				curves: map[time.Time][]EquityPoint{
+					ok.Start: dailyCurve(ok.Start, 100, 90, 95, 110),
+				},
+				errs: map[time.Time]error{
+					failing.Start: errors.New("no prices"),
+				},
+			},
+		}
+
+		strategyID := uuid.New()
+		strategyRepository.EXPECT().Get(strategyID).Return(&model.Strategy{
+			StrategyID:        strategyID,
+			FactorExpression:  "pricePercentChange(nDaysAgo(7), currentDate)",
+			RebalanceInterval: "monthly",
+			NumAssets:         10,
+			AssetUniverse:     "SPY_TOP_80",
+		}, nil)
+		priceRepository.EXPECT().List([]string{"SPY"}, ok.Start, ok.End).Return(dailyPrices("SPY", ok.Start, 100, 80, 90, 105), nil)
+
+		profile, endProfile := domain.NewProfile()
+		defer endProfile()
+		ctx := context.WithValue(context.Background(), domain.ContextProfileKey, profile)
+
+		results, err := handler.RunStressScenarios(ctx, strategyID, []StressScenario{ok, failing})
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+
+		require.Equal(t, "ok", results[0].Scenario.Name)
+		require.NoError(t, results[0].Err)
+		require.InDelta(t, 0.1, results[0].TotalReturn, 1e-9)
+		require.InDelta(t, 0.05, *results[0].BenchmarkTotalReturn, 1e-9)
+		require.InDelta(t, 0.05, *results[0].ExcessReturn, 1e-9)
+
+		require.Equal(t, "failing", results[1].Scenario.Name)
+		require.ErrorContains(t, results[1].Err, "no prices")
+		require.Nil(t, results[1].EquityCurve)
+	})
+}
+
+func TestRunStressScenario(t *testing.T) {
+	start := util.NewDate(2020, 2, 19)
+	end := util.NewDate(2020, 2, 22)
+
+	t.Run("missing benchmark keeps the strategy's stats", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		priceRepository := mock_repository.NewMockAdjustedPriceRepository(ctrl)
+		handler := strategyServiceHandler{
+			PriceRepository: priceRepository,
+			BacktestHandler: stubBacktester{
+				curves: map[time.Time][]EquityPoint{
+					start: dailyCurve(start, 100, 90, 95, 110),
+				},
+			},
+		}
+		scenario := StressScenario{Name: "covid", Start: start, End: end, Benchmark: "SPY"}
+
+		priceRepository.EXPECT().List([]string{"SPY"}, start, end).Return(nil, errors.New("db is down"))
+
+		result, err := handler.runStressScenario(context.Background(), BacktestInput{BacktestStart: start, BacktestEnd: end}, scenario)
+		require.NoError(t, err)
+		require.InDelta(t, 0.1, result.TotalReturn, 1e-9)
+		require.InDelta(t, 0.1, result.MaxDrawdown.Depth, 1e-9)
+		require.ErrorContains(t, result.BenchmarkErr, "db is down")
+		require.Nil(t, result.BenchmarkTotalReturn)
+		require.Nil(t, result.BenchmarkMaxDrawdown)
+		require.Nil(t, result.ExcessReturn)
+	})
+
+	t.Run("benchmark without enough prices", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		priceRepository := mock_repository.NewMockAdjustedPriceRepository(ctrl)
+		handler := strategyServiceHandler{
+			PriceRepository: priceRepository,
+			BacktestHandler: stubBacktester{
+				curves: map[time.Time][]EquityPoint{
+					start: dailyCurve(start, 100, 90, 95, 110),
+				},
+			},
+		}
+		scenario := StressScenario{Name: "covid", Start: start, End: end, Benchmark: "SPY"}
+
+		priceRepository.EXPECT().List([]string{"SPY"}, start, end).Return(dailyPrices("SPY", start, 100), nil)
+
+		result, err := handler.runStressScenario(context.Background(), BacktestInput{BacktestStart: start, BacktestEnd: end}, scenario)
+		require.NoError(t, err)
+		require.ErrorContains(t, result.BenchmarkErr, "not enough SPY prices")
+		require.Nil(t, result.ExcessReturn)
+	})
+
+	t.Run("recovery after the scenario ends", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		priceRepository := mock_repository.NewMockAdjustedPriceRepository(ctrl)
+		recoveryEnd := start.AddDate(0, 0, 10)
+		// peaks on day 1, bottoms out on End, and is back above the
+		// peak 3 days later
+		curve := dailyCurve(start, 100, 110, 95, 88, 92, 105, 111, 115)
+		handler := strategyServiceHandler{
+			PriceRepository: priceRepository,
+			BacktestHandler: stubBacktester{
+				curves: map[time.Time][]EquityPoint{start: curve},
+			},
+		}
+		scenario := StressScenario{Name: "covid", Start: start, End: end, RecoveryEnd: recoveryEnd, Benchmark: "SPY"}
+
+		// the benchmark only covers the scenario, not the recovery
+		priceRepository.EXPECT().List([]string{"SPY"}, start, end).Return(dailyPrices("SPY", start, 100, 95, 90, 92), nil)
+
+		result, err := handler.runStressScenario(context.Background(), BacktestInput{BacktestStart: start, BacktestEnd: recoveryEnd}, scenario)
+		require.NoError(t, err)
+		require.Len(t, result.EquityCurve, len(curve))
+		require.InDelta(t, -0.12, result.TotalReturn, 1e-9)
+		require.InDelta(t, 0.2, result.MaxDrawdown.Depth, 1e-9)
+		require.Equal(t, start.AddDate(0, 0, 1), result.MaxDrawdown.Peak)
+		require.Equal(t, end, result.MaxDrawdown.Trough)
+		require.Equal(t, start.AddDate(0, 0, 6), *result.MaxDrawdown.Recovery)
+		require.Equal(t, 3, *result.RecoveryDays)
+		require.NoError(t, result.BenchmarkErr)
+	})
+
+	t.Run("no recovery by the end of the curve", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		priceRepository := mock_repository.NewMockAdjustedPriceRepository(ctrl)
+		handler := strategyServiceHandler{
+			PriceRepository: priceRepository,
+			BacktestHandler: stubBacktester{
+				curves: map[time.Time][]EquityPoint{
+					start: dailyCurve(start, 100, 110, 95, 88, 92, 105),
+				},
+			},
+		}
+		scenario := StressScenario{Name: "covid", Start: start, End: end, RecoveryEnd: start.AddDate(0, 0, 5), Benchmark: "SPY"}
+
+		priceRepository.EXPECT().List([]string{"SPY"}, start, end).Return(dailyPrices("SPY", start, 100, 95, 90, 92), nil)
+
+		result, err := handler.runStressScenario(context.Background(), BacktestInput{BacktestStart: start, BacktestEnd: scenario.RecoveryEnd}, scenario)
+		require.NoError(t, err)
+		require.Nil(t, result.RecoveryDays)
+		require.Nil(t, result.MaxDrawdown.Recovery)
+	})
+
+	t.Run("too few trading days", func(t *testing.T) {
+		handler := strategyServiceHandler{
+			BacktestHandler: stubBacktester{
+				curves: map[time.Time][]EquityPoint{start: dailyCurve(start, 100)},
+			},
+		}
+		scenario := StressScenario{Name: "covid", Start: start, End: end, Benchmark: "SPY"}
+
+		_, err := handler.runStressScenario(context.Background(), BacktestInput{BacktestStart: start, BacktestEnd: end}, scenario)
+		require.ErrorContains(t, err, "too few trading days")
+	})
+}
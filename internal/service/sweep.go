@@ -0,0 +1,282 @@
+package service
+
+import (
+	"context"
+	"factorbacktest/internal/calculator"
+	"factorbacktest/internal/domain"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// most combinations a single sweep can run
+const maxSweepCombinations = 200
+
+var placeholderRegex = regexp.MustCompile(`\$([A-Za-z_][A-Za-z0-9_]*)`)
+
+type SweepParameter struct {
+	// placeholder in the expression, without the $
+	Name   string
+	Values []float64
+}
+
+type SweepInput struct {
+	// FactorExpression may contain $placeholders. NumTickers and
+	// RebalanceSchedule are used when their sweeps are empty
+	Base               BacktestInput
+	Parameters         []SweepParameter
+	NumTickers         []int
+	RebalanceSchedules []domain.RebalanceSchedule
+}
+
+type SweepCombination struct {
+	// one value per SweepInput.Parameters, in the same order
+	ParameterValues   []float64
+	NumTickers        int
+	RebalanceSchedule domain.RebalanceSchedule
+	FactorExpression  string
+	// nil when Err is set
+	EquityCurve []EquityPoint
+	Err         error
+}
+
+type SweepResult struct {
+	Combinations []SweepCombination
+}
+
+// PlaceholderNames returns every distinct $placeholder in the expression
+func PlaceholderNames(expression string) []string {
+	seen := map[string]bool{}
+	out := []string{}
+	for _, match := range placeholderRegex.FindAllStringSubmatch(expression, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			out = append(out, match[1])
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func substitutePlaceholders(expression string, values map[string]float64) string {
+	return placeholderRegex.ReplaceAllStringFunc(expression, func(match string) string {
+		v, ok := values[match[1:]]
+		if !ok {
+			return match
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	})
+}
+
+// expression substitutes one combination of parameter values, in the
+// same order as Parameters
+func (in SweepInput) expression(values []float64) string {
+	valuesByName := map[string]float64{}
+	for i, p := range in.Parameters {
+		valuesByName[p.Name] = values[i]
+	}
+	return substitutePlaceholders(in.Base.FactorExpression, valuesByName)
+}
+
+func (in SweepInput) Validate() error {
+	declared := map[string]bool{}
+	numCombinations := 1
+	for _, p := range in.Parameters {
+		if len(p.Values) == 0 {
+			return fmt.Errorf("sweep parameter $%s has no values", p.Name)
+		}
+		if declared[p.Name] {
+			return fmt.Errorf("sweep parameter $%s is declared twice", p.Name)
+		}
+		declared[p.Name] = true
+		numCombinations *= len(p.Values)
+	}
+	for _, name := range PlaceholderNames(in.Base.FactorExpression) {
+		if !declared[name] {
+			return fmt.Errorf("no values given for $%s", name)
+		}
+	}
+	for _, n := range in.NumTickers {
+		if n <= 0 {
+			return fmt.Errorf("number of tickers must be positive, got %d", n)
+		}
+	}
+	numCombinations *= max(len(in.NumTickers), 1) * max(len(in.RebalanceSchedules), 1)
+	if numCombinations > maxSweepCombinations {
+		return fmt.Errorf("sweep has %d combinations, max is %d", numCombinations, maxSweepCombinations)
+	}
+	// values can be the wrong type for where they're used, e.g. 6.5 in
+	// nMonthsAgo($lookback)
+	for _, values := range parameterGrid(in.Parameters) {
+		expression := in.expression(values)
+		if err := calculator.ValidateFactorExpression(expression); err != nil {
+			return fmt.Errorf("invalid expression %s: %w", expression, err)
+		}
+	}
+	return nil
+}
+
+// parameterGrid lists every combination of parameter values, varying
+// the last parameter fastest
+func parameterGrid(params []SweepParameter) [][]float64 {
+	out := [][]float64{{}}
+	for _, p := range params {
+		next := [][]float64{}
+		for _, prefix := range out {
+			for _, v := range p.Values {
+				combo := append(append([]float64{}, prefix...), v)
+				next = append(next, combo)
+			}
+		}
+		out = next
+	}
+	return out
+}
+
+type scheduleDays struct {
+	schedule    domain.RebalanceSchedule
+	tradingDays []time.Time
+	fillDates   map[time.Time]time.Time
+}
+
+// Sweep backtests every combination of expression parameters, number of
+// tickers and rebalance schedule. each expression is only scored once,
+// over every schedule's rebalance days, and its price cache is shared by
+// every simulation that uses it. failed combinations are reported rather
+// than failing the sweep
+func (h BacktestHandler) Sweep(ctx context.Context, in SweepInput) (*SweepResult, error) {
+	profile, endProfile := domain.GetProfile(ctx)
+	defer endProfile()
+
+	if err := in.Validate(); err != nil {
+		return nil, err
+	}
+
+	membership, err := h.AssetUniverseRepository.GetMembership(in.Base.AssetUniverse, in.Base.BacktestStart, in.Base.BacktestEnd)
+	if err != nil {
+		return nil, err
+	}
+	tickers := membership.Tickers()
+	if len(tickers) == 0 {
+		return nil, fmt.Errorf("no tickers found")
+	}
+	universeSymbols := []string{}
+	for _, u := range tickers {
+		universeSymbols = append(universeSymbols, u.Symbol)
+	}
+
+	numTickers := in.NumTickers
+	if len(numTickers) == 0 {
+		numTickers = []int{in.Base.NumTickers}
+	}
+	schedules := in.RebalanceSchedules
+	if len(schedules) == 0 {
+		schedules = []domain.RebalanceSchedule{in.Base.RebalanceSchedule}
+	}
+
+	allDays := []scheduleDays{}
+	scoringDaySet := map[time.Time]bool{}
+	for _, schedule := range schedules {
+		tradingDays, err := h.calculateRelevantTradingDays(in.Base.BacktestStart, in.Base.BacktestEnd, schedule)
+		if err != nil {
+			return nil, err
+		}
+		if len(tradingDays) == 0 {
+			return nil, fmt.Errorf("failed to backtest: no calculated trading days in given range")
+		}
+		fillDates, err := h.calculateFillDates(tradingDays, in.Base.BacktestEnd, in.Base.ExecutionDelay)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range tradingDays {
+			scoringDaySet[t] = true
+		}
+		allDays = append(allDays, scheduleDays{
+			schedule:    schedule,
+			tradingDays: tradingDays,
+			fillDates:   fillDates,
+		})
+	}
+	scoringDays := []time.Time{}
+	for t := range scoringDaySet {
+		scoringDays = append(scoringDays, t)
+	}
+	sort.Slice(scoringDays, func(i, j int) bool {
+		return scoringDays[i].Before(scoringDays[j])
+	})
+
+	// the filter doesn't depend on the swept parameters, so it's
+	// only applied once
+	members := membersByDay(membership, scoringDays)
+	if in.Base.UniverseFilter != nil {
+		span, endSpan := profile.StartNewSpan("filtering universe")
+		members, err = h.applyUniverseFilter(domain.NewCtxWithSubProfile(ctx, span), scoringDays, members, *in.Base.UniverseFilter)
+		endSpan()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	weightingDataByDay, err := h.weightingData(ctx, scoringDays, members, in.Base.Weighting)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &SweepResult{Combinations: []SweepCombination{}}
+	for _, values := range parameterGrid(in.Parameters) {
+		expression := in.expression(values)
+
+		span, endSpan := profile.StartNewSpan(fmt.Sprintf("scoring %s", expression))
+		scoresByDay, priceCache, scoreErr := h.FactorExpressionService.CalculateFactorScoresForMembers(domain.NewCtxWithSubProfile(ctx, span), scoringDays, members, expression)
+		endSpan()
+		if scoreErr != nil {
+			scoreErr = fmt.Errorf("failed to calculate factor scores: %w", scoreErr)
+		}
+
+		for _, days := range allDays {
+			for _, n := range numTickers {
+				combination := SweepCombination{
+					ParameterValues:   values,
+					NumTickers:        n,
+					RebalanceSchedule: days.schedule,
+					FactorExpression:  expression,
+				}
+				if scoreErr != nil {
+					combination.Err = scoreErr
+					out.Combinations = append(out.Combinations, combination)
+					continue
+				}
+				if len(days.fillDates) == 0 {
+					combination.Err = fmt.Errorf("execution delay of %d days leaves no trades in given range", in.Base.ExecutionDelay)
+					out.Combinations = append(out.Combinations, combination)
+					continue
+				}
+
+				backtestInput := in.Base
+				backtestInput.FactorExpression = expression
+				backtestInput.NumTickers = n
+				backtestInput.RebalanceSchedule = days.schedule
+				// walk forward windows are per backtest, not per sweep
+				backtestInput.WalkForward = nil
+
+				resp, err := h.simulate(ctx, profile, backtestInput, backtestScores{
+					tickers:            tickers,
+					universeSymbols:    universeSymbols,
+					scoresByDay:        scoresByDay,
+					priceCache:         priceCache,
+					weightingDataByDay: weightingDataByDay,
+				}, days.tradingDays, days.fillDates)
+				if err != nil {
+					combination.Err = err
+				} else {
+					combination.EquityCurve = resp.EquityCurve
+				}
+				out.Combinations = append(out.Combinations, combination)
+			}
+		}
+	}
+
+	return out, nil
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_substitutePlaceholders(t *testing.T) {
+	expression := "pricePercentChange(nMonthsAgo($lookback), currentDate) / $lookback + $skip"
+	require.Equal(t, []string{"lookback", "skip"}, PlaceholderNames(expression))
+	require.Equal(
+		t,
+		"pricePercentChange(nMonthsAgo(6), currentDate) / 6 + 0.5",
+		substitutePlaceholders(expression, map[string]float64{"lookback": 6, "skip": 0.5}),
+	)
+}
+
+func Test_parameterGrid(t *testing.T) {
+	grid := parameterGrid([]SweepParameter{
+		{Name: "a", Values: []float64{1, 2}},
+		{Name: "b", Values: []float64{10, 20, 30}},
+	})
+	require.Equal(t, [][]float64{
+		{1, 10}, {1, 20}, {1, 30},
+		{2, 10}, {2, 20}, {2, 30},
+	}, grid)
+
+	require.Equal(t, [][]float64{{}}, parameterGrid(nil))
+}
+
+func TestSweepInput_Validate(t *testing.T) {
+	in := SweepInput{
+		Base: BacktestInput{FactorExpression: "$a + $b"},
+		Parameters: []SweepParameter{
+			{Name: "a", Values: []float64{1}},
+		},
+	}
+	require.ErrorContains(t, in.Validate(), "$b")
+
+	in.Parameters = append(in.Parameters, SweepParameter{Name: "b", Values: make([]float64, 201)})
+	require.ErrorContains(t, in.Validate(), "201 combinations")
+
+	in.Parameters[1].Values = []float64{1, 2}
+	require.NoError(t, in.Validate())
+
+	in = SweepInput{
+		Base: BacktestInput{FactorExpression: "pricePercentChange(nMonthsAgo($lookback), currentDate)"},
+		Parameters: []SweepParameter{
+			{Name: "lookback", Values: []float64{6, 6.5}},
+		},
+	}
+	require.ErrorContains(t, in.Validate(), "nMonthsAgo(6.5)")
+}
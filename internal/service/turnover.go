@@ -0,0 +1,115 @@
+package service
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// TurnoverReport describes how much a backtest traded. turnover is
+// one-sided, i.e. half the traded notional over portfolio value, so
+// fully replacing the portfolio is a turnover of 1
+type TurnoverReport struct {
+	Rebalances []RebalanceTurnover
+	// average yearly turnover, not counting the initial investment
+	AnnualizedTurnover float64
+	// calendar days a name was held, averaged over positions that were
+	// closed. nil when nothing was ever sold out of
+	AverageHoldingPeriodDays *float64
+	// every trade in the backtest, in order
+	Trades []TradeRecord
+}
+
+type RebalanceTurnover struct {
+	Date       time.Time
+	Turnover   float64
+	NumEntered int
+	NumExited  int
+	NumTrades  int
+}
+
+type TradeRecord struct {
+	Date     time.Time
+	Symbol   string
+	Quantity float64
+	Price    float64
+	// signed, so buys are positive
+	Amount float64
+}
+
+func calculateTurnover(results []BacktestResult, end time.Time) TurnoverReport {
+	out := TurnoverReport{
+		Rebalances: []RebalanceTurnover{},
+		Trades:     []TradeRecord{},
+	}
+	if len(results) == 0 {
+		return out
+	}
+
+	// when each currently held name was bought into
+	entryDates := map[string]time.Time{}
+	totalTurnover := 0.0
+	holdingDays := []float64{}
+
+	for i, r := range results {
+		rebalance := RebalanceTurnover{
+			Date:      r.Date,
+			NumTrades: len(r.Trades),
+		}
+
+		traded := decimal.Zero
+		for _, t := range r.Trades {
+			amount := t.ExactQuantity.Mul(t.ExpectedPrice)
+			traded = traded.Add(amount.Abs())
+			out.Trades = append(out.Trades, TradeRecord{
+				Date:     r.Date,
+				Symbol:   t.Symbol,
+				Quantity: t.ExactQuantity.InexactFloat64(),
+				Price:    t.ExpectedPrice.InexactFloat64(),
+				Amount:   amount.InexactFloat64(),
+			})
+		}
+		if r.TotalValue != 0 {
+			rebalance.Turnover = traded.InexactFloat64() / 2 / r.TotalValue
+		}
+		if i > 0 {
+			totalTurnover += rebalance.Turnover
+		}
+
+		for symbol, position := range r.Portfolio.Positions {
+			if position.ExactQuantity.IsZero() {
+				continue
+			}
+			if _, ok := entryDates[symbol]; !ok {
+				entryDates[symbol] = r.Date
+				rebalance.NumEntered++
+			}
+		}
+		for symbol, entered := range entryDates {
+			position, ok := r.Portfolio.Positions[symbol]
+			if !ok || position.ExactQuantity.IsZero() {
+				holdingDays = append(holdingDays, r.Date.Sub(entered).Hours()/24)
+				delete(entryDates, symbol)
+				rebalance.NumExited++
+			}
+		}
+
+		out.Rebalances = append(out.Rebalances, rebalance)
+	}
+
+	numYears := end.Sub(results[0].Date).Hours() / (365 * 24)
+	if numYears > 0 {
+		out.AnnualizedTurnover = totalTurnover / numYears
+	}
+
+	if len(holdingDays) > 0 {
+		total := 0.0
+		for _, d := range holdingDays {
+			total += d
+		}
+		avg := total / float64(len(holdingDays))
+		out.AverageHoldingPeriodDays = &avg
+	}
+
+	return out
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"factorbacktest/internal/domain"
+	"factorbacktest/internal/util"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_calculateTurnover(t *testing.T) {
+	portfolio := func(quantities map[string]float64) domain.Portfolio {
+		p := domain.NewPortfolio()
+		for symbol, qty := range quantities {
+			p.Positions[symbol] = &domain.Position{Symbol: symbol, ExactQuantity: decimal.NewFromFloat(qty)}
+		}
+		return *p
+	}
+	trade := func(symbol string, qty int64) domain.ProposedTrade {
+		return domain.ProposedTrade{
+			Symbol:        symbol,
+			ExactQuantity: decimal.NewFromInt(qty),
+			ExpectedPrice: decimal.NewFromInt(10),
+		}
+	}
+
+	results := []BacktestResult{
+		{
+			Date:       util.NewDate(2020, 1, 1),
+			TotalValue: 200,
+			Portfolio:  portfolio(map[string]float64{"A": 10, "B": 10}),
+			Trades:     []domain.ProposedTrade{trade("A", 10), trade("B", 10)},
+		},
+		{
+			Date:       util.NewDate(2020, 7, 1),
+			TotalValue: 200,
+			Portfolio:  portfolio(map[string]float64{"A": 10, "C": 10}),
+			Trades:     []domain.ProposedTrade{trade("B", -10), trade("C", 10)},
+		},
+	}
+
+	report := calculateTurnover(results, util.NewDate(2021, 1, 1))
+
+	require.Len(t, report.Rebalances, 2)
+	// buying in from cash is only one side
+	require.Equal(t, 0.5, report.Rebalances[0].Turnover)
+	require.Equal(t, 2, report.Rebalances[0].NumEntered)
+	require.Equal(t, 0.5, report.Rebalances[1].Turnover)
+	require.Equal(t, 1, report.Rebalances[1].NumEntered)
+	require.Equal(t, 1, report.Rebalances[1].NumExited)
+
+	// one half-portfolio swap over a year
+	require.InDelta(t, 0.5, report.AnnualizedTurnover, 0.01)
+	require.NotNil(t, report.AverageHoldingPeriodDays)
+	require.Equal(t, 182.0, *report.AverageHoldingPeriodDays)
+
+	require.Len(t, report.Trades, 4)
+	require.Equal(t, -100.0, report.Trades[2].Amount)
+}
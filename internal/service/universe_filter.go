@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"factorbacktest/internal/calculator"
+	"factorbacktest/internal/db/models/postgres/public/model"
+	"factorbacktest/internal/domain"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// UniverseFilter narrows the base universe on every rebalance date, using
+// the same functions as factor expressions, e.g. only names trading above
+// $5, or the 500 largest by market cap. stored on the strategy as json
+type UniverseFilter struct {
+	// optional - boolean expression a ticker must satisfy, e.g.
+	// price(currentDate) > 5 && stdev(nYearsAgo(1), currentDate) < 0.6
+	Condition string `json:"condition,omitempty"`
+	// optional - keeps the Top tickers with the highest value of this
+	// expression, after applying Condition. negate it to keep the lowest
+	RankBy string `json:"rankBy,omitempty"`
+	Top    int    `json:"top,omitempty"`
+}
+
+// ParseUniverseFilter reads the filter saved on a strategy, if it has one
+func ParseUniverseFilter(s *string) (*UniverseFilter, error) {
+	if s == nil {
+		return nil, nil
+	}
+	out := UniverseFilter{}
+	if err := json.Unmarshal([]byte(*s), &out); err != nil {
+		return nil, fmt.Errorf("failed to parse universe filter: %w", err)
+	}
+	return &out, nil
+}
+
+func (f UniverseFilter) Validate() error {
+	if f.Condition == "" && f.RankBy == "" {
+		return fmt.Errorf("universe filter needs a condition or a ranking")
+	}
+	if f.RankBy != "" && f.Top <= 0 {
+		return fmt.Errorf("universe filter ranking needs a positive number of tickers to keep")
+	}
+	if f.RankBy == "" && f.Top != 0 {
+		return fmt.Errorf("universe filter can only keep the top tickers when ranking")
+	}
+	if f.Condition != "" {
+		if err := calculator.ValidateConditionExpression(f.Condition); err != nil {
+			return fmt.Errorf("invalid universe filter condition: %w", err)
+		}
+	}
+	if f.RankBy != "" {
+		if err := calculator.ValidateFactorExpression(f.RankBy); err != nil {
+			return fmt.Errorf("invalid universe filter ranking: %w", err)
+		}
+	}
+	return nil
+}
+
+// applyUniverseFilter returns who's left in the universe on each day after
+// the filter. tickers the filter can't be evaluated for, e.g. from
+// missing data, are left out
+func (h BacktestHandler) applyUniverseFilter(
+	ctx context.Context,
+	tradingDays []time.Time,
+	tickersByDay map[time.Time][]model.Ticker,
+	filter UniverseFilter,
+) (map[time.Time][]model.Ticker, error) {
+	return ApplyUniverseFilter(ctx, h.FactorExpressionService, tradingDays, tickersByDay, filter)
+}
+
+// ApplyUniverseFilter is applyUniverseFilter for callers outside a
+// backtest, e.g. strategy summaries
+func ApplyUniverseFilter(
+	ctx context.Context,
+	factorExpressionService calculator.FactorExpressionService,
+	tradingDays []time.Time,
+	tickersByDay map[time.Time][]model.Ticker,
+	filter UniverseFilter,
+) (map[time.Time][]model.Ticker, error) {
+	profile, endProfile := domain.GetProfile(ctx)
+	defer endProfile()
+
+	out := tickersByDay
+	if filter.Condition != "" {
+		span, endSpan := profile.StartNewSpan("evaluating universe filter condition")
+		scoresByDay, _, err := factorExpressionService.CalculateFactorScoresForMembers(
+			domain.NewCtxWithSubProfile(ctx, span),
+			tradingDays,
+			out,
+			filter.conditionExpression(),
+		)
+		endSpan()
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate universe filter condition: %w", err)
+		}
+		out = filterByCondition(out, scoresByDay)
+	}
+
+	if filter.RankBy != "" {
+		span, endSpan := profile.StartNewSpan("ranking universe")
+		scoresByDay, _, err := factorExpressionService.CalculateFactorScoresForMembers(
+			domain.NewCtxWithSubProfile(ctx, span),
+			tradingDays,
+			out,
+			filter.RankBy,
+		)
+		endSpan()
+		if err != nil {
+			return nil, fmt.Errorf("failed to rank universe: %w", err)
+		}
+		out = filterByRank(out, scoresByDay, filter.Top)
+	}
+
+	return out, nil
+}
+
+// filterLatestUniverse applies the filter using the latest prices, the
+// same way live rebalances score
+func filterLatestUniverse(
+	ctx context.Context,
+	factorExpressionService calculator.FactorExpressionService,
+	tickers []model.Ticker,
+	filter UniverseFilter,
+) ([]model.Ticker, error) {
+	// the filters work day by day, so everything goes on one day
+	day := time.Time{}
+	out := map[time.Time][]model.Ticker{day: tickers}
+	if filter.Condition != "" {
+		scores, err := factorExpressionService.CalculateLatestFactorScores(ctx, out[day], filter.conditionExpression())
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate universe filter condition: %w", err)
+		}
+		out = filterByCondition(out, map[time.Time]*calculator.ScoresResultsOnDay{day: scores})
+	}
+	if filter.RankBy != "" {
+		scores, err := factorExpressionService.CalculateLatestFactorScores(ctx, out[day], filter.RankBy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to rank universe: %w", err)
+		}
+		out = filterByRank(out, map[time.Time]*calculator.ScoresResultsOnDay{day: scores}, filter.Top)
+	}
+	return out[day], nil
+}
+
+// conditionExpression scores the condition as 1 or 0, since the scoring
+// pipeline only deals in numbers
+func (f UniverseFilter) conditionExpression() string {
+	return fmt.Sprintf("(%s) ? 1.0 : 0.0", f.Condition)
+}
+
+func filterByCondition(tickersByDay map[time.Time][]model.Ticker, scoresByDay map[time.Time]*calculator.ScoresResultsOnDay) map[time.Time][]model.Ticker {
+	out := map[time.Time][]model.Ticker{}
+	for day, tickers := range tickersByDay {
+		out[day] = []model.Ticker{}
+		scores, ok := scoresByDay[day]
+		if !ok {
+			continue
+		}
+		for _, t := range tickers {
+			if score, ok := scores.SymbolScores[t.Symbol]; ok && score != nil && *score == 1 {
+				out[day] = append(out[day], t)
+			}
+		}
+	}
+	return out
+}
+
+// filterByRank keeps the top n tickers on each day, breaking ties by
+// symbol so reruns pick the same names
+func filterByRank(tickersByDay map[time.Time][]model.Ticker, scoresByDay map[time.Time]*calculator.ScoresResultsOnDay, n int) map[time.Time][]model.Ticker {
+	out := map[time.Time][]model.Ticker{}
+	for day, tickers := range tickersByDay {
+		out[day] = []model.Ticker{}
+		scores, ok := scoresByDay[day]
+		if !ok {
+			continue
+		}
+		ranked := []model.Ticker{}
+		for _, t := range tickers {
+			if score, ok := scores.SymbolScores[t.Symbol]; ok && score != nil {
+				ranked = append(ranked, t)
+			}
+		}
+		sort.Slice(ranked, func(i, j int) bool {
+			a, b := *scores.SymbolScores[ranked[i].Symbol], *scores.SymbolScores[ranked[j].Symbol]
+			if a != b {
+				return a > b
+			}
+			return ranked[i].Symbol < ranked[j].Symbol
+		})
+		if len(ranked) > n {
+			ranked = ranked[:n]
+		}
+		sort.Slice(ranked, func(i, j int) bool {
+			return ranked[i].Symbol < ranked[j].Symbol
+		})
+		out[day] = ranked
+	}
+	return out
+}
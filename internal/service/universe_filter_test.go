@@ -0,0 +1,53 @@
+package service
+
+import (
+	"factorbacktest/internal/calculator"
+	"factorbacktest/internal/db/models/postgres/public/model"
+	"factorbacktest/internal/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_filterUniverse(t *testing.T) {
+	day := util.NewDate(2020, 1, 2)
+	tickers := []model.Ticker{{Symbol: "A"}, {Symbol: "B"}, {Symbol: "C"}, {Symbol: "D"}}
+	score := func(f float64) *float64 { return &f }
+	symbols := func(tickers []model.Ticker) []string {
+		out := []string{}
+		for _, t := range tickers {
+			out = append(out, t.Symbol)
+		}
+		return out
+	}
+
+	t.Run("condition", func(t *testing.T) {
+		out := filterByCondition(
+			map[time.Time][]model.Ticker{day: tickers},
+			map[time.Time]*calculator.ScoresResultsOnDay{day: {
+				// D couldn't be evaluated
+				SymbolScores: map[string]*float64{"A": score(1), "B": score(0), "C": score(1)},
+			}},
+		)
+		require.Equal(t, []string{"A", "C"}, symbols(out[day]))
+	})
+
+	t.Run("rank", func(t *testing.T) {
+		out := filterByRank(
+			map[time.Time][]model.Ticker{day: tickers},
+			map[time.Time]*calculator.ScoresResultsOnDay{day: {
+				SymbolScores: map[string]*float64{"A": score(1), "B": score(5), "C": score(3), "D": score(5)},
+			}},
+			3,
+		)
+		require.Equal(t, []string{"B", "C", "D"}, symbols(out[day]))
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		require.Error(t, UniverseFilter{}.Validate())
+		require.Error(t, UniverseFilter{RankBy: "marketCap(currentDate)"}.Validate())
+		require.Error(t, UniverseFilter{Condition: "price(currentDate) > 5", Top: 10}.Validate())
+		require.NoError(t, UniverseFilter{Condition: "price(currentDate) > 5", RankBy: "marketCap(currentDate)", Top: 500}.Validate())
+	})
+}
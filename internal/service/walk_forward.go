@@ -0,0 +1,116 @@
+package service
+
+import (
+	"fmt"
+	"time"
+)
+
+// WalkForwardOptions splits a backtest into alternating in-sample and
+// out-of-sample windows. rolling windows keep the in-sample length fixed,
+// anchored ones always start in-sample at the beginning of the backtest
+type WalkForwardOptions struct {
+	InSampleMonths    int
+	OutOfSampleMonths int
+	Anchored          bool
+}
+
+func (o WalkForwardOptions) Validate() error {
+	if o.InSampleMonths <= 0 || o.OutOfSampleMonths <= 0 {
+		return fmt.Errorf("walk forward windows must be at least 1 month")
+	}
+	return nil
+}
+
+type WalkForwardResult struct {
+	Folds []WalkForwardFold
+	// every out-of-sample window chained together, as if we'd only
+	// ever held the strategy out of sample
+	StitchedOutOfSample []EquityPoint
+}
+
+type WalkForwardFold struct {
+	InSampleStart    time.Time
+	OutOfSampleStart time.Time
+	OutOfSampleEnd   time.Time
+	InSample         []EquityPoint
+	// starts on the last in-sample day, so the first out-of-sample
+	// day's return is counted
+	OutOfSample []EquityPoint
+}
+
+// splitWalkForward carves the equity curve into folds. the expression is
+// fixed, so each window's performance comes straight from the one backtest
+// rather than re-running it per window
+func splitWalkForward(curve []EquityPoint, start, end time.Time, options WalkForwardOptions) (*WalkForwardResult, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	out := &WalkForwardResult{
+		Folds:               []WalkForwardFold{},
+		StitchedOutOfSample: []EquityPoint{},
+	}
+	for i := 0; ; i++ {
+		inSampleStart := start
+		if !options.Anchored {
+			inSampleStart = start.AddDate(0, i*options.OutOfSampleMonths, 0)
+		}
+		outOfSampleStart := start.AddDate(0, options.InSampleMonths+i*options.OutOfSampleMonths, 0)
+		if !outOfSampleStart.Before(end) {
+			break
+		}
+		outOfSampleEnd := outOfSampleStart.AddDate(0, options.OutOfSampleMonths, 0)
+		if outOfSampleEnd.After(end) {
+			outOfSampleEnd = end
+		}
+
+		fold := WalkForwardFold{
+			InSampleStart:    inSampleStart,
+			OutOfSampleStart: outOfSampleStart,
+			OutOfSampleEnd:   outOfSampleEnd,
+			InSample:         []EquityPoint{},
+			OutOfSample:      []EquityPoint{},
+		}
+		for _, p := range curve {
+			if !p.Date.Before(inSampleStart) && p.Date.Before(outOfSampleStart) {
+				fold.InSample = append(fold.InSample, p)
+			}
+			// out-of-sample windows don't overlap. the last one
+			// runs through the end of the backtest
+			inWindow := p.Date.Before(outOfSampleEnd) || (outOfSampleEnd.Equal(end) && p.Date.Equal(end))
+			if !p.Date.Before(outOfSampleStart) && inWindow {
+				fold.OutOfSample = append(fold.OutOfSample, p)
+			}
+		}
+		if len(fold.InSample) < 2 || len(fold.OutOfSample) == 0 {
+			continue
+		}
+		fold.OutOfSample = append([]EquityPoint{fold.InSample[len(fold.InSample)-1]}, fold.OutOfSample...)
+
+		out.Folds = append(out.Folds, fold)
+	}
+
+	if len(out.Folds) == 0 {
+		return nil, fmt.Errorf("backtest range is too short for %d month in-sample and %d month out-of-sample windows", options.InSampleMonths, options.OutOfSampleMonths)
+	}
+
+	for _, fold := range out.Folds {
+		segment := fold.OutOfSample
+		if len(out.StitchedOutOfSample) == 0 {
+			out.StitchedOutOfSample = append(out.StitchedOutOfSample, segment...)
+			continue
+		}
+		// the segment's first point is the previous window's
+		// last, so only its returns carry over
+		last := out.StitchedOutOfSample[len(out.StitchedOutOfSample)-1]
+		scale := last.Value / segment[0].Value
+		for _, p := range segment[1:] {
+			out.StitchedOutOfSample = append(out.StitchedOutOfSample, EquityPoint{
+				Date:  p.Date,
+				Value: p.Value * scale,
+			})
+		}
+	}
+
+	return out, nil
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"factorbacktest/internal/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_splitWalkForward(t *testing.T) {
+	// one point on the first of every month in 2020, plus year end,
+	// growing 1% a month
+	curve := []EquityPoint{}
+	value := 100.0
+	for m := 1; m <= 12; m++ {
+		curve = append(curve, EquityPoint{Date: util.NewDate(2020, m, 1), Value: value})
+		value *= 1.01
+	}
+	end := util.NewDate(2020, 12, 31)
+	curve = append(curve, EquityPoint{Date: end, Value: value})
+
+	t.Run("rolling", func(t *testing.T) {
+		result, err := splitWalkForward(curve, util.NewDate(2020, 1, 1), end, WalkForwardOptions{
+			InSampleMonths:    6,
+			OutOfSampleMonths: 3,
+		})
+		require.NoError(t, err)
+		require.Len(t, result.Folds, 2)
+
+		first := result.Folds[0]
+		require.Len(t, first.InSample, 6)
+		// jun 1 carried over, then jul, aug, sep
+		require.Len(t, first.OutOfSample, 4)
+		require.Equal(t, util.NewDate(2020, 6, 1), first.OutOfSample[0].Date)
+
+		second := result.Folds[1]
+		require.Equal(t, util.NewDate(2020, 4, 1), second.InSampleStart)
+		require.Equal(t, util.NewDate(2020, 10, 1), second.OutOfSampleStart)
+		// sep 1 carried over, then oct, nov, dec, and year end
+		require.Len(t, second.OutOfSample, 5)
+
+		// jun 1 through year end with no gaps or repeats
+		require.Len(t, result.StitchedOutOfSample, 8)
+		require.InDelta(t, value, result.StitchedOutOfSample[7].Value, 1e-9)
+	})
+
+	t.Run("anchored", func(t *testing.T) {
+		result, err := splitWalkForward(curve, util.NewDate(2020, 1, 1), end, WalkForwardOptions{
+			InSampleMonths:    6,
+			OutOfSampleMonths: 3,
+			Anchored:          true,
+		})
+		require.NoError(t, err)
+		require.Len(t, result.Folds[1].InSample, 9)
+	})
+
+	t.Run("too short", func(t *testing.T) {
+		_, err := splitWalkForward(curve, util.NewDate(2020, 1, 1), end, WalkForwardOptions{
+			InSampleMonths:    12,
+			OutOfSampleMonths: 3,
+		})
+		require.Error(t, err)
+	})
+}
@@ -1,6 +1,7 @@
 package internal
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
 	"sort"
@@ -27,20 +28,108 @@ type CalculateTargetAssetWeightsInput struct {
 	Date                 time.Time
 	FactorScoresBySymbol map[string]*float64
 	NumTickers           int
+	// optional - nil is long-only and fully invested
+	LongShort *LongShortOptions
+	// optional - nil is unconstrained. MinPositionDollars
+	// should already be folded into MinWeight
+	Constraints *PositionConstraints
+	// only needed for MaxNamesPerSector
+	SectorBySymbol map[string]string
+	Weighting      WeightingOptions
+	// values from Weighting.Scheme.DataExpression(), if it has one
+	WeightingDataBySymbol map[string]*float64
+}
+
+// LongShortOptions goes long the top NumTickers and short the bottom
+// NumShortTickers by factor score. exposures are fractions of portfolio
+// value, so 100/100 market neutral is gross 2, net 0. stored on the
+// strategy as json
+type LongShortOptions struct {
+	NumShortTickers int     `json:"numShortTickers"`
+	GrossExposure   float64 `json:"grossExposure"`
+	NetExposure     float64 `json:"netExposure"`
+}
+
+// ParseLongShortOptions reads the long/short book saved on a strategy,
+// if it has one
+func ParseLongShortOptions(s *string) (*LongShortOptions, error) {
+	if s == nil {
+		return nil, nil
+	}
+	out := LongShortOptions{}
+	if err := json.Unmarshal([]byte(*s), &out); err != nil {
+		return nil, fmt.Errorf("failed to parse long/short options: %w", err)
+	}
+	return &out, nil
+}
+
+func (o LongShortOptions) Validate() error {
+	if o.NumShortTickers < 1 {
+		return fmt.Errorf("long/short strategies need at least 1 short ticker, got %d", o.NumShortTickers)
+	}
+	if o.GrossExposure <= 0 {
+		return fmt.Errorf("gross exposure must be positive, got %f", o.GrossExposure)
+	}
+	if math.Abs(o.NetExposure) > o.GrossExposure {
+		return fmt.Errorf("net exposure %f cannot exceed gross exposure %f", o.NetExposure, o.GrossExposure)
+	}
+	return nil
+}
+
+// LongExposure and ShortExposure split gross into each side. short
+// is returned as a positive number
+func (o LongShortOptions) LongExposure() float64 {
+	return (o.GrossExposure + o.NetExposure) / 2
+}
+
+func (o LongShortOptions) ShortExposure() float64 {
+	return (o.GrossExposure - o.NetExposure) / 2
 }
 
 // use a factor strategy and determine what the weight
-// of each asset should be on given date
+// of each asset should be on given date. shorts have
+// negative weights
 func CalculateTargetAssetWeights(in CalculateTargetAssetWeightsInput) (map[string]float64, error) {
-	newWeights, err := calculateWeightsViaNumTickers(
-		in.NumTickers,
-		in.FactorScoresBySymbol,
+	var (
+		newWeights  map[string]float64
+		expectedSum = 1.0
+		err         error
 	)
+	constraints := PositionConstraints{}
+	if in.Constraints != nil {
+		if err := in.Constraints.Validate(); err != nil {
+			return nil, err
+		}
+		constraints = *in.Constraints
+	}
+	invested := 1 - constraints.CashBuffer
+	if err := in.Weighting.Validate(); err != nil {
+		return nil, err
+	}
+	weighting := weightingInput{
+		options:      in.Weighting,
+		dataBySymbol: in.WeightingDataBySymbol,
+	}
+
+	if in.LongShort != nil {
+		newWeights, err = calculateLongShortWeights(in.NumTickers, *in.LongShort, in.FactorScoresBySymbol, weighting, constraints, in.SectorBySymbol)
+		expectedSum = in.LongShort.NetExposure * invested
+	} else {
+		newWeights, err = calculateConstrainedWeights(
+			in.NumTickers,
+			in.FactorScoresBySymbol,
+			weighting,
+			constraints,
+			in.SectorBySymbol,
+			invested,
+		)
+		expectedSum = invested
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to calculate weights: %w", err)
 	}
 
-	// validate new weights add to 100
+	// validate new weights add to 100 (or net exposure)
 	sum := 0.0
 	for symbol, w := range newWeights {
 		if math.IsNaN(w) {
@@ -48,13 +137,86 @@ func CalculateTargetAssetWeights(in CalculateTargetAssetWeightsInput) (map[strin
 		}
 		sum += w
 	}
-	if math.Abs(sum-1) > 0.0001 {
-		return nil, fmt.Errorf("new weight should sum to 1, got %f", sum)
+	if math.Abs(sum-expectedSum) > 0.0001 {
+		return nil, fmt.Errorf("new weight should sum to %f, got %f", expectedSum, sum)
 	}
 
 	return newWeights, nil
 }
 
+// calculateLongShortWeights weights each leg the same way as a long-only
+// book, using negated scores on the short side so the lowest score gets
+// the largest short. each leg is then scaled to its exposure, less any
+// cash buffer. no name is in both legs
+func calculateLongShortWeights(
+	numLongTickers int,
+	options LongShortOptions,
+	factorScoresBySymbol map[string]*float64,
+	weighting weightingInput,
+	constraints PositionConstraints,
+	sectorBySymbol map[string]string,
+) (map[string]float64, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	numScored := 0
+	for _, score := range factorScoresBySymbol {
+		if score != nil {
+			numScored++
+		}
+	}
+	if numScored < numLongTickers+options.NumShortTickers {
+		return nil, fmt.Errorf("long/short portfolio needs %d scored assets but only %d have scores", numLongTickers+options.NumShortTickers, numScored)
+	}
+
+	negatedScores := map[string]*float64{}
+	for symbol, score := range factorScoresBySymbol {
+		if score != nil {
+			negated := -*score
+			negatedScores[symbol] = &negated
+		}
+	}
+
+	// constraints can push either leg past its usual names, so the legs
+	// are kept apart: the long leg can't take the bottom names the short
+	// leg would pick, and the short leg can't take anything held long
+	invested := 1 - constraints.CashBuffer
+	longCandidates := withoutSymbols(factorScoresBySymbol, topNScores(negatedScores, options.NumShortTickers))
+	longWeights, err := calculateConstrainedWeights(numLongTickers, longCandidates, weighting, constraints, sectorBySymbol, options.LongExposure()*invested)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate long weights: %w", err)
+	}
+
+	shortWeighting := weighting
+	shortWeighting.scoreMagnitudes = true
+	shortWeights, err := calculateConstrainedWeights(options.NumShortTickers, withoutSymbols(negatedScores, longWeights), shortWeighting, constraints, sectorBySymbol, options.ShortExposure()*invested)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate short weights: %w", err)
+	}
+
+	out := map[string]float64{}
+	for symbol, w := range longWeights {
+		out[symbol] = w
+	}
+	for symbol, w := range shortWeights {
+		out[symbol] = -w
+	}
+
+	return out, nil
+}
+
+// withoutSymbols copies scores, leaving out anything in exclude
+func withoutSymbols[T any](scores map[string]*float64, exclude map[string]T) map[string]*float64 {
+	out := map[string]*float64{}
+	for symbol, score := range scores {
+		if _, ok := exclude[symbol]; !ok {
+			out[symbol] = score
+		}
+	}
+	return out
+}
+
 func zScoreBySymbol(factorScoreBySymbol map[string]float64) (map[string]float64, error) {
 	if len(factorScoreBySymbol) < 2 {
 		return nil, fmt.Errorf("cannot compute z-score of less than two values, got %d value(s)", len(factorScoreBySymbol))
@@ -128,6 +290,7 @@ func calculateWeightsRelativeToAnchor(
 func calculateWeightsViaNumTickers(
 	numTickers int,
 	factorScoresBySymbol map[string]*float64,
+	intensity float64,
 ) (map[string]float64, error) {
 	topScores := topNScores(factorScoresBySymbol, numTickers)
 	if len(topScores) != numTickers {
@@ -150,7 +313,7 @@ func calculateWeightsViaNumTickers(
 	return calculateWeightsRelativeToAnchor(
 		originalWeights,
 		topScores,
-		0.999,
+		intensity,
 	)
 }
 
@@ -0,0 +1,163 @@
+package internal
+
+import (
+	"factorbacktest/internal/db/models/postgres/public/model"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// WeightingScheme decides how the selected names are weighted. the
+// empty scheme is the z-score tilt
+type WeightingScheme string
+
+const (
+	WeightingSchemeZScoreTilt        WeightingScheme = "zScoreTilt"
+	WeightingSchemeEqual             WeightingScheme = "equal"
+	WeightingSchemeScoreProportional WeightingScheme = "scoreProportional"
+	WeightingSchemeRank              WeightingScheme = "rank"
+	WeightingSchemeInverseVolatility WeightingScheme = "inverseVolatility"
+	WeightingSchemeMarketCap         WeightingScheme = "marketCap"
+)
+
+// how far the z-score tilt leans away from equal weight, as a
+// fraction of the most it can before a weight goes negative
+const defaultZScoreTiltIntensity = 0.999
+
+type WeightingOptions struct {
+	Scheme WeightingScheme
+	// only used by the z-score tilt. 0 uses the default
+	Intensity float64
+}
+
+func (o WeightingOptions) Validate() error {
+	switch o.Scheme {
+	case "", WeightingSchemeZScoreTilt:
+		if o.Intensity < 0 || o.Intensity > 1 {
+			return fmt.Errorf("weighting intensity must be between 0 and 1, got %f", o.Intensity)
+		}
+		return nil
+	case WeightingSchemeEqual, WeightingSchemeScoreProportional, WeightingSchemeRank, WeightingSchemeInverseVolatility, WeightingSchemeMarketCap:
+		if o.Intensity != 0 {
+			return fmt.Errorf("weighting intensity only applies to %s weighting", WeightingSchemeZScoreTilt)
+		}
+		return nil
+	}
+	return fmt.Errorf("invalid weighting scheme %s", o.Scheme)
+}
+
+// DataExpression is the factor expression for the per-name values the
+// scheme weights by, or empty if it only needs factor scores
+func (s WeightingScheme) DataExpression() string {
+	switch s {
+	case WeightingSchemeInverseVolatility:
+		return "stdev(nYearsAgo(1), currentDate)"
+	case WeightingSchemeMarketCap:
+		return "marketCap(currentDate)"
+	}
+	return ""
+}
+
+type weightingInput struct {
+	options      WeightingOptions
+	dataBySymbol map[string]*float64
+	// the short leg is picked by negated scores, which are usually
+	// negative, so score proportional weights it by their size instead
+	scoreMagnitudes bool
+}
+
+// StrategyWeighting reads the weighting saved on a strategy
+func StrategyWeighting(s model.Strategy) WeightingOptions {
+	out := WeightingOptions{}
+	if s.WeightingScheme != nil {
+		out.Scheme = WeightingScheme(*s.WeightingScheme)
+	}
+	if s.WeightingIntensity != nil {
+		out.Intensity = *s.WeightingIntensity
+	}
+	return out
+}
+
+// calculateWeights picks the top numTickers names by score and weights
+// them, summing to 1. weighting.dataBySymbol holds the values from the
+// scheme's DataExpression
+func calculateWeights(
+	numTickers int,
+	factorScoresBySymbol map[string]*float64,
+	weighting weightingInput,
+) (map[string]float64, error) {
+	options, dataBySymbol := weighting.options, weighting.dataBySymbol
+	if options.Scheme == "" || options.Scheme == WeightingSchemeZScoreTilt {
+		intensity := options.Intensity
+		if intensity == 0 {
+			intensity = defaultZScoreTiltIntensity
+		}
+		return calculateWeightsViaNumTickers(numTickers, factorScoresBySymbol, intensity)
+	}
+
+	topScores := topNScores(factorScoresBySymbol, numTickers)
+	if len(topScores) != numTickers {
+		return nil, fmt.Errorf("target portfolio should have %d assets but calculated scores for %d assets", numTickers, len(topScores))
+	}
+
+	raw := map[string]float64{}
+	switch options.Scheme {
+	case WeightingSchemeEqual:
+		for symbol := range topScores {
+			raw[symbol] = 1
+		}
+	case WeightingSchemeScoreProportional:
+		for symbol, score := range topScores {
+			if weighting.scoreMagnitudes {
+				score = math.Abs(score)
+			}
+			if score <= 0 {
+				return nil, fmt.Errorf("%s weighting needs positive scores, %s has %f", options.Scheme, symbol, score)
+			}
+			raw[symbol] = score
+		}
+	case WeightingSchemeRank:
+		// lowest score gets 1, highest gets n. ties are broken by
+		// symbol so reruns match
+		symbols := []string{}
+		for symbol := range topScores {
+			symbols = append(symbols, symbol)
+		}
+		sort.Slice(symbols, func(i, j int) bool {
+			if topScores[symbols[i]] != topScores[symbols[j]] {
+				return topScores[symbols[i]] < topScores[symbols[j]]
+			}
+			return symbols[i] > symbols[j]
+		})
+		for i, symbol := range symbols {
+			raw[symbol] = float64(i + 1)
+		}
+	case WeightingSchemeInverseVolatility, WeightingSchemeMarketCap:
+		for symbol := range topScores {
+			value, ok := dataBySymbol[symbol]
+			if !ok || value == nil {
+				return nil, fmt.Errorf("%s weighting is missing data for %s", options.Scheme, symbol)
+			}
+			if *value <= 0 {
+				return nil, fmt.Errorf("%s weighting needs positive values, %s has %f", options.Scheme, symbol, *value)
+			}
+			if options.Scheme == WeightingSchemeInverseVolatility {
+				raw[symbol] = 1 / *value
+			} else {
+				raw[symbol] = *value
+			}
+		}
+	default:
+		return nil, fmt.Errorf("invalid weighting scheme %s", options.Scheme)
+	}
+
+	total := 0.0
+	for _, w := range raw {
+		total += w
+	}
+	out := map[string]float64{}
+	for symbol, w := range raw {
+		out[symbol] = w / total
+	}
+	return out, nil
+}
@@ -0,0 +1,167 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_calculateLongShortWeights(t *testing.T) {
+	scores := map[string]*float64{}
+	for symbol, score := range map[string]float64{
+		"A": 5, "B": 4, "C": 3, "D": 2, "E": 1, "F": 0,
+	} {
+		s := score
+		scores[symbol] = &s
+	}
+
+	t.Run("market neutral", func(t *testing.T) {
+		weights, err := CalculateTargetAssetWeights(CalculateTargetAssetWeightsInput{
+			FactorScoresBySymbol: scores,
+			NumTickers:           2,
+			LongShort: &LongShortOptions{
+				NumShortTickers: 2,
+				GrossExposure:   2,
+				NetExposure:     0,
+			},
+		})
+		require.NoError(t, err)
+
+		require.Len(t, weights, 4)
+		require.Greater(t, weights["A"], weights["B"])
+		require.Greater(t, weights["B"], 0.0)
+		// lowest score gets the biggest short
+		require.Less(t, weights["F"], weights["E"])
+		require.Less(t, weights["E"], 0.0)
+		require.InDelta(t, 1, weights["A"]+weights["B"], 0.0001)
+		require.InDelta(t, -1, weights["E"]+weights["F"], 0.0001)
+	})
+
+	t.Run("130/30", func(t *testing.T) {
+		weights, err := CalculateTargetAssetWeights(CalculateTargetAssetWeightsInput{
+			FactorScoresBySymbol: scores,
+			NumTickers:           3,
+			LongShort: &LongShortOptions{
+				NumShortTickers: 3,
+				GrossExposure:   1.6,
+				NetExposure:     1,
+			},
+		})
+		require.NoError(t, err)
+		require.InDelta(t, 1.3, weights["A"]+weights["B"]+weights["C"], 0.0001)
+		require.InDelta(t, -0.3, weights["D"]+weights["E"]+weights["F"], 0.0001)
+	})
+
+	t.Run("sector limit doesn't push a name into both legs", func(t *testing.T) {
+		// the long leg runs out of tech names and would otherwise
+		// backfill with E, one of the shorts
+		weights, err := CalculateTargetAssetWeights(CalculateTargetAssetWeightsInput{
+			FactorScoresBySymbol: scores,
+			NumTickers:           2,
+			LongShort: &LongShortOptions{
+				NumShortTickers: 2,
+				GrossExposure:   2,
+			},
+			Constraints:    &PositionConstraints{MaxNamesPerSector: 1},
+			SectorBySymbol: map[string]string{"A": "tech", "B": "tech", "C": "tech", "D": "tech"},
+		})
+		require.NoError(t, err)
+		require.InDelta(t, 1, weights["A"], 0.0001)
+		require.Less(t, weights["E"], 0.0)
+		require.Less(t, weights["F"], 0.0)
+		require.Len(t, weights, 3)
+	})
+
+	t.Run("score proportional shorts", func(t *testing.T) {
+		score := func(f float64) *float64 { return &f }
+		weights, err := CalculateTargetAssetWeights(CalculateTargetAssetWeightsInput{
+			FactorScoresBySymbol: map[string]*float64{"A": score(3), "B": score(1), "C": score(-1), "D": score(-3)},
+			NumTickers:           2,
+			LongShort: &LongShortOptions{
+				NumShortTickers: 2,
+				GrossExposure:   2,
+			},
+			Weighting: WeightingOptions{Scheme: WeightingSchemeScoreProportional},
+		})
+		require.NoError(t, err)
+		require.InDelta(t, 0.75, weights["A"], 0.0001)
+		require.InDelta(t, -0.25, weights["C"], 0.0001)
+		require.InDelta(t, -0.75, weights["D"], 0.0001)
+	})
+
+	t.Run("legs can't overlap", func(t *testing.T) {
+		_, err := CalculateTargetAssetWeights(CalculateTargetAssetWeightsInput{
+			FactorScoresBySymbol: scores,
+			NumTickers:           4,
+			LongShort: &LongShortOptions{
+				NumShortTickers: 3,
+				GrossExposure:   2,
+			},
+		})
+		require.Error(t, err)
+	})
+}
+
+func Test_calculateWeights(t *testing.T) {
+	score := func(f float64) *float64 { return &f }
+	scores := map[string]*float64{"A": score(3), "B": score(1), "C": score(2), "D": score(-1)}
+
+	t.Run("equal", func(t *testing.T) {
+		weights, err := calculateWeights(3, scores, weightingInput{options: WeightingOptions{Scheme: WeightingSchemeEqual}})
+		require.NoError(t, err)
+		require.Equal(t, map[string]float64{"A": 1.0 / 3, "B": 1.0 / 3, "C": 1.0 / 3}, weights)
+	})
+
+	t.Run("score proportional", func(t *testing.T) {
+		weights, err := calculateWeights(3, scores, weightingInput{options: WeightingOptions{Scheme: WeightingSchemeScoreProportional}})
+		require.NoError(t, err)
+		require.InDelta(t, 0.5, weights["A"], 1e-9)
+		require.InDelta(t, 1.0/6, weights["B"], 1e-9)
+
+		_, err = calculateWeights(4, scores, weightingInput{options: WeightingOptions{Scheme: WeightingSchemeScoreProportional}})
+		require.ErrorContains(t, err, "positive scores")
+	})
+
+	t.Run("rank", func(t *testing.T) {
+		weights, err := calculateWeights(3, scores, weightingInput{options: WeightingOptions{Scheme: WeightingSchemeRank}})
+		require.NoError(t, err)
+		require.InDelta(t, 3.0/6, weights["A"], 1e-9)
+		require.InDelta(t, 2.0/6, weights["C"], 1e-9)
+		require.InDelta(t, 1.0/6, weights["B"], 1e-9)
+	})
+
+	t.Run("inverse volatility", func(t *testing.T) {
+		vol := map[string]*float64{"A": score(0.1), "B": score(0.4), "C": score(0.2)}
+		weights, err := calculateWeights(3, scores, weightingInput{options: WeightingOptions{Scheme: WeightingSchemeInverseVolatility}, dataBySymbol: vol})
+		require.NoError(t, err)
+		require.InDelta(t, 4.0/7, weights["A"], 1e-9)
+		require.InDelta(t, 1.0/7, weights["B"], 1e-9)
+
+		delete(vol, "C")
+		_, err = calculateWeights(3, scores, weightingInput{options: WeightingOptions{Scheme: WeightingSchemeInverseVolatility}, dataBySymbol: vol})
+		require.ErrorContains(t, err, "missing data for C")
+	})
+
+	t.Run("market cap", func(t *testing.T) {
+		caps := map[string]*float64{"A": score(100), "B": score(300), "C": score(600)}
+		weights, err := calculateWeights(3, scores, weightingInput{options: WeightingOptions{Scheme: WeightingSchemeMarketCap}, dataBySymbol: caps})
+		require.NoError(t, err)
+		require.InDelta(t, 0.6, weights["C"], 1e-9)
+	})
+
+	t.Run("z-score tilt intensity", func(t *testing.T) {
+		mild, err := calculateWeights(3, scores, weightingInput{options: WeightingOptions{Intensity: 0.1}})
+		require.NoError(t, err)
+		strong, err := calculateWeights(3, scores, weightingInput{options: WeightingOptions{}})
+		require.NoError(t, err)
+		require.Greater(t, mild["B"], strong["B"])
+		require.Less(t, mild["A"], strong["A"])
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		require.Error(t, WeightingOptions{Scheme: "momentum"}.Validate())
+		require.Error(t, WeightingOptions{Scheme: WeightingSchemeEqual, Intensity: 0.5}.Validate())
+		require.Error(t, WeightingOptions{Intensity: 1.5}.Validate())
+		require.NoError(t, WeightingOptions{Scheme: WeightingSchemeZScoreTilt, Intensity: 0.5}.Validate())
+	})
+}
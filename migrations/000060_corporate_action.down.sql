@@ -0,0 +1,5 @@
+drop table corporate_action;
+drop type corporate_action_type;
+
+ALTER TABLE adjusted_price
+DROP COLUMN raw_price;
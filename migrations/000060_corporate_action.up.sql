@@ -0,0 +1,16 @@
+ALTER TABLE adjusted_price
+ADD COLUMN raw_price decimal;
+
+create type corporate_action_type as enum ('DIVIDEND', 'SPLIT');
+
+-- amount is cash per share for dividends, and new shares
+-- per old share for splits (2:1 split = 2)
+create table corporate_action(
+  corporate_action_id uuid primary key default uuid_generate_v4(),
+  symbol text not null,
+  ex_date date not null,
+  corporate_action_type corporate_action_type not null,
+  amount decimal not null,
+  created_at timestamp with time zone not null default now(),
+  unique(symbol, ex_date, corporate_action_type)
+);
@@ -0,0 +1,15 @@
+create or replace view asset_universe_size as
+  select max(display_name) as "display_name", asset_universe_name, count(*) as num_assets
+  from asset_universe inner join asset_universe_ticker on asset_universe.asset_universe_id = asset_universe_ticker.asset_universe_id
+  group by asset_universe_name;
+
+drop index unique_current_asset_in_universe;
+
+delete from asset_universe_ticker where end_date is not null;
+
+alter table asset_universe_ticker
+add constraint unique_asset_in_universe unique (ticker_id, asset_universe_id);
+
+alter table asset_universe_ticker
+drop column start_date,
+drop column end_date;
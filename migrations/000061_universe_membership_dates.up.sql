@@ -0,0 +1,21 @@
+-- null start_date means the ticker was in the universe before we have
+-- history for it, null end_date means it's still a member. end_date is
+-- the first day it's no longer in the universe
+alter table asset_universe_ticker
+add column start_date date,
+add column end_date date;
+
+-- tickers can leave and rejoin a universe, but only have one open
+-- membership at a time
+alter table asset_universe_ticker
+drop constraint unique_asset_in_universe;
+
+create unique index unique_current_asset_in_universe
+on asset_universe_ticker (ticker_id, asset_universe_id)
+where end_date is null;
+
+create or replace view asset_universe_size as
+  select max(display_name) as "display_name", asset_universe_name, count(*) as num_assets
+  from asset_universe inner join asset_universe_ticker on asset_universe.asset_universe_id = asset_universe_ticker.asset_universe_id
+  where asset_universe_ticker.end_date is null
+  group by asset_universe_name;
@@ -0,0 +1,5 @@
+alter table strategy
+drop column position_constraints;
+
+alter table ticker
+drop column sector;
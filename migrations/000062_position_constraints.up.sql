@@ -0,0 +1,10 @@
+-- null when we don't know the sector, which leaves the ticker
+-- out of per-sector limits
+alter table ticker
+add column sector text;
+
+-- limits on how the target portfolio is built, stored as
+-- json so live rebalances construct the same portfolio as
+-- the backtest. null is unconstrained
+alter table strategy
+add column position_constraints json;
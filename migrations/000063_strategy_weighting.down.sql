@@ -0,0 +1,3 @@
+alter table strategy
+drop column weighting_scheme,
+drop column weighting_intensity;
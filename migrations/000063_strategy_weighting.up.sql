@@ -0,0 +1,5 @@
+-- null scheme is the original z-score tilt. intensity only
+-- applies to the z-score tilt, null uses the default
+alter table strategy
+add column weighting_scheme text,
+add column weighting_intensity double precision;
@@ -0,0 +1,4 @@
+drop index asset_fundamental_symbol_available_date;
+
+alter table asset_fundamental
+drop column available_date;
@@ -0,0 +1,14 @@
+-- first day a filing was public. it's weeks after the quarter ends,
+-- so reading fundamentals as of the quarter they cover peeks ahead.
+-- we don't have filing dates for what's already ingested, so assume
+-- the sec's 10-q and 10-k deadlines
+alter table asset_fundamental
+add column available_date date;
+
+update asset_fundamental
+set available_date = end_date + case when extract(month from end_date) = 1 then 90 else 45 end;
+
+alter table asset_fundamental
+alter column available_date set not null;
+
+create index asset_fundamental_symbol_available_date on asset_fundamental(symbol, available_date);
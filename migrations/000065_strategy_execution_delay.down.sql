@@ -0,0 +1,2 @@
+alter table strategy
+drop column execution_delay_days;
@@ -0,0 +1,4 @@
+-- trading days between scoring and filling, so stress tests and
+-- live rebalances of a saved strategy trade on the same days
+alter table strategy
+add column execution_delay_days integer not null default 0;
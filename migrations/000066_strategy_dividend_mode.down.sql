@@ -0,0 +1,2 @@
+alter table strategy
+drop column dividend_mode;
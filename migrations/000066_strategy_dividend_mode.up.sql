@@ -0,0 +1,3 @@
+-- null trades on adjusted prices, otherwise "cash" or "reinvest"
+alter table strategy
+add column dividend_mode text;
@@ -0,0 +1,3 @@
+alter table strategy
+drop column long_short,
+drop column short_borrow_bps;
@@ -0,0 +1,5 @@
+-- the short book as json, null for long only strategies. the
+-- borrow cost is kept apart since it's a cost, not a position
+alter table strategy
+add column long_short json,
+add column short_borrow_bps double precision;
@@ -0,0 +1,3 @@
+-- the universe filter as json, null when the whole universe is scored
+alter table strategy
+add column universe_filter json;
@@ -0,0 +1,22 @@
+-- marketCap, peRatio and pbRatio now read the latest filing that was
+-- public on the date instead of the quarter the date falls in, so
+-- scores cached for expressions using them are stale. factor_score only
+-- has the expression's hash, so rebuild it the way HashFactorExpression
+-- does from every saved strategy's expression
+create temporary table strategy_expression_hash as
+select
+  encode(sha256(convert_to(regexp_replace(factor_expression, '\s+', '', 'g'), 'UTF8')), 'hex') as factor_expression_hash,
+  factor_expression ~ '(marketCap|peRatio|pbRatio)\s*\(' as uses_fundamentals
+from strategy;
+
+-- sweep trials aren't saved as strategies, so there's no telling what
+-- their scores used. drop those too, they get recalculated the next
+-- time they're backtested
+delete from factor_score
+where factor_expression_hash in (
+  select factor_expression_hash from strategy_expression_hash where uses_fundamentals
+) or factor_expression_hash not in (
+  select factor_expression_hash from strategy_expression_hash
+);
+
+drop table strategy_expression_hash;
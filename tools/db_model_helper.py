@@ -10,6 +10,7 @@ update_files = {
   "excess_trade_volume.go",
   "published_strategy_holdings",
   "rebalance_price.go",
+  "corporate_action.go",
 }
 
 os.chdir("./internal/db/models/postgres/public/model/")